@@ -0,0 +1,143 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//AccessEntry is a request served by a handler wrapped with AccessLog
+type AccessEntry struct {
+	//Time is when the request was received
+	Time time.Time `json:"time"`
+	//Identity is the X-Identity header of the request, identifying the service
+	Identity string `json:"identity,omitempty"`
+	//Remote is the address of the client
+	Remote string `json:"remote"`
+	//Method is the method of the request
+	Method string `json:"method"`
+	//Endpoint is the path of the request, such as "/v2/check/bad.com"
+	//the domain of a check request is only included once the check is served, redacted like Domain
+	Endpoint string `json:"endpoint"`
+	//Domain is the checked domain of check requests, redacted as set by Client.SetPrivacy
+	Domain string `json:"domain,omitempty"`
+	//Result is the result of check requests, "hit" or "miss"
+	Result string `json:"result,omitempty"`
+	//Status is the status code of the response
+	Status int `json:"status"`
+	//Bytes is the size of the response body
+	Bytes int64 `json:"bytes"`
+	//Latency is how long the request took to serve
+	Latency Duration `json:"latency"`
+}
+
+//AccessSink receives the entries of AccessLog
+//it's called after each request is served, and may be called concurrently
+type AccessSink interface {
+	LogAccess(entry AccessEntry)
+}
+
+//AccessSinkFunc is a function that implements AccessSink
+type AccessSinkFunc func(entry AccessEntry)
+
+//LogAccess calls f(entry)
+func (f AccessSinkFunc) LogAccess(entry AccessEntry) {
+	f(entry)
+}
+
+//JSONAccessSink writes each entry as a json line into w, write errors are ignored
+func JSONAccessSink(w io.Writer) AccessSink {
+	var m sync.Mutex
+	return AccessSinkFunc(func(entry AccessEntry) {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		m.Lock()
+		defer m.Unlock()
+		_, _ = w.Write(append(b, '\n'))
+	})
+}
+
+//accessEntryKey is the context key of the AccessEntry of a request being logged
+type accessEntryKey struct{}
+
+//AccessLog wraps h to report every request into sink, so operators can audit which services query which domains
+//check requests served by Mirror also report the checked domain and its result
+//check requests rejected before they're served, such as by BearerAuth or RateLimiter, are reported without their domain
+func AccessLog(h http.Handler, sink AccessSink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &AccessEntry{
+			Time:     time.Now(),
+			Identity: r.Header.Get("X-Identity"),
+			Remote:   r.RemoteAddr,
+			Method:   r.Method,
+			Endpoint: accessEndpoint(r.URL.Path),
+		}
+		rw := &accessWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), accessEntryKey{}, entry)))
+		entry.Status = rw.status
+		entry.Bytes = rw.bytes
+		entry.Latency = Duration(time.Since(entry.Time))
+		sink.LogAccess(*entry)
+	})
+}
+
+//accessEndpoint returns the path as reported before the request is served, the domain of check requests is left out
+//as the privacy mode is unknown until logCheck reports the check
+func accessEndpoint(path string) string {
+	if strings.HasPrefix(path, endpointCheck) {
+		return endpointCheck
+	}
+	return path
+}
+
+//logCheck reports a check of the request into its AccessEntry, if it's being logged
+//the domain is redacted as set by Client.SetPrivacy, including in the endpoint
+func logCheck(r *http.Request, c *Client, domain string, phishing bool) {
+	entry, ok := r.Context().Value(accessEntryKey{}).(*AccessEntry)
+	if !ok {
+		return
+	}
+	entry.Domain = c.redact(domain)
+	entry.Endpoint = endpointCheck + entry.Domain
+	entry.Result = "miss"
+	if phishing {
+		entry.Result = "hit"
+	}
+}
+
+//accessWriter records the status and size of a response
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+//Hijack hijacks the underlying connection, so websocket upgrades such as /feed of Mirror keep working
+func (w *accessWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.ResponseWriter does not implement http.Hijacker")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hj.Hijack()
+}
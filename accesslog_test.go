@@ -0,0 +1,120 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAccessLog(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+	mirror := NewMirror(primary)
+	defer mirror.Close()
+
+	var m sync.Mutex
+	var entries []AccessEntry
+	srv := httptest.NewServer(AccessLog(mirror, AccessSinkFunc(func(entry AccessEntry) {
+		m.Lock()
+		defer m.Unlock()
+		entries = append(entries, entry)
+	})))
+	defer srv.Close()
+
+	r := NewRawClient(srv.URL, "checker", *srv.Client())
+	for _, domain := range []string{"bad.com", "good.com"} {
+		_, err := r.Check(domain)
+		a.NoError(err)
+	}
+	_, err := r.Size()
+	a.NoError(err)
+	follower := New(srv.URL, "follower", http.Client{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- follower.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return follower.Listening() }, time.Second, time.Millisecond*10)
+	cancel()
+	<-done
+
+	a.Eventually(func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return len(entries) == 4
+	}, time.Second, time.Millisecond*10)
+	tests := []struct {
+		endpoint string
+		identity string
+		domain   string
+		result   string
+		status   int
+	}{
+		{endpointCheck + "bad.com", "checker", "bad.com", "hit", http.StatusOK},
+		{endpointCheck + "good.com", "checker", "good.com", "miss", http.StatusOK},
+		{endpointSize, "checker", "", "", http.StatusOK},
+		{endpointFeed, "follower", "", "", http.StatusSwitchingProtocols},
+	}
+	for i, data := range tests {
+		entry := entries[i]
+		a.Equal(data.endpoint, entry.Endpoint)
+		a.Equal(data.identity, entry.Identity)
+		a.Equal(data.domain, entry.Domain)
+		a.Equal(data.result, entry.Result)
+		a.Equal(data.status, entry.Status)
+		a.Equal(http.MethodGet, entry.Method)
+	}
+
+	var buf bytes.Buffer
+	JSONAccessSink(&buf).LogAccess(entries[0])
+	var decoded map[string]interface{}
+	a.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	a.Equal("hit", decoded["result"])
+	a.True(strings.HasSuffix(buf.String(), "\n"))
+}
+
+func TestAccessLogRejectedCheck(t *testing.T) {
+	c := New("", "test", http.Client{})
+	c.SetPrivacy(PrivacyOmit, nil)
+	mirror := NewMirror(c)
+	defer mirror.Close()
+	limiter := NewRateLimiter(1, 1)
+
+	var entries []AccessEntry
+	handler := AccessLog(limiter.Wrap(BearerAuth(mirror, "secret")), AccessSinkFunc(func(entry AccessEntry) {
+		entries = append(entries, entry)
+	}))
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		status int
+	}{
+		{name: "unauthorized", method: http.MethodGet, status: http.StatusUnauthorized},
+		{name: "rate limited", method: http.MethodGet, token: "secret", status: http.StatusTooManyRequests},
+	}
+	for i, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			req := httptest.NewRequest(data.method, endpointCheck+"secret-domain.com", nil)
+			if data.token != "" {
+				req.Header.Set("Authorization", "Bearer "+data.token)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if a.Len(entries, i+1) {
+				entry := entries[i]
+				a.Equal(data.status, entry.Status)
+				a.Equal(endpointCheck, entry.Endpoint)
+				a.Empty(entry.Domain)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+//adminBodyLimit bounds the size of admin request bodies
+const adminBodyLimit = 1 << 20
+
+//adminLocal is the body of local domain requests
+type adminLocal struct {
+	Domains []string `json:"domains"`
+	TTL     Duration `json:"ttl,omitempty"`
+	Review  Duration `json:"review,omitempty"`
+}
+
+//adminRollback is the body of rollback requests
+type adminRollback struct {
+	Backup string    `json:"backup,omitempty"`
+	Time   time.Time `json:"time,omitempty"`
+}
+
+//adminHeld is the body of held update requests
+type adminHeld struct {
+	ID uint64 `json:"id"`
+}
+
+//AdminHandler serves an admin api to manage a running Manager without restarting it
+//every request requires "Authorization: Bearer <token>", an empty token rejects every request
+//the following endpoints are served relative to the handler, use http.StripPrefix to mount it under a path
+//  GET /status returns Stats as json
+//  POST /sync forces a FullSync
+//  POST /pause pauses changes to the cache, see Manager.Pause
+//  POST /resume resumes changes to the cache and catches up with a sync, then returns Stats as json
+//  POST /rollback restores the backup from {"backup":"<path>"} or the newest made at or before {"time":"<rfc3339>"}, see Manager.Rollback
+//  it pauses the Manager and returns Stats as json
+//  GET /held returns the updates held by the AnomalyGuard
+//  POST /held/confirm applies the held update from {"id":1}, POST /held/discard drops it
+//  POST /save saves the cache into the configured Store
+//  POST /reload reloads the json Config in the body, see Manager.Reload
+//  GET /local returns local domains with their expiry
+//  POST /local adds local domains from {"domains":[...],"ttl":"1h","review":"720h"}, ttl may be omitted to never expire
+//  review may be omitted to not set a review date, see Client.SetLocalReview
+//  DELETE /local removes local domains from {"domains":[...]}
+//  GET /local/state returns the LocalState of the local domains, for merging into other instances
+//  POST /local/merge merges the LocalState in the body into the local domains with MergeAddWins, and returns the Diff
+func AdminHandler(m *Manager, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", adminMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) error {
+		return writeJSON(w, m.Client().Stats())
+	}))
+	mux.HandleFunc("/sync", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		err := m.Client().FullSync()
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, m.Client().Stats())
+	}))
+	mux.HandleFunc("/pause", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		m.Pause()
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+	mux.HandleFunc("/resume", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		err := m.Resume()
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, m.Client().Stats())
+	}))
+	mux.HandleFunc("/rollback", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		var body adminRollback
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return badRequest{err}
+		}
+		var err error
+		switch {
+		case body.Backup != "":
+			err = m.Rollback(body.Backup)
+		case !body.Time.IsZero():
+			_, err = m.RollbackTo(body.Time)
+		default:
+			return badRequest{errors.New("backup or time is required")}
+		}
+		if errors.Is(err, errUnknownBackup) || errors.Is(err, ErrNoBackup) {
+			return badRequest{err}
+		}
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, m.Client().Stats())
+	}))
+	mux.HandleFunc("/held", adminMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) error {
+		return writeJSON(w, m.Client().HeldUpdates())
+	}))
+	heldFunc := func(fn func(id uint64) error) http.HandlerFunc {
+		return adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+			var body adminHeld
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				return badRequest{err}
+			}
+			if err := fn(body.ID); err != nil {
+				return badRequest{err}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		})
+	}
+	mux.HandleFunc("/held/confirm", heldFunc(m.Client().ConfirmHeld))
+	mux.HandleFunc("/held/discard", heldFunc(m.Client().DiscardHeld))
+	mux.HandleFunc("/save", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		if m.Store() == nil {
+			return errors.New("no store is configured")
+		}
+		err := m.Store().Save(m.Client())
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+	mux.HandleFunc("/reload", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		var cfg Config
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return badRequest{err}
+		}
+		if err := m.Reload(cfg); err != nil {
+			return badRequest{err}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+	mux.HandleFunc("/local", adminFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method == http.MethodGet {
+			return writeJSON(w, m.Client().LocalDomains())
+		}
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			return errMethodNotAllowed
+		}
+		var body adminLocal
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return badRequest{err}
+		}
+		if r.Method == http.MethodPost {
+			m.Client().AddLocal(time.Duration(body.TTL), body.Domains...)
+			if body.Review > 0 {
+				m.Client().SetLocalReview(time.Now().Add(time.Duration(body.Review)), body.Domains...)
+			}
+		} else {
+			m.Client().RemoveLocal(body.Domains...)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+	mux.HandleFunc("/local/state", adminMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) error {
+		return writeJSON(w, m.Client().LocalState())
+	}))
+	mux.HandleFunc("/local/merge", adminMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) error {
+		var state LocalState
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			return badRequest{err}
+		}
+		return writeJSON(w, m.Client().MergeLocal(state, MergeAddWins))
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || !bearerAuthorized(r, token) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, adminBodyLimit)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+//badRequest is an error caused by the request rather than the server
+type badRequest struct {
+	error
+}
+
+//errMethodNotAllowed is returned by admin handlers for unsupported methods
+var errMethodNotAllowed = errors.New(http.StatusText(http.StatusMethodNotAllowed))
+
+//adminMethod wraps fn to only allow method, see adminFunc
+func adminMethod(method string, fn func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return adminFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != method {
+			return errMethodNotAllowed
+		}
+		return fn(w, r)
+	})
+}
+
+//adminFunc wraps fn to respond with its error
+func adminFunc(fn func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := fn(w, r)
+		var bad badRequest
+		switch {
+		case err == nil:
+		case errors.Is(err, errMethodNotAllowed):
+			http.Error(w, err.Error(), http.StatusMethodNotAllowed)
+		case errors.As(err, &bad):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+//writeJSON responds with v as json
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,80 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["a.com","b.com"]`))
+	}))
+	defer srv.Close()
+	cfg := Config{
+		Endpoint: srv.URL,
+		Identity: "test",
+		Store:    StoreConfig{Path: filepath.Join(t.TempDir(), "cache.json")},
+	}
+	m, err := NewManager(cfg)
+	assert.NoError(t, err)
+	h := AdminHandler(m, "secret")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		status int
+		check  func(a *assert.Assertions, body string)
+	}{
+		{name: "unauthorized", method: http.MethodGet, path: "/status", token: "wrong", status: http.StatusUnauthorized},
+		{name: "sync", method: http.MethodPost, path: "/sync", status: http.StatusOK, check: func(a *assert.Assertions, body string) {
+			a.Contains(body, `"domains":2`)
+		}},
+		{name: "status", method: http.MethodGet, path: "/status", status: http.StatusOK},
+		{name: "sync wrong method", method: http.MethodGet, path: "/sync", status: http.StatusMethodNotAllowed},
+		{name: "add local", method: http.MethodPost, path: "/local", body: `{"domains":["c.com"],"ttl":"1h"}`, status: http.StatusNoContent, check: func(a *assert.Assertions, _ string) {
+			a.True(m.Client().Check("c.com"))
+		}},
+		{name: "list local", method: http.MethodGet, path: "/local", status: http.StatusOK, check: func(a *assert.Assertions, body string) {
+			a.Contains(body, `"c.com"`)
+		}},
+		{name: "remove local", method: http.MethodDelete, path: "/local", body: `{"domains":["c.com"]}`, status: http.StatusNoContent, check: func(a *assert.Assertions, _ string) {
+			a.False(m.Client().Check("c.com"))
+		}},
+		{name: "invalid local", method: http.MethodPost, path: "/local", body: `{`, status: http.StatusBadRequest},
+		{name: "pause", method: http.MethodPost, path: "/pause", status: http.StatusNoContent, check: func(a *assert.Assertions, _ string) {
+			a.True(m.Paused())
+		}},
+		{name: "sync while paused", method: http.MethodPost, path: "/sync", status: http.StatusInternalServerError},
+		{name: "resume", method: http.MethodPost, path: "/resume", status: http.StatusOK, check: func(a *assert.Assertions, body string) {
+			a.Contains(body, `"paused":false`)
+		}},
+		{name: "rollback without backups", method: http.MethodPost, path: "/rollback", body: `{"time":"2020-01-01T00:00:00Z"}`, status: http.StatusBadRequest},
+		{name: "save", method: http.MethodPost, path: "/save", status: http.StatusNoContent},
+		{name: "reload", method: http.MethodPost, path: "/reload", body: `{"endpoint":"` + srv.URL + `","identity":"test","store":{"path":"` + cfg.Store.Path + `"},"sweep_interval":"1m"}`, status: http.StatusNoContent},
+		{name: "reload fixed setting", method: http.MethodPost, path: "/reload", body: `{"endpoint":"https://example.com","identity":"test"}`, status: http.StatusBadRequest},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			token := data.token
+			if token == "" {
+				token = "secret"
+			}
+			req := httptest.NewRequest(data.method, data.path, strings.NewReader(data.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			a.Equal(data.status, rec.Code, rec.Body.String())
+			if data.check != nil {
+				data.check(a, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+//alertInterval is how often WatchAlerts checks for alert conditions
+const alertInterval = time.Second * 10
+
+//kinds of alerts raised by WatchAlerts
+const (
+	//AlertSyncFailing is raised when syncs with the api failed too many times in a row
+	AlertSyncFailing = "sync_failing"
+	//AlertFeedDown is raised when the realtime feed has been disconnected for too long
+	AlertFeedDown = "feed_down"
+	//AlertStale is raised when the cache hasn't been synced for too long
+	AlertStale = "stale"
+	//AlertAnomaly is raised when the AnomalyGuard flagged updates
+	AlertAnomaly = "anomaly"
+)
+
+//Alert is a condition operators should be notified about
+type Alert struct {
+	//Kind is the kind of the alert, one of the Alert constants
+	Kind string `json:"kind"`
+	//Message describes the condition
+	Message string `json:"message"`
+	//Time is when the alert was raised
+	Time time.Time `json:"time"`
+	//Resolved is true when the condition of a previously raised alert cleared, anomalies are never resolved
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+//Alerter notifies operators of alerts, such as by paging them
+type Alerter interface {
+	Alert(ctx context.Context, alert Alert) error
+}
+
+//AlerterFunc is a function that implements Alerter
+type AlerterFunc func(ctx context.Context, alert Alert) error
+
+//Alert calls f(ctx, alert)
+func (f AlerterFunc) Alert(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+//MultiAlerter sends alerts to every alerter, the errors of all alerters are joined
+func MultiAlerter(alerters ...Alerter) Alerter {
+	return AlerterFunc(func(ctx context.Context, alert Alert) error {
+		var msgs []string
+		for _, a := range alerters {
+			if err := a.Alert(ctx, alert); err != nil {
+				msgs = append(msgs, err.Error())
+			}
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	})
+}
+
+//WriterAlerter writes every alert as a line into w, such as os.Stderr
+func WriterAlerter(w io.Writer) Alerter {
+	return AlerterFunc(func(_ context.Context, alert Alert) error {
+		state := "firing"
+		if alert.Resolved {
+			state = "resolved"
+		}
+		_, err := fmt.Fprintf(w, "%s alert %s %s: %s\n", alert.Time.Format(time.RFC3339), alert.Kind, state, alert.Message)
+		return err
+	})
+}
+
+//WebhookAlerter posts every alert as json to URL
+type WebhookAlerter struct {
+	//URL receives the alerts
+	URL string
+	//Client sends the requests, defaults to http.DefaultClient
+	Client *http.Client
+	//Header is added to every request, such as for authentication
+	Header http.Header
+}
+
+//Alert posts the alert
+func (a WebhookAlerter) Alert(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range a.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return unexpectedStatusError{
+			endpoint: a.URL,
+			status:   resp.StatusCode,
+		}
+	}
+	return nil
+}
+
+//AlertThresholds are the conditions WatchAlerts raises alerts on, zero values disable a condition
+type AlertThresholds struct {
+	//SyncFailures is the amount of failed syncs in a row that raises AlertSyncFailing
+	SyncFailures int `json:"sync_failures,omitempty"`
+	//FeedDown is how long the realtime feed may be disconnected before AlertFeedDown is raised
+	FeedDown Duration `json:"feed_down,omitempty"`
+	//Stale is how long the cache may go without a sync before AlertStale is raised, it's not raised while paused
+	Stale Duration `json:"stale,omitempty"`
+	//Anomalies raises AlertAnomaly whenever the AnomalyGuard flags updates
+	Anomalies bool `json:"anomalies,omitempty"`
+}
+
+//alertState tracks raised alerts between checks
+type alertState struct {
+	thresholds AlertThresholds
+	firing     map[string]bool
+	feedDown   time.Time
+	anomalies  uint64
+}
+
+//alertInput is what alert conditions are checked against
+type alertInput struct {
+	stats    Stats
+	lastSync time.Time
+	realtime bool
+}
+
+//check returns the alerts raised or resolved since the last check
+func (s *alertState) check(now time.Time, in alertInput) []Alert {
+	var alerts []Alert
+	set := func(kind string, firing bool, message string) {
+		if firing == s.firing[kind] {
+			return
+		}
+		s.firing[kind] = firing
+		alerts = append(alerts, Alert{Kind: kind, Message: message, Time: now, Resolved: !firing})
+	}
+	th := s.thresholds
+
+	if th.SyncFailures > 0 {
+		failing := in.stats.SyncFailures >= th.SyncFailures
+		message := "syncs are succeeding again"
+		if failing {
+			message = fmt.Sprintf("%d syncs failed in a row, last with: %s", in.stats.SyncFailures, in.stats.LastSyncError)
+		}
+		set(AlertSyncFailing, failing, message)
+	}
+
+	if th.FeedDown > 0 && in.realtime {
+		if in.stats.FeedConnected {
+			s.feedDown = time.Time{}
+		} else if s.feedDown.IsZero() {
+			s.feedDown = now
+		}
+		down := !s.feedDown.IsZero() && now.Sub(s.feedDown) >= time.Duration(th.FeedDown)
+		message := "feed is connected again"
+		if down {
+			message = fmt.Sprintf("feed has been disconnected for %s", now.Sub(s.feedDown).Truncate(time.Second))
+		}
+		set(AlertFeedDown, down, message)
+	}
+
+	if th.Stale > 0 && !in.lastSync.IsZero() {
+		stale := !in.stats.Paused && now.Sub(in.lastSync) >= time.Duration(th.Stale)
+		message := "cache is synced again"
+		if stale {
+			message = fmt.Sprintf("cache was last synced %s ago", now.Sub(in.lastSync).Truncate(time.Second))
+		}
+		set(AlertStale, stale, message)
+	}
+
+	if th.Anomalies && in.stats.Anomalies > s.anomalies {
+		alerts = append(alerts, Alert{
+			Kind:    AlertAnomaly,
+			Message: fmt.Sprintf("%d updates were flagged by the anomaly guard, %d are held", in.stats.Anomalies-s.anomalies, in.stats.Held),
+			Time:    now,
+		})
+	}
+	s.anomalies = in.stats.Anomalies
+	return alerts
+}
+
+//WatchAlerts checks the Manager for the conditions of thresholds every 10 seconds, and sends raised and resolved alerts to alerter
+//an alert is sent once when its condition is met, and once more when it clears
+//errors of alerter are passed to onError, which may be nil
+//this function blocks and returns only when cancelled by ctx
+func WatchAlerts(ctx context.Context, m *Manager, alerter Alerter, thresholds AlertThresholds, onError func(error)) {
+	state := &alertState{thresholds: thresholds, firing: map[string]bool{}, anomalies: m.client.Stats().Anomalies}
+	ticker := time.NewTicker(alertInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cfg, _ := m.current()
+			m.m.Lock()
+			lastSync := m.lastSync
+			m.m.Unlock()
+			alerts := state.check(now, alertInput{stats: m.client.Stats(), lastSync: lastSync, realtime: cfg.Sync.Realtime})
+			for _, alert := range alerts {
+				if err := alerter.Alert(ctx, alert); err != nil && onError != nil {
+					onError(fmt.Errorf("alert %s: %w", alert.Kind, err))
+				}
+			}
+		}
+	}
+}
+
+//AlertConfig configures the alerts of a Manager, see Manager.RunAlerts
+type AlertConfig struct {
+	AlertThresholds
+	//Webhook is a url alerts are posted to as json, see WebhookAlerter
+	Webhook string `json:"webhook,omitempty"`
+	//Stderr writes alerts to stderr
+	Stderr bool `json:"stderr,omitempty"`
+}
+
+//alerter returns the Alerter of the configured sinks, or nil if none are configured
+func (cfg AlertConfig) alerter(client *http.Client) Alerter {
+	var alerters []Alerter
+	if cfg.Webhook != "" {
+		alerters = append(alerters, WebhookAlerter{URL: cfg.Webhook, Client: client})
+	}
+	if cfg.Stderr {
+		alerters = append(alerters, WriterAlerter(os.Stderr))
+	}
+	switch len(alerters) {
+	case 0:
+		return nil
+	case 1:
+		return alerters[0]
+	default:
+		return MultiAlerter(alerters...)
+	}
+}
+
+//RunAlerts runs WatchAlerts with the alerts configured in the Config, it returns immediately if no sink is configured
+//errors of sending alerts are passed to onError, which may be nil
+//this function blocks and returns only when cancelled by ctx
+func (m *Manager) RunAlerts(ctx context.Context, onError func(error)) {
+	cfg, _ := m.current()
+	client := NewHTTPClient(cfg.timeout())
+	alerter := cfg.Alerts.alerter(&client)
+	if alerter == nil {
+		return
+	}
+	WatchAlerts(ctx, m, alerter, cfg.Alerts.AlertThresholds, onError)
+}
+
+//recordSync publishes the end of a sync and counts failed syncs in a row, syncs refused while paused are not counted
+func (c *Client) recordSync(op string, err error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.publish(Event{Kind: EventSyncFinished, Op: op, Err: err})
+	if errors.Is(err, ErrPaused) {
+		return
+	}
+	if err == nil {
+		c.syncFailures = 0
+		c.syncErr = nil
+		return
+	}
+	c.syncFailures++
+	c.syncErr = err
+}
@@ -0,0 +1,95 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertState(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	s := &alertState{
+		thresholds: AlertThresholds{SyncFailures: 2, FeedDown: Duration(time.Minute), Stale: Duration(time.Hour), Anomalies: true},
+		firing:     map[string]bool{},
+	}
+	kinds := func(alerts []Alert) map[string]bool {
+		m := map[string]bool{}
+		for _, alert := range alerts {
+			m[alert.Kind] = !alert.Resolved
+		}
+		return m
+	}
+	in := alertInput{stats: Stats{FeedConnected: true}, lastSync: now, realtime: true}
+	a.Empty(s.check(now, in))
+
+	in.stats = Stats{SyncFailures: 1, LastSyncError: "boom"}
+	a.Empty(s.check(now, in), "feed just went down and one failure is below the threshold")
+
+	in.stats.SyncFailures = 2
+	in.stats.Anomalies = 1
+	a.Equal(map[string]bool{AlertSyncFailing: true, AlertFeedDown: true, AlertAnomaly: true}, kinds(s.check(now.Add(time.Minute), in)))
+	a.Empty(s.check(now.Add(time.Minute*2), in), "alerts are only sent once")
+
+	a.Equal(map[string]bool{AlertStale: true}, kinds(s.check(now.Add(time.Hour), in)))
+	in.stats.Paused = true
+	a.Equal(map[string]bool{AlertStale: false}, kinds(s.check(now.Add(time.Hour), in)))
+
+	in = alertInput{stats: Stats{FeedConnected: true, Anomalies: 1}, lastSync: now.Add(time.Hour), realtime: true}
+	a.Equal(map[string]bool{AlertSyncFailing: false, AlertFeedDown: false}, kinds(s.check(now.Add(time.Hour), in)))
+}
+
+func TestAlerters(t *testing.T) {
+	a := assert.New(t)
+	var received []Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		a.NoError(json.NewDecoder(r.Body).Decode(&alert))
+		a.Equal("secret", r.Header.Get("X-Token"))
+		received = append(received, alert)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	failing := AlerterFunc(func(context.Context, Alert) error {
+		return errors.New("failed")
+	})
+	webhook := WebhookAlerter{URL: srv.URL, Header: http.Header{"X-Token": {"secret"}}}
+	alert := Alert{Kind: AlertFeedDown, Message: "feed is down", Time: time.Now()}
+	a.EqualError(MultiAlerter(webhook, WriterAlerter(&buf), failing).Alert(context.Background(), alert), "failed")
+	a.Len(received, 1)
+	a.Equal(AlertFeedDown, received[0].Kind)
+	a.Contains(buf.String(), "alert feed_down firing: feed is down")
+}
+
+func TestSyncFailures(t *testing.T) {
+	a := assert.New(t)
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`["a.com"]`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test", http.Client{})
+	a.Error(c.FullSync())
+	a.Error(c.FullSync())
+	a.Equal(2, c.Stats().SyncFailures)
+	a.NotEmpty(c.Stats().LastSyncError)
+	c.Pause()
+	a.ErrorIs(c.FullSync(), ErrPaused)
+	a.Equal(2, c.Stats().SyncFailures)
+	c.Resume()
+	fail = false
+	a.NoError(c.FullSync())
+	a.Zero(c.Stats().SyncFailures)
+	a.Empty(c.Stats().LastSyncError)
+}
@@ -0,0 +1,198 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+//errUnknownHeld is returned when confirming or discarding a held update that doesn't exist
+var errUnknownHeld = errors.New("unknown held update")
+
+//AnomalyGuard flags abnormally large updates, protecting the cache from upstream mistakes or a compromised upstream
+//such as a single update removing most of the list, or adding tens of thousands of domains at once
+//updates are checked one by one, a full sync is checked as a whole by its difference to the cache
+//the guard doesn't apply while the cache is empty, so the first sync is never flagged
+type AnomalyGuard struct {
+	//MaxAdded is the most domains a single update may add, 0 disables the limit
+	MaxAdded int `json:"max_added,omitempty"`
+	//MaxRemovedRatio is the largest fraction of the known domains a single update may remove, such as 0.5, 0 disables the limit
+	MaxRemovedRatio float64 `json:"max_removed_ratio,omitempty"`
+	//Hold holds flagged updates until they're confirmed with Client.ConfirmHeld, instead of applying them
+	//only the latest held full sync of each source is kept, as it supersedes the ones before
+	Hold bool `json:"hold,omitempty"`
+}
+
+//enabled checks if any limit is set
+func (g AnomalyGuard) enabled() bool {
+	return g.MaxAdded > 0 || g.MaxRemovedRatio > 0
+}
+
+//validate checks that the limits are in range
+func (g AnomalyGuard) validate() error {
+	if g.MaxAdded < 0 {
+		return errors.New("anomaly guard max added can't be negative")
+	}
+	if g.MaxRemovedRatio < 0 || g.MaxRemovedRatio > 1 {
+		return errors.New("anomaly guard max removed ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+//Anomaly is an update that tripped the AnomalyGuard
+type Anomaly struct {
+	//ID identifies a held update for Client.ConfirmHeld and Client.DiscardHeld
+	ID uint64
+	//Source is where the update came from
+	Source UpdateSource
+	//Diff are the domains the update adds and removes, domains it wouldn't change are left out
+	Diff Diff
+	//Reason describes which limit was exceeded
+	Reason string
+	//Time is when the update was flagged
+	Time time.Time
+	//Held is true if the update was held instead of applied
+	Held bool
+}
+
+//heldUpdate is a held Anomaly, along with the updates to apply once confirmed
+type heldUpdate struct {
+	anomaly Anomaly
+	updates []DomainUpdate
+	//replace is true if the updates replace the whole cache, such as a full sync
+	replace bool
+}
+
+//SetAnomalyGuard sets the limits updates are checked against, a zero AnomalyGuard disables the guard
+//updates held before are kept until confirmed or discarded
+func (c *Client) SetAnomalyGuard(g AnomalyGuard) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.anomalyGuard = g
+}
+
+//OnAnomaly registers fn to be called with every update that trips the AnomalyGuard, replacing the previous fn
+//fn is called while Client is locked, it must not block or call back into Client
+func (c *Client) OnAnomaly(fn func(Anomaly)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.onAnomaly = fn
+}
+
+//HeldUpdates returns the updates held by the AnomalyGuard, oldest first
+func (c *Client) HeldUpdates() []Anomaly {
+	c.m.Lock()
+	defer c.m.Unlock()
+	held := make([]Anomaly, 0, len(c.held))
+	for _, h := range c.held {
+		held = append(held, h.anomaly)
+	}
+	return held
+}
+
+//ConfirmHeld applies a held update, bypassing the AnomalyGuard
+//the update is applied as it was received, changes to the cache since then are not undone
+func (c *Client) ConfirmHeld(id uint64) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	h, ok := c.takeHeld(id)
+	if !ok {
+		return errUnknownHeld
+	}
+	c.lastUpdated = time.Now()
+	for _, mod := range h.updates {
+		c.applyUnguarded(mod, h.anomaly.Source)
+	}
+	c.sendUpdate()
+	return nil
+}
+
+//DiscardHeld drops a held update without applying it
+func (c *Client) DiscardHeld(id uint64) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, ok := c.takeHeld(id); !ok {
+		return errUnknownHeld
+	}
+	return nil
+}
+
+//takeHeld removes a held update
+//should only be called when mutex is locked
+func (c *Client) takeHeld(id uint64) (heldUpdate, bool) {
+	for i, h := range c.held {
+		if h.anomaly.ID == id {
+			c.held = append(c.held[:i:i], c.held[i+1:]...)
+			return h, true
+		}
+	}
+	return heldUpdate{}, false
+}
+
+//dropHeldReplacement drops the held replacement from the source, as a newer replacement supersedes it
+//should only be called when mutex is locked
+func (c *Client) dropHeldReplacement(source UpdateSource) {
+	for _, h := range c.held {
+		if h.replace && h.anomaly.Source == source {
+			c.takeHeld(h.anomaly.ID)
+			return
+		}
+	}
+}
+
+//guardMod checks a single update against the AnomalyGuard, returning true if it got held
+//should only be called when mutex is locked
+func (c *Client) guardMod(mod DomainUpdate, source UpdateSource) bool {
+	if !c.anomalyGuard.enabled() {
+		return false
+	}
+	var diff Diff
+	for _, domain := range mod.Domains {
+		_, found := c.domains[domain]
+		switch {
+		case mod.Add && !found:
+			diff.Added = append(diff.Added, domain)
+		case !mod.Add && found:
+			diff.Removed = append(diff.Removed, domain)
+		}
+	}
+	return c.guard(diff, source, []DomainUpdate{mod}, false)
+}
+
+//guard checks a change against the AnomalyGuard, flagging it if it exceeds a limit
+//it returns true if the change got held, in which case updates are applied once it's confirmed
+//replace is true if the change replaces the whole cache, a held replacement supersedes the one held before from the same source
+//should only be called when mutex is locked
+func (c *Client) guard(diff Diff, source UpdateSource, updates []DomainUpdate, replace bool) bool {
+	g := c.anomalyGuard
+	if !g.enabled() || len(c.domains) == 0 || source == SourceEviction || source == SourceReset {
+		return false
+	}
+	var reason string
+	switch {
+	case g.MaxAdded > 0 && len(diff.Added) > g.MaxAdded:
+		reason = fmt.Sprintf("adds %d domains, more than the limit of %d", len(diff.Added), g.MaxAdded)
+	case g.MaxRemovedRatio > 0 && float64(len(diff.Removed)) > g.MaxRemovedRatio*float64(len(c.domains)):
+		reason = fmt.Sprintf("removes %d of %d domains, more than the limit of %g", len(diff.Removed), len(c.domains), g.MaxRemovedRatio)
+	default:
+		return false
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	c.anomalyID++
+	c.anomalies++
+	a := Anomaly{ID: c.anomalyID, Source: source, Diff: diff, Reason: reason, Time: time.Now(), Held: g.Hold}
+	if a.Held {
+		if replace {
+			c.dropHeldReplacement(source)
+		}
+		c.held = append(c.held, heldUpdate{anomaly: a, updates: updates, replace: replace})
+	}
+	if c.onAnomaly != nil {
+		c.onAnomaly(a)
+	}
+	c.publish(Event{Kind: EventAnomaly, Time: a.Time, Anomaly: &a})
+	return a.Held
+}
@@ -0,0 +1,91 @@
+package sinkingyachts
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnomalyGuard(t *testing.T) {
+	domains := make([]string, 10)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("%d.com", i)
+	}
+	tests := []struct {
+		name    string
+		guard   AnomalyGuard
+		mod     DomainUpdate
+		flagged bool
+	}{
+		{"small add", AnomalyGuard{MaxAdded: 2}, DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, false},
+		{"large add", AnomalyGuard{MaxAdded: 2}, DomainUpdate{Add: true, Domains: []string{"a.com", "b.com", "c.com"}}, true},
+		{"known domains don't count", AnomalyGuard{MaxAdded: 2}, DomainUpdate{Add: true, Domains: []string{"a.com", "1.com", "2.com"}}, false},
+		{"small remove", AnomalyGuard{MaxRemovedRatio: 0.5}, DomainUpdate{Add: false, Domains: domains[:5]}, false},
+		{"large remove", AnomalyGuard{MaxRemovedRatio: 0.5}, DomainUpdate{Add: false, Domains: domains[:6]}, true},
+		{"disabled", AnomalyGuard{}, DomainUpdate{Add: false, Domains: domains}, false},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			for _, hold := range []bool{false, true} {
+				a := assert.New(t)
+				c := New("", "test", http.Client{})
+				c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: domains}}, SourceFeed)
+				data.guard.Hold = hold
+				c.SetAnomalyGuard(data.guard)
+				var flagged []Anomaly
+				c.OnAnomaly(func(an Anomaly) {
+					flagged = append(flagged, an)
+				})
+				before := c.Size()
+				c.ApplyUpdates([]DomainUpdate{data.mod}, SourceFeed)
+				a.Equal(data.flagged, len(flagged) == 1)
+				a.Equal(data.flagged, c.Stats().Anomalies == 1)
+				held := data.flagged && hold
+				a.Equal(held, c.Size() == before)
+				a.Equal(held, len(c.HeldUpdates()) == 1)
+				if held {
+					a.True(flagged[0].Held)
+					a.NoError(c.ConfirmHeld(flagged[0].ID))
+					a.NotEqual(before, c.Size())
+					a.Empty(c.HeldUpdates())
+					a.Error(c.ConfirmHeld(flagged[0].ID))
+				}
+			}
+		})
+	}
+}
+
+func TestAnomalyGuardFullSync(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["a.com"]`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test", http.Client{})
+	c.SetAnomalyGuard(AnomalyGuard{MaxAdded: 1, MaxRemovedRatio: 0.5, Hold: true})
+	c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: []string{"b.com", "c.com", "d.com"}}}, SourceFeed)
+	a.Equal(3, c.Size(), "the first update of an empty cache isn't guarded")
+
+	synced := c.SyncState().LastUpdated
+	a.NoError(c.FullSync())
+	a.Equal(3, c.Size())
+	held := c.HeldUpdates()
+	a.Len(held, 1)
+	a.Equal(SourceFullSync, held[0].Source)
+	a.Equal(Diff{Added: []string{"a.com"}, Removed: []string{"b.com", "c.com", "d.com"}}, held[0].Diff)
+	a.Equal(synced, c.SyncState().LastUpdated, "a held full sync isn't synced")
+
+	//another held full sync supersedes the previous one, instead of piling up
+	a.NoError(c.FullSync())
+	superseded := held[0].ID
+	held = c.HeldUpdates()
+	a.Len(held, 1)
+	a.NotEqual(superseded, held[0].ID)
+	a.Error(c.ConfirmHeld(superseded))
+
+	a.NoError(c.DiscardHeld(held[0].ID))
+	a.Empty(c.HeldUpdates())
+	a.Equal(3, c.Size())
+}
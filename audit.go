@@ -0,0 +1,52 @@
+package sinkingyachts
+
+import (
+	"context"
+	"database/sql"
+)
+
+//SQLAuditQuery is the default insert statement used by SQLAudit, using "?" placeholders
+//the table can be created with
+//CREATE TABLE sinkingyachts_audit (domain TEXT NOT NULL, action TEXT NOT NULL, source TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)
+const SQLAuditQuery = "INSERT INTO sinkingyachts_audit (domain, action, source, applied_at) VALUES (?, ?, ?, ?)"
+
+//SQLAudit is a Publisher that records every added or removed domain as a row in a sql table
+//use it with PublishUpdates, so it can be answered when and why a domain got blocked
+type SQLAudit struct {
+	db    *sql.DB
+	query string
+}
+
+//NewSQLAudit creates a SQLAudit writing into db
+//query is the insert statement, receiving domain, action ("add" or "delete"), source and time in that order
+//use SQLAuditQuery for drivers with "?" placeholders, drivers such as postgres need their own "$1" style query
+func NewSQLAudit(db *sql.DB, query string) *SQLAudit {
+	return &SQLAudit{
+		db:    db,
+		query: query,
+	}
+}
+
+//Publish inserts a row for every domain of the update within a single transaction
+func (s *SQLAudit) Publish(ctx context.Context, update AppliedUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, s.query)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	action := newModEntry(update.Update).Type
+	for _, domain := range update.Update.Domains {
+		_, err = stmt.ExecContext(ctx, domain, action, string(update.Source), update.Time)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+package sinkingyachts
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+//auditDriver is an in memory sql driver recording the rows inserted by SQLAudit
+//rows are only kept once their transaction is committed
+type auditDriver struct {
+	m         sync.Mutex
+	query     string
+	rows      [][]driver.Value
+	pending   [][]driver.Value
+	failOn    string
+	rollbacks int
+}
+
+func (d *auditDriver) Open(string) (driver.Conn, error) {
+	return auditConn{d}, nil
+}
+
+//reset clears everything recorded so far
+func (d *auditDriver) reset() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.query, d.rows, d.pending, d.failOn, d.rollbacks = "", nil, nil, "", 0
+}
+
+func (d *auditDriver) inserted() [][]driver.Value {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return append([][]driver.Value(nil), d.rows...)
+}
+
+type auditConn struct {
+	d *auditDriver
+}
+
+func (c auditConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.m.Lock()
+	defer c.d.m.Unlock()
+	c.d.query = query
+	return auditStmt(c), nil
+}
+
+func (c auditConn) Close() error {
+	return nil
+}
+
+func (c auditConn) Begin() (driver.Tx, error) {
+	return auditTx(c), nil
+}
+
+type auditTx struct {
+	d *auditDriver
+}
+
+func (tx auditTx) Commit() error {
+	tx.d.m.Lock()
+	defer tx.d.m.Unlock()
+	tx.d.rows = append(tx.d.rows, tx.d.pending...)
+	tx.d.pending = nil
+	return nil
+}
+
+func (tx auditTx) Rollback() error {
+	tx.d.m.Lock()
+	defer tx.d.m.Unlock()
+	tx.d.pending = nil
+	tx.d.rollbacks++
+	return nil
+}
+
+type auditStmt struct {
+	d *auditDriver
+}
+
+func (s auditStmt) Close() error {
+	return nil
+}
+
+func (s auditStmt) NumInput() int {
+	return 4
+}
+
+func (s auditStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.m.Lock()
+	defer s.d.m.Unlock()
+	if args[0] == s.d.failOn {
+		return nil, errors.New("insert failed")
+	}
+	s.d.pending = append(s.d.pending, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s auditStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var auditDB = &auditDriver{}
+
+func init() {
+	sql.Register("sinkingyachts-audit", auditDB)
+}
+
+func TestSQLAudit(t *testing.T) {
+	a := assert.New(t)
+	auditDB.reset()
+	db, err := sql.Open("sinkingyachts-audit", "")
+	a.NoError(err)
+	defer db.Close()
+	audit := NewSQLAudit(db, SQLAuditQuery)
+	at := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	a.NoError(audit.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, Time: at, Source: SourceFeed}))
+	a.NoError(audit.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: false, Domains: []string{"a.com"}}, Time: at, Source: SourceRecent}))
+	a.Equal(SQLAuditQuery, auditDB.query)
+	a.Equal([][]driver.Value{
+		{"a.com", "add", string(SourceFeed), at},
+		{"b.com", "add", string(SourceFeed), at},
+		{"a.com", "delete", string(SourceRecent), at},
+	}, auditDB.inserted())
+
+	auditDB.m.Lock()
+	auditDB.failOn = "bad.com"
+	auditDB.m.Unlock()
+	err = audit.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: true, Domains: []string{"c.com", "bad.com"}}, Time: at, Source: SourceFeed})
+	a.Error(err)
+	a.Len(auditDB.inserted(), 3, "a failed insert rolls back the whole update")
+	auditDB.m.Lock()
+	a.Equal(1, auditDB.rollbacks)
+	auditDB.m.Unlock()
+}
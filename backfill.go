@@ -0,0 +1,83 @@
+package sinkingyachts
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Backfill reconstructs when recent updates happened, for analyzing the timing of phishing campaigns
+//the api doesn't timestamp updates, so windows growing by chunk up to window are fetched with Recent,
+//and updates first seen in a window are dated to the start of the chunk that window added
+//window should not be longer than the api serves, and every chunk re-fetches all newer updates so small chunks are costly
+//the returned updates are ordered from oldest to newest, with SourceRecent as their source
+func Backfill(ctx context.Context, r RawClient, window, chunk time.Duration) ([]AppliedUpdate, error) {
+	if chunk <= 0 || chunk > window {
+		chunk = window
+	}
+	now := time.Now()
+	seen := map[string]int{}
+	var updates []AppliedUpdate
+	for end := chunk; ; end += chunk {
+		if end > window {
+			end = window
+		}
+		mods, err := r.recent(ctx, int(math.Ceil(end.Seconds())))
+		if err != nil {
+			return nil, err
+		}
+		//updates can repeat, so the same update is only new once it's seen more times than in the previous window
+		counts := map[string]int{}
+		for _, mod := range mods {
+			key := updateKey(mod)
+			counts[key]++
+			if counts[key] > seen[key] {
+				seen[key]++
+				updates = append(updates, AppliedUpdate{Update: mod, Time: now.Add(-end), Source: SourceRecent})
+			}
+		}
+		if end >= window {
+			break
+		}
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Time.Before(updates[j].Time)
+	})
+	return updates, nil
+}
+
+//updateKey identifies an update by its content
+func updateKey(mod DomainUpdate) string {
+	domains := append([]string(nil), mod.Domains...)
+	sort.Strings(domains)
+	kind := "-"
+	if mod.Add {
+		kind = "+"
+	}
+	return kind + mod.Category + "|" + strings.Join(domains, ",")
+}
+
+//WriteUpdatesCSV writes updates as csv with a header, one row of time, type, category and domain per domain
+//time is formatted as RFC 3339, updates can also be written as json lines with Journal
+func WriteUpdatesCSV(w io.Writer, updates []AppliedUpdate) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"time", "type", "category", "domain"})
+	if err != nil {
+		return err
+	}
+	for _, au := range updates {
+		entry := newModEntry(au.Update)
+		for _, domain := range au.Update.Domains {
+			err = cw.Write([]string{au.Time.UTC().Format(time.RFC3339), entry.Type, entry.Category, domain})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,64 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackfill(t *testing.T) {
+	a := assert.New(t)
+	//updates by how many seconds ago they happened
+	events := []struct {
+		age int
+		mod DomainUpdate
+	}{
+		{age: 30, mod: DomainUpdate{Add: true, Domains: []string{"new.com"}}},
+		{age: 90, mod: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}},
+		{age: 150, mod: DomainUpdate{Add: false, Domains: []string{"a.com"}}},
+		{age: 170, mod: DomainUpdate{Add: true, Domains: []string{"new.com"}}},
+		{age: 500, mod: DomainUpdate{Add: true, Domains: []string{"old.com"}}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, endpointRecent))
+		a.NoError(err)
+		var mods []DomainUpdate
+		for _, e := range events {
+			if e.age <= seconds {
+				mods = append(mods, e.mod)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(mods)
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	updates, err := Backfill(context.Background(), NewRawClient(srv.URL, "test", http.Client{}), time.Minute*3, time.Minute)
+	a.NoError(err)
+	var got []string
+	for _, au := range updates {
+		a.Equal(SourceRecent, au.Source)
+		age := start.Sub(au.Time).Round(time.Minute)
+		got = append(got, age.String()+" "+updateKey(au.Update))
+	}
+	a.Equal([]string{
+		"3m0s -|a.com",
+		"3m0s +|new.com",
+		"2m0s +|a.com,b.com",
+		"1m0s +|new.com",
+	}, got)
+
+	var buf bytes.Buffer
+	a.NoError(WriteUpdatesCSV(&buf, updates[2:3]))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	a.Equal("time,type,category,domain", lines[0])
+	a.Len(lines, 3)
+	a.True(strings.HasSuffix(lines[1], ",add,,a.com"), lines[1])
+}
@@ -0,0 +1,80 @@
+package sinkingyachts
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//defaultFeedBuffer is the default amount of feed updates buffered for the consumer, see WithFeedBuffer
+const defaultFeedBuffer = 8
+
+//Backpressure decides what Feed does with an update when its consumer doesn't keep up
+type Backpressure int
+
+const (
+	//BackpressureBlock waits for the consumer to receive the update, this is the default
+	//reading the websocket stalls while waiting, so the feed stalls along with the consumer
+	BackpressureBlock Backpressure = iota
+	//BackpressureDrop drops updates the consumer isn't ready to receive, they are counted in Transfer.FeedDropped
+	//the feed keeps up no matter the consumer, but the cache misses the dropped updates until the next sync
+	BackpressureDrop
+)
+
+//WithFeedBackpressure sets what Feed does with an update when its consumer doesn't keep up
+//with BackpressureBlock, a timeout above 0 closes the feed with an error once the consumer doesn't receive an update in time
+//so a stuck consumer is reported clearly instead of silently stalling the feed
+func WithFeedBackpressure(mode Backpressure, timeout time.Duration) Option {
+	return func(client *RawClient) {
+		client.backpressure = mode
+		client.consumerTimeout = timeout
+	}
+}
+
+//WithFeedBuffer sets the amount of feed updates Client buffers for applying while listening, defaults to 8
+//a larger buffer absorbs bursts of updates before backpressure applies
+func WithFeedBuffer(size int) Option {
+	return func(client *RawClient) {
+		client.feedBuffer = size
+	}
+}
+
+//feedBufferSize returns the feed buffer size, or its default
+func (c RawClient) feedBufferSize() int {
+	if c.feedBuffer <= 0 {
+		return defaultFeedBuffer
+	}
+	return c.feedBuffer
+}
+
+//sendFeed passes an update from the feed to its consumer, according to the backpressure mode of c
+//returns false if ctx is done before the update is passed
+func sendFeed[T any](ctx context.Context, c RawClient, modFeed chan T, mod T) (bool, error) {
+	switch {
+	case c.backpressure == BackpressureDrop:
+		select {
+		case modFeed <- mod:
+		default:
+			c.transfer.feedDropped()
+		}
+		return ctx.Err() == nil, nil
+	case c.consumerTimeout > 0:
+		timer := time.NewTimer(c.consumerTimeout)
+		defer timer.Stop()
+		select {
+		case modFeed <- mod:
+			return true, nil
+		case <-ctx.Done():
+			return false, nil
+		case <-timer.C:
+			return false, fmt.Errorf("feed consumer didn't receive an update within %s", c.consumerTimeout)
+		}
+	default:
+		select {
+		case modFeed <- mod:
+			return true, nil
+		case <-ctx.Done():
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+	"strings"
+	"testing"
+	"time"
+)
+
+//burstServer serves a feed that sends n updates right away, then stays open
+func burstServer(n int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer cn.Close(websocket.StatusNormalClosure, "")
+		ctx := cn.CloseRead(r.Context())
+		for i := 0; i < n; i++ {
+			if err = wsjson.Write(ctx, cn, DomainUpdate{Add: true, Domains: []string{"a.com"}}); err != nil {
+				return
+			}
+		}
+		<-ctx.Done()
+	}))
+}
+
+func TestFeedBackpressure(t *testing.T) {
+	srv := burstServer(5)
+	defer srv.Close()
+	endpoint := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	t.Run("drop", func(t *testing.T) {
+		a := assert.New(t)
+		r := NewRawClient(endpoint, "test", http.Client{}, WithFeedBackpressure(BackpressureDrop, 0))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- r.Feed(ctx, make(chan DomainUpdate, 1))
+		}()
+		a.Eventually(func() bool { return r.Transfer().FeedDropped == 4 }, time.Second, time.Millisecond*10)
+		cancel()
+		a.NoError(<-done)
+	})
+
+	t.Run("consumer timeout", func(t *testing.T) {
+		a := assert.New(t)
+		r := NewRawClient(endpoint, "test", http.Client{}, WithFeedBackpressure(BackpressureBlock, time.Millisecond*50))
+		err := r.Feed(context.Background(), make(chan DomainUpdate))
+		a.Error(err)
+		a.Contains(err.Error(), "feed consumer didn't receive an update within 50ms")
+		a.Zero(r.Transfer().FeedDropped)
+	})
+}
+
+func TestWithFeedBuffer(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(defaultFeedBuffer, NewRawClient("", "test", http.Client{}).feedBufferSize())
+	a.Equal(64, NewRawClient("", "test", http.Client{}, WithFeedBuffer(64)).feedBufferSize())
+}
@@ -0,0 +1,151 @@
+package sinkingyachts
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//ErrNoBackup is returned by FileStore.BackupAt when no backup was made at or before the requested time
+var ErrNoBackup = errors.New("no backup at or before the requested time")
+
+//backupTime is the layout of the timestamp in backup names, it sorts in chronological order
+const backupTime = "20060102T150405.000Z"
+
+//BackupPolicy configures the backups kept by FileStore, see FileStore.EnableBackups
+type BackupPolicy struct {
+	//Keep is the amount of backups kept, older backups are deleted
+	Keep int
+	//Interval is the minimum time between backups, saves in between don't create a backup
+	Interval time.Duration
+	//Compress gzips the backups
+	Compress bool
+}
+
+//EnableBackups keeps timestamped copies of saved caches next to the file, named "<file>.<time>.bak"
+//a backup is made by Save if the last backup is at least the policy's interval old, which includes the first save
+//a Keep of 0 disables backups, this should be called before the FileStore is used
+func (s *FileStore) EnableBackups(policy BackupPolicy) {
+	s.backups = policy
+}
+
+//Backups returns the paths of the backups, newest first
+func (s *FileStore) Backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(s.path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(s.path) + "."
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || !(strings.HasSuffix(name, ".bak") || strings.HasSuffix(name, ".bak.gz")) {
+			continue
+		}
+		if _, err := parseBackupTime(name[len(prefix):]); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(s.path), name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+//BackupAt returns the path of the newest backup made at or before t, ErrNoBackup is returned if there is none
+func (s *FileStore) BackupAt(t time.Time) (string, error) {
+	backups, err := s.Backups()
+	if err != nil {
+		return "", err
+	}
+	prefix := filepath.Base(s.path) + "."
+	for _, backup := range backups {
+		made, err := parseBackupTime(filepath.Base(backup)[len(prefix):])
+		if err == nil && !made.After(t) {
+			return backup, nil
+		}
+	}
+	return "", ErrNoBackup
+}
+
+//parseBackupTime parses the time a backup was made from the suffix of its name after the cache file's name
+func parseBackupTime(suffix string) (time.Time, error) {
+	return time.Parse(backupTime, strings.TrimSuffix(strings.TrimSuffix(suffix, ".gz"), ".bak"))
+}
+
+//Restore loads a backup into Client and saves it as the current cache, rolling back a bad sync
+//backup is a path returned by Backups, gzipped backups are recognized by their ".gz" suffix
+func (s *FileStore) Restore(c *Client, backup string) error {
+	f, err := os.Open(backup)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(backup, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	err = s.read(c, r)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, s.write(c))
+}
+
+//backup copies the saved cache into a new backup if the last one is old enough, and deletes backups beyond Keep
+func (s *FileStore) backup() error {
+	if s.backups.Keep <= 0 {
+		return nil
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	now := time.Now()
+	if !s.lastBackup.IsZero() && now.Sub(s.lastBackup) < s.backups.Interval {
+		return nil
+	}
+
+	name := s.path + "." + now.UTC().Format(backupTime) + ".bak"
+	if s.backups.Compress {
+		name += ".gz"
+	}
+	err := writeFileAtomic(name, func(w io.Writer) error {
+		f, err := os.Open(s.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if !s.backups.Compress {
+			_, err = io.Copy(w, f)
+			return err
+		}
+		gz := gzip.NewWriter(w)
+		if _, err = io.Copy(gz, f); err != nil {
+			return err
+		}
+		return gz.Close()
+	})
+	if err != nil {
+		return err
+	}
+	s.lastBackup = now
+
+	backups, err := s.Backups()
+	if err != nil {
+		return err
+	}
+	for i := s.backups.Keep; i < len(backups); i++ {
+		if err = os.Remove(backups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+//DefaultBootstrapMaxSize is the largest cache Bootstrap and BootstrapVerified download, in bytes
+//Manager downloads up to BootstrapConfig.MaxSize instead
+const DefaultBootstrapMaxSize = 256 << 20
+
+//Bootstrap downloads a cache from a mirror url and loads it into Client, replacing its cache
+//the mirror serves a cache written by WriteCacheFormat in the format, such as an export hosted on a CDN
+//this lets large fleets start from a recent cache and only fetch recent updates, instead of each doing a FullSync
+//caches larger than DefaultBootstrapMaxSize are rejected
+//BootstrapVerified should be used instead when the mirror is not trusted
+func Bootstrap(ctx context.Context, c *Client, client *http.Client, url string, format CacheFormat) error {
+	return bootstrap(ctx, c, client, url, format, DefaultBootstrapMaxSize)
+}
+
+//bootstrap is Bootstrap, rejecting caches larger than maxSize
+func bootstrap(ctx context.Context, c *Client, client *http.Client, url string, format CacheFormat, maxSize int64) error {
+	data, err := download(ctx, client, url, maxSize)
+	if err != nil {
+		return err
+	}
+	return ReadCacheFormat(c, bytes.NewReader(data), format)
+}
+
+//download returns the body of a GET request to url, or an error if it's larger than maxSize
+func download(ctx context.Context, client *http.Client, url string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, unexpectedStatusError{
+			endpoint: url,
+			status:   resp.StatusCode,
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxSize)
+	}
+	return data, nil
+}
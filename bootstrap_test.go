@@ -0,0 +1,93 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBootstrap(t *testing.T) {
+	a := assert.New(t)
+	mirrored := New("", "test", http.Client{})
+	mirrored.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	var cache bytes.Buffer
+	a.NoError(WriteCacheFormat(mirrored, &cache, CacheMsgpack))
+
+	var all, recent int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/mirror/cache.msgpack":
+			_, _ = w.Write(cache.Bytes())
+		case r.URL.Path == endpointAll:
+			atomic.AddInt32(&all, 1)
+			_, _ = w.Write([]byte(`["a.com"]`))
+		case strings.HasPrefix(r.URL.Path, endpointRecent):
+			atomic.AddInt32(&recent, 1)
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New("", "test", http.Client{})
+	a.Error(Bootstrap(context.Background(), c, srv.Client(), srv.URL+"/missing", CacheJSON))
+	a.NoError(Bootstrap(context.Background(), c, srv.Client(), srv.URL+"/mirror/cache.msgpack", CacheMsgpack))
+	a.Equal(2, c.Size())
+	c.Reset()
+	a.Error(bootstrap(context.Background(), c, srv.Client(), srv.URL+"/mirror/cache.msgpack", CacheMsgpack, int64(cache.Len()-1)))
+	a.Zero(c.Size(), "caches larger than the limit aren't loaded")
+	a.NoError(bootstrap(context.Background(), c, srv.Client(), srv.URL+"/mirror/cache.msgpack", CacheMsgpack, int64(cache.Len())))
+	a.Equal(2, c.Size())
+
+	tests := []struct {
+		name    string
+		url     string
+		maxSize int64
+		size    int
+		all     int32
+		recent  int32
+		errs    int
+	}{
+		{"mirror", srv.URL + "/mirror/cache.msgpack", 0, 2, 0, 1, 0},
+		{"fallback", srv.URL + "/missing", 0, 1, 1, 0, 1},
+		{"too large", srv.URL + "/mirror/cache.msgpack", 8, 1, 1, 0, 1},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			atomic.StoreInt32(&all, 0)
+			atomic.StoreInt32(&recent, 0)
+			m, err := NewManager(Config{
+				Endpoint:  srv.URL,
+				Identity:  "test",
+				Bootstrap: BootstrapConfig{URL: data.url, Format: "msgpack", MaxSize: data.maxSize},
+			})
+			a.NoError(err)
+			var errs []error
+			m.OnError(func(err error) {
+				errs = append(errs, err)
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- m.Run(ctx)
+			}()
+			a.Eventually(func() bool { return m.Ready(time.Minute) == nil }, time.Second, time.Millisecond*10)
+			cancel()
+			a.NoError(<-done)
+			a.Equal(data.size, m.Client().Size())
+			a.Equal(data.all, atomic.LoadInt32(&all))
+			a.Equal(data.recent, atomic.LoadInt32(&recent))
+			if a.Len(errs, data.errs) && data.errs > 0 {
+				a.Contains(errs[0].Error(), "falling back to a full sync")
+			}
+		})
+	}
+}
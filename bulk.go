@@ -0,0 +1,80 @@
+package sinkingyachts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//CheckMany checks multiple domains, returning a map of each domain to whether it's flagged as phishing
+//the api has no batch endpoint, so domains are checked individually with bounded concurrency and rate, see WithBulkLimits
+//duplicate domains are only checked once
+//on the first error remaining checks are cancelled, and the results so far are returned along with the error
+func (c RawClient) CheckMany(ctx context.Context, domains []string) (map[string]bool, error) {
+	ctx, cancel := c.withBase(ctx)
+	defer cancel()
+
+	results := make(map[string]bool, len(domains))
+	jobs := make(chan string)
+	var m sync.Mutex
+	var firstErr error
+
+	workers := c.bulkWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range jobs {
+				found, err := c.check(ctx, domain)
+				m.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					results[domain] = found
+				}
+				m.Unlock()
+			}
+		}()
+	}
+
+	var tick <-chan time.Time
+	if c.bulkRate > 0 {
+		ticker := time.NewTicker(c.bulkRate)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	seen := make(map[string]empty, len(domains))
+dispatch:
+	for i, domain := range domains {
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+		seen[domain] = empty{}
+		if tick != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- domain:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	return results, ctx.Err()
+}
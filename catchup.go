@@ -0,0 +1,52 @@
+package sinkingyachts
+
+import (
+	"time"
+)
+
+//defaultRecentWindow is how far back recent updates are trusted to reach by default, see WithRecentWindow
+const defaultRecentWindow = time.Hour * 24
+
+//MissedWindow is reported when Client.Update can't catch up with recent updates, as the cache is older than the recent window
+type MissedWindow struct {
+	//LastUpdated is when the cache was last synced
+	LastUpdated time.Time
+	//Window is how far back recent updates reach, see WithRecentWindow
+	Window time.Duration
+	//Time is when the missed window was detected
+	Time time.Time
+}
+
+//WithRecentWindow sets how far back the api serves recent updates, defaults to 24 hours
+//Client.Update does a full sync instead of fetching recent updates when the cache is older than the window
+//as the updates before the window would be silently missed, 0 or less disables the detection
+func WithRecentWindow(window time.Duration) Option {
+	return func(client *RawClient) {
+		client.recentWindow = window
+	}
+}
+
+//OnMissedWindow registers fn to be called whenever Client.Update falls back to a full sync, replacing the previous fn
+//fn is called while Client is locked, it must not block or call back into Client
+func (c *Client) OnMissedWindow(fn func(MissedWindow)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.onMissedWindow = fn
+}
+
+//missedWindow reports and returns true if recent updates since lastUpdated can't be fetched
+//a zero lastUpdated is a cache that never synced, and is left to the caller
+//should only be called when mutex is locked
+func (c *Client) missedWindow(lastUpdated time.Time) bool {
+	window := c.r.recentWindow
+	if window <= 0 || lastUpdated.IsZero() || time.Since(lastUpdated) <= window {
+		return false
+	}
+	c.missedWindows++
+	mw := MissedWindow{LastUpdated: lastUpdated, Window: window, Time: time.Now()}
+	if c.onMissedWindow != nil {
+		c.onMissedWindow(mw)
+	}
+	c.publish(Event{Kind: EventMissedWindow, Time: mw.Time, MissedWindow: &mw})
+	return true
+}
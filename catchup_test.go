@@ -0,0 +1,59 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMissedWindow(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, endpointRecent) {
+			requests = append(requests, endpointRecent)
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		requests = append(requests, r.URL.Path)
+		_, _ = w.Write([]byte(`["full.com"]`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		updated  time.Duration
+		options  []Option
+		requests []string
+		missed   bool
+	}{
+		{name: "within window", updated: time.Hour, requests: []string{endpointRecent}},
+		{name: "missed window", updated: time.Hour * 25, requests: []string{endpointAll}, missed: true},
+		{name: "custom window", updated: time.Hour, options: []Option{WithRecentWindow(time.Minute * 30)}, requests: []string{endpointAll}, missed: true},
+		{name: "disabled", updated: time.Hour * 25, options: []Option{WithRecentWindow(0)}, requests: []string{endpointRecent}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			requests = nil
+			c := New(srv.URL, "test", http.Client{}, data.options...)
+			c.lastUpdated = time.Now().Add(-data.updated)
+			var missed []MissedWindow
+			c.OnMissedWindow(func(mw MissedWindow) {
+				missed = append(missed, mw)
+			})
+			a.NoError(c.Update())
+			a.Equal(data.requests, requests)
+			if !data.missed {
+				a.Empty(missed)
+				a.Zero(c.Stats().MissedWindows)
+				return
+			}
+			a.Len(missed, 1)
+			a.Equal(uint64(1), c.Stats().MissedWindows)
+			a.Equal([]string{"full.com"}, c.Domains())
+		})
+	}
+}
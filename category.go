@@ -0,0 +1,61 @@
+package sinkingyachts
+
+//DefaultCategory is the category of domains from sources that don't provide one, such as the api
+const DefaultCategory = "phishing"
+
+//FilterCategories makes Client only ingest domains of the given categories, such as "phishing", "scam" or "malware"
+//updates without a category are of DefaultCategory, so the api's domains are only ingested if DefaultCategory is included
+//domains that are already known are kept, removals are always applied
+//calling it without categories ingests every category, which is the default
+func (c *Client) FilterCategories(categories ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if len(categories) == 0 {
+		c.categories = nil
+		return
+	}
+	c.categories = make(map[string]empty, len(categories))
+	for _, category := range categories {
+		c.categories[category] = empty{}
+	}
+}
+
+//allowCategory checks if a category should be ingested
+//should only be called when mutex is locked
+func (c *Client) allowCategory(category string) bool {
+	if c.categories == nil {
+		return true
+	}
+	_, ok := c.categories[category]
+	return ok
+}
+
+//allowUpdate checks if an update should be ingested, only additions are filtered
+//should only be called when mutex is locked
+func (c *Client) allowUpdate(mod DomainUpdate) bool {
+	return !mod.Add || c.allowCategory(mod.category())
+}
+
+//filterKnown returns the domains of dMap that are already known, if DefaultCategory isn't ingested
+//it lets a full sync of the api, whose domains are of DefaultCategory, remove known domains without adding new ones
+//should only be called when mutex is locked
+func (c *Client) filterKnown(dMap map[string]empty) map[string]empty {
+	if c.allowCategory(DefaultCategory) {
+		return dMap
+	}
+	known := make(map[string]empty, len(c.domains))
+	for domain := range dMap {
+		if _, ok := c.domains[domain]; ok {
+			known[domain] = empty{}
+		}
+	}
+	return known
+}
+
+//category returns the category of the update, or DefaultCategory if it has none
+func (m DomainUpdate) category() string {
+	if m.Category == "" {
+		return DefaultCategory
+	}
+	return m.Category
+}
@@ -5,19 +5,61 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
 )
 
 type Client struct {
-	r           RawClient
-	domains     map[string]empty
-	lastUpdated time.Time
-	m           sync.Mutex
-	streaming   bool
-	cancelFunc  context.CancelFunc
-	updateChan  chan struct{}
+	r              RawClient
+	domains        map[string]empty
+	arena          *arena
+	lastUpdated    time.Time
+	m              sync.Mutex
+	streaming      bool
+	cancelFunc     context.CancelFunc
+	updateChan     chan struct{}
+	listeners      map[int]func(AppliedUpdate)
+	listenerID     int
+	feed           feedStats
+	listenDone     chan struct{}
+	listenStop     context.CancelFunc
+	listenErr      error
+	meta           map[string]Metadata
+	categories     map[string]empty
+	local          map[string]time.Time
+	dryRun         bool
+	onDryRun       func(Match)
+	dryRunHits     uint64
+	draining       sync.WaitGroup
+	history        *history
+	generation     uint64
+	modified       time.Time
+	delta          deltaCursor
+	privacy        PrivacyMode
+	privacyKey     []byte
+	evict          *evictQueue
+	evicted        uint64
+	normalization  Normalization
+	regexRules     []regexRule
+	ruleHits       map[ruleKey]*ruleHit
+	reviews        map[string]time.Time
+	localAdded     map[string]time.Time
+	localRemoved   map[string]time.Time
+	reviewed       map[string]time.Time
+	onReviewDue    func(LocalReview)
+	paused         bool
+	anomalyGuard   AnomalyGuard
+	onAnomaly      func(Anomaly)
+	held           []heldUpdate
+	anomalyID      uint64
+	anomalies      uint64
+	syncFailures   int
+	syncErr        error
+	onMissedWindow func(MissedWindow)
+	missedWindows  uint64
+	subscribers    map[int]subscriber
+	subscriberID   int
+	snapshot       []string
 }
 
 func New(endpoint, identity string, client http.Client, options ...Option) *Client {
@@ -31,23 +73,30 @@ func New(endpoint, identity string, client http.Client, options ...Option) *Clie
 
 //Check if a domain is phishing
 //parent domains will not be checked, FuzzyCheck should be used instead
+//local domains that haven't expired and regex rules are also considered phishing
 func (c *Client) Check(domain string) bool {
-	c.m.Lock()
-	defer c.m.Unlock()
-	_, found := c.domains[domain]
-	return found
+	return c.Lookup(domain, CheckOpts{}).Phishing()
 }
 
 //FuzzyCheck if a domain is phishing
 //fuzzy check includes checking parent domains (foo.bar.bad.com will check bar.bad.com and bad.com)
 //and returns true if any of the domains is phishing
 func (c *Client) FuzzyCheck(domain string) bool {
-	for _, part := range generateVariants(domain) {
-		if c.Check(part) {
-			return true
-		}
+	return c.CheckDetailed(domain).Phishing()
+}
+
+//lookup checks if a domain is a known domain, or a local domain if local is true, returning if it's local
+//it doesn't count rule hits, so it can be used without affecting them, such as to plan an import
+func (c *Client) lookup(domain string, local bool) (bool, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, found := c.domains[domain]; found {
+		return true, false
 	}
-	return false
+	if local && c.checkLocal(domain) {
+		return true, true
+	}
+	return false, false
 }
 
 //Domains return a list of known phishing domains.
@@ -70,34 +119,123 @@ func (c *Client) Size() int {
 }
 
 //FullSync clears the local cache and loading all known domain form the api
-func (c *Client) FullSync() error {
+func (c *Client) FullSync() (err error) {
+	c.startSync(SyncOpFullSync)
+	defer func() { c.recordSync(SyncOpFullSync, err) }()
+	return c.fullSync()
+}
+
+//fullSync is FullSync without recording the result
+func (c *Client) fullSync() error {
+	if c.Paused() {
+		return ErrPaused
+	}
 	ds, err := c.r.All()
 	if err != nil {
 		return err
 	}
+	//the new set is built before locking, so checks aren't stalled while a large list is hashed
+	dMap, a := internDomains(ds)
 	c.m.Lock()
 	defer c.m.Unlock()
-	c.lastUpdated = time.Now()
-	dMap := map[string]empty{}
-	for _, d := range ds {
-		dMap[d] = empty{}
+	if c.paused {
+		return ErrPaused
+	}
+	//a held list isn't synced yet, so the next sync fetches the full list again
+	if !c.replaceDomains(c.filterKnown(dMap), a, SourceFullSync) {
+		c.lastUpdated = time.Now()
 	}
-	c.domains = dMap
 	c.sendUpdate()
 	return nil
 }
 
-//Update updates the list of known phishing domains from the api based on last update time.
-func (c *Client) Update() error {
+//replaceDomains swaps in a set of domains built by internDomains, reporting the difference as updates from the source
+//the set isn't swapped in if the AnomalyGuard holds the difference, in which case it returns true
+//should only be called when mutex is locked
+func (c *Client) replaceDomains(dMap map[string]empty, a *arena, source UpdateSource) bool {
+	if c.evict != nil {
+		c.evict.withoutEvicted(dMap)
+	}
+	diff := diffSets(c.domains, dMap)
+	if c.guard(diff, source, diff.Updates(), true) {
+		return true
+	}
+	c.domains = dMap
+	c.arena = a
+	for _, mod := range diff.Updates() {
+		c.trackMeta(mod, source)
+		c.trackEviction(mod)
+		c.emit(mod, source)
+	}
+	c.evictOverflow()
+	return false
+}
+
+//Reset clears the cache and the local domains, as if Client was just created, in a single step
+//settings such as categories, metadata, history, regex rules and listeners are kept
+//the removal of every known domain is reported to OnUpdate as an update from SourceReset
+func (c *Client) Reset() {
 	c.m.Lock()
 	defer c.m.Unlock()
-	mods, err := c.r.After(c.lastUpdated.Add(-(time.Minute * 1)))
+	c.lastUpdated = time.Time{}
+	c.replaceDomains(map[string]empty{}, nil, SourceReset)
+	if c.meta != nil {
+		c.meta = map[string]Metadata{}
+	}
+	c.local = nil
+	c.localAdded = nil
+	c.localRemoved = nil
+	c.reviews = nil
+	c.reviewed = nil
+	c.ruleHits = nil
+	c.held = nil
+	c.delta = deltaCursor{}
+	c.snapshot = nil
+	c.bumpGeneration()
+	c.resetEviction()
+	c.sendUpdate()
+}
+
+//InvalidateAndResync resets Client with Reset, and immediately does a FullSync
+//checks don't match anything until the FullSync is done, and the cache stays empty if it fails
+func (c *Client) InvalidateAndResync() error {
+	c.Reset()
+	return c.FullSync()
+}
+
+//Update updates the list of known phishing domains from the api based on last update time.
+//the request is made without holding the lock, so checks aren't blocked by the network
+//a cache older than the recent window does a full sync instead, see WithRecentWindow
+func (c *Client) Update() (err error) {
+	c.startSync(SyncOpUpdate)
+	defer func() { c.recordSync(SyncOpUpdate, err) }()
+	c.m.Lock()
+	since, paused := c.lastUpdated, c.paused
+	missed := !paused && c.missedWindow(since)
+	c.m.Unlock()
+	if paused {
+		return ErrPaused
+	}
+	if missed {
+		return c.fullSync()
+	}
+	started := time.Now()
+	mods, err := c.r.After(since.Add(-(time.Minute * 1)))
 	if err != nil {
 		return err
 	}
-	c.lastUpdated = time.Now()
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.paused {
+		return ErrPaused
+	}
+	//another sync may have finished while fetching, lastUpdated should never go backwards
+	if started.After(c.lastUpdated) {
+		c.lastUpdated = started
+	}
 	for _, mod := range mods {
-		c.applyMod(mod)
+		c.applyMod(mod, SourceRecent)
 	}
 	if len(mods) > 0 {
 		c.sendUpdate()
@@ -107,38 +245,130 @@ func (c *Client) Update() error {
 
 //ListenForUpdates starts a wss connection to the api and listens for updates.
 //use ctx to cancel close the connection
+//updates that have been received are applied before returning
 func (c *Client) ListenForUpdates(ctx context.Context) error {
+	c.draining.Add(1)
+	defer c.draining.Done()
 
-	modChan := make(chan DomainUpdate, 8)
-	defer close(modChan)
+	modChan := make(chan feedUpdate, c.r.feedBufferSize())
+	drained := make(chan struct{})
 	go func(a *Client) {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case mod, ok := <-modChan:
-				if !ok {
-					return
-				}
-				a.applyLiveUpdates(mod)
+		defer close(drained)
+		for update := range modChan {
+			//apply everything that queued up while the previous updates were applied in one go
+			updates := []feedUpdate{update}
+			for queued := len(modChan); queued > 0; queued-- {
+				updates = append(updates, <-modChan)
 			}
+			a.applyUpdates(updates, SourceFeed)
 		}
 	}(c)
 
-	return c.listenForUpdates(ctx, modChan)
+	err := c.listenForUpdates(ctx, modChan)
+	close(modChan)
+	<-drained
+	return err
+}
+
+//StartListening listens for updates in the background, see ListenForUpdates
+//it returns immediately, and is a no op if Client is already listening
+//listening stops when ctx is cancelled, StopListening is called, or the connection fails
+//use Listening and ListenErr to check on its status
+func (c *Client) StartListening(ctx context.Context) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.streaming || c.listenDone != nil {
+		return nil
+	}
+	done := make(chan struct{})
+	ctx, cancel := c.r.withBase(ctx)
+	c.listenDone = done
+	c.listenStop = cancel
+	c.listenErr = nil
+	go func() {
+		err := c.ListenForUpdates(ctx)
+		cancel()
+		c.m.Lock()
+		defer c.m.Unlock()
+		c.listenErr = err
+		c.listenDone = nil
+		c.listenStop = nil
+		close(done)
+	}()
+	return nil
+}
+
+//StopListening stops listening for updates and waits for the connection to close
+//it returns the error that stopped listening if any, see ListenErr
+func (c *Client) StopListening() error {
+	c.m.Lock()
+	done := c.listenDone
+	if c.listenStop != nil {
+		c.listenStop()
+	}
+	c.m.Unlock()
+	if done != nil {
+		<-done
+	}
+	return c.ListenErr()
+}
+
+//Listening returns true while Client is listening for updates
+func (c *Client) Listening() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.streaming || c.listenDone != nil
+}
+
+//ListenErr returns the error that stopped the last StartListening, nil if it's still listening or stopped cleanly
+func (c *Client) ListenErr() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.listenErr
 }
 
 //applyLiveUpdates applies an update to the cache
-func (c *Client) applyLiveUpdates(mod DomainUpdate) {
+func (c *Client) applyLiveUpdates(mod DomainUpdate, source UpdateSource) {
+	c.ApplyUpdates([]DomainUpdate{mod}, source)
+}
+
+//ApplyUpdates applies updates to the cache in order, as if they were received from source
+//the lock is only taken once, and a single update notification is sent for all of them,
+//making it cheaper than applying the updates one by one when catching up on many updates
+//listeners registered with OnUpdate are still called for every update
+func (c *Client) ApplyUpdates(mods []DomainUpdate, source UpdateSource) {
+	updates := make([]feedUpdate, len(mods))
+	for i, mod := range mods {
+		updates[i] = feedUpdate{mod: mod}
+	}
+	c.applyUpdates(updates, source)
+}
+
+//applyUpdates is ApplyUpdates, with when the updates were received if they came from the feed
+func (c *Client) applyUpdates(updates []feedUpdate, source UpdateSource) {
+	if len(updates) == 0 {
+		return
+	}
 	c.m.Lock()
 	defer c.m.Unlock()
+	if c.paused {
+		return
+	}
 	c.lastUpdated = time.Now()
-	c.applyMod(mod)
+	for _, u := range updates {
+		if source == SourceFeed {
+			c.feed.record(u, c.lastUpdated)
+		}
+		if source == SourceFeed && c.applySnapshot(u.mod) {
+			continue
+		}
+		c.applyMod(u.mod, source)
+	}
 	c.sendUpdate()
 }
 
 //listenForUpdates listens for updates from the api and pipe it into modChan
-func (c *Client) listenForUpdates(ctx context.Context, modChan chan DomainUpdate) error {
+func (c *Client) listenForUpdates(ctx context.Context, modChan chan feedUpdate) (err error) {
 	checkStreaming := func() error {
 		c.m.Lock()
 		defer c.m.Unlock()
@@ -146,7 +376,10 @@ func (c *Client) listenForUpdates(ctx context.Context, modChan chan DomainUpdate
 			return fmt.Errorf("already listening for updates")
 		}
 		c.streaming = true
+		c.feed.connects++
+		c.snapshot = nil
 		ctx, c.cancelFunc = context.WithCancel(ctx)
+		c.publish(Event{Kind: EventFeedStarted, Op: SyncOpFeed})
 		return nil
 	}
 	if err := checkStreaming(); err != nil {
@@ -161,8 +394,9 @@ func (c *Client) listenForUpdates(ctx context.Context, modChan chan DomainUpdate
 			c.cancelFunc()
 		}
 		c.cancelFunc = nil
+		c.publish(Event{Kind: EventFeedStopped, Op: SyncOpFeed, Err: err})
 	}()
-	return c.r.Feed(ctx, modChan)
+	return c.r.feedReceived(ctx, modChan)
 }
 
 //Close closes the client and releases all resources.
@@ -173,7 +407,10 @@ func (c *Client) Close() error {
 		c.cancelFunc()
 	}
 	c.domains = nil
-	close(c.updateChan)
+	c.arena = nil
+	if c.updateChan != nil {
+		close(c.updateChan)
+	}
 	c.updateChan = nil
 	return nil
 }
@@ -207,30 +444,137 @@ func (c *Client) sendUpdate() {
 	}
 }
 
-//applyMod applies an update to the cache
+//OnUpdate registers fn to be called with every update applied to Client, with only the domains it added or removed
+//full syncs are reported as the difference between the old and new domains, updates that change nothing are not reported
+//fn is called while Client is locked, it must not block or call back into Client
+//calling the returned function unregisters fn
+func (c *Client) OnUpdate(fn func(AppliedUpdate)) func() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.listeners == nil {
+		c.listeners = map[int]func(AppliedUpdate){}
+	}
+	id := c.listenerID
+	c.listenerID++
+	c.listeners[id] = fn
+	return func() {
+		c.m.Lock()
+		defer c.m.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+//applyMod applies an update to the cache, unless the AnomalyGuard holds it
+//should only be called when mutex is locked
+func (c *Client) applyMod(mod DomainUpdate, source UpdateSource) {
+	if !c.allowUpdate(mod) {
+		return
+	}
+	if c.guardMod(mod, source) {
+		return
+	}
+	c.applyUnguarded(mod, source)
+}
+
+//applyUnguarded applies a mod without checking it against the AnomalyGuard
 //should only be called when mutex is locked
-func (c *Client) applyMod(mod DomainUpdate) {
+func (c *Client) applyUnguarded(mod DomainUpdate, source UpdateSource) {
+	if !c.allowUpdate(mod) {
+		return
+	}
+	if mod.Add {
+		mod.Domains = c.internAll(mod.Domains)
+	}
+	//only domains that are actually added or removed are reported, re-adding a known domain is not a change
+	//so history, listeners and the generation only ever see changes, and Changes can net them out
+	changed := make([]string, 0, len(mod.Domains))
 	for _, domain := range mod.Domains {
+		if _, found := c.domains[domain]; found == mod.Add {
+			continue
+		}
 		if mod.Add {
 			c.domains[domain] = empty{}
 		} else {
 			delete(c.domains, domain)
 		}
+		changed = append(changed, domain)
+	}
+	if len(changed) == 0 {
+		return
 	}
+	mod.Domains = changed
+	c.trackMeta(mod, source)
+	c.trackEviction(mod)
+	c.emit(mod, source)
+	c.evictOverflow()
 }
 
-//MarshalJSON marshal the Client's cache to JSON
-func (c *Client) MarshalJSON() ([]byte, error) {
-	c.m.Lock()
-	defer c.m.Unlock()
-	sf := save{
-		LastUpdated: c.lastUpdated,
-		Domains:     make([]string, 0, len(c.domains)),
+//internAll interns domains that aren't known yet into the Client's arena
+//it returns a new slice so the caller's slice is left untouched
+//should only be called when mutex is locked
+func (c *Client) internAll(domains []string) []string {
+	if c.arena == nil {
+		c.arena = &arena{}
 	}
-	for d := range c.domains {
-		sf.Domains = append(sf.Domains, d)
+	interned := make([]string, len(domains))
+	for i, d := range domains {
+		if _, found := c.domains[d]; found {
+			interned[i] = d
+			continue
+		}
+		interned[i] = c.arena.intern(d)
+	}
+	return interned
+}
+
+//emit notifies all registered listeners of an applied update
+//should only be called when mutex is locked
+func (c *Client) emit(mod DomainUpdate, source UpdateSource) {
+	c.bumpGeneration()
+	if len(c.listeners) == 0 && len(c.subscribers) == 0 && c.history == nil {
+		return
+	}
+	au := AppliedUpdate{
+		Update: mod,
+		Time:   time.Now(),
+		Source: source,
+	}
+	if c.history != nil {
+		c.history.record(au, c.generation)
+	}
+	for _, fn := range c.listeners {
+		fn(au)
 	}
-	return json.Marshal(sf)
+	c.publish(Event{Kind: EventUpdateApplied, Time: au.Time, Update: &au})
+}
+
+//Generation returns a number that is increased by every change to the cache, including local domains and loading a cache
+//updates that add or remove no domain don't increase it, so it can be compared to cheaply detect changes, such as by replication followers
+//it may still increase without a visible difference, such as when a cache is loaded or local domains are added again
+//the generation starts at 0 and is not persisted with the cache, it's only comparable within the same Client unless restored with RestoreSyncState
+func (c *Client) Generation() uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.generation
+}
+
+//bumpGeneration increases the generation of the cache, and records when it got modified
+//should only be called when mutex is locked
+func (c *Client) bumpGeneration() {
+	c.generation++
+	c.modified = time.Now()
+}
+
+//version returns the generation of the cache, and when it was last modified
+func (c *Client) version() (uint64, time.Time) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.generation, c.modified
+}
+
+//MarshalJSON marshal the Client's cache to JSON
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toSave())
 }
 
 //UnmarshalJSON unmarshal the Client's cache from JSON
@@ -240,23 +584,81 @@ func (c *Client) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	c.lastUpdated = sf.LastUpdated
-	dMap := map[string]empty{}
-	for _, d := range sf.Domains {
-		dMap[d] = empty{}
+	c.fromSave(sf)
+	return nil
+}
+
+//MarshalMsgpack marshal the Client's cache to MessagePack
+//it is faster than MarshalJSON for large caches
+func (c *Client) MarshalMsgpack() ([]byte, error) {
+	return msgpackMarshal(c.toSave())
+}
+
+//UnmarshalMsgpack unmarshal the Client's cache from MessagePack
+func (c *Client) UnmarshalMsgpack(data []byte) error {
+	var sf save
+	err := msgpackUnmarshal(data, &sf)
+	if err != nil {
+		return err
 	}
-	c.domains = dMap
+	c.fromSave(sf)
 	return nil
 }
 
-//generateVariants generate variations of the domain and parent domains
-//"foo.bar.bad.com" will generate itself, "bar.bad.com" and "bad.com" but not "com"
-//could have been optimized with callbacks or channels but this is simpler
-func generateVariants(domain string) []string {
-	var variants []string
-	parts := strings.Split(domain, ".")
-	for i := 0; i < len(parts)-1; i++ {
-		variants = append(variants, strings.Join(parts[i:], "."))
+//toSave copies the Client's cache into the save format
+func (c *Client) toSave() save {
+	c.m.Lock()
+	defer c.m.Unlock()
+	sf := save{
+		LastUpdated: c.lastUpdated,
+		Domains:     make([]string, 0, len(c.domains)),
+	}
+	for d := range c.domains {
+		sf.Domains = append(sf.Domains, d)
+	}
+	if len(c.local) > 0 {
+		sf.Local = make(map[string]time.Time, len(c.local))
+		for d, expiry := range c.local {
+			sf.Local[d] = expiry
+		}
+	}
+	if len(c.reviews) > 0 {
+		sf.Reviews = make(map[string]time.Time, len(c.reviews))
+		for d, review := range c.reviews {
+			sf.Reviews[d] = review
+		}
+	}
+	if len(c.localAdded) > 0 {
+		sf.LocalAdded = make(map[string]time.Time, len(c.localAdded))
+		for d, added := range c.localAdded {
+			sf.LocalAdded[d] = added
+		}
+	}
+	if len(c.localRemoved) > 0 {
+		sf.LocalRemoved = make(map[string]time.Time, len(c.localRemoved))
+		for d, removed := range c.localRemoved {
+			sf.LocalRemoved[d] = removed
+		}
 	}
-	return variants
+	if c.meta != nil {
+		sf.Metadata = make(map[string]Metadata, len(c.meta))
+		for d, md := range c.meta {
+			sf.Metadata[d] = md
+		}
+	}
+	return sf
+}
+
+//fromSave replaces the Client's cache with the save format
+func (c *Client) fromSave(sf save) {
+	c.lastUpdated = sf.LastUpdated
+	c.domains, c.arena = internDomains(sf.Domains)
+	c.meta = sf.Metadata
+	c.local = sf.Local
+	c.reviews = sf.Reviews
+	c.localAdded = sf.LocalAdded
+	c.localRemoved = sf.LocalRemoved
+	c.bumpGeneration()
+	c.history.reset(c.generation)
+	c.resetEviction()
 }
@@ -2,7 +2,11 @@ package sinkingyachts
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerateVariants(t *testing.T) {
@@ -45,8 +49,148 @@ func TestGenerateVariants(t *testing.T) {
 	for _, data := range tests {
 		t.Run(data.name, func(t *testing.T) {
 			a := assert.New(t)
-			result := generateVariants(data.input)
+			result := GenerateVariants(data.input)
 			a.Equal(data.expected, result)
 		})
 	}
 }
+
+func TestGeneration(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	a.Equal(uint64(0), c.Generation())
+
+	steps := []struct {
+		name    string
+		change  func()
+		changed bool
+	}{
+		{"add", func() { c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed) }, true},
+		{"remove", func() { c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed) }, true},
+		{"add local", func() { c.AddLocal(0, "local.com") }, true},
+		{"remove missing local", func() { c.RemoveLocal("missing.com") }, false},
+		{"remove local", func() { c.RemoveLocal("local.com") }, true},
+		{"sweep nothing", func() { c.SweepExpired() }, false},
+		{"load", func() { a.NoError(ReadCacheFrom(c, strings.NewReader(`{"domains":["b.com"]}`))) }, true},
+	}
+	for _, step := range steps {
+		before := c.Generation()
+		step.change()
+		a.Equal(step.changed, c.Generation() > before, step.name)
+	}
+	a.Equal(c.Generation(), c.Stats().Generation)
+}
+
+func TestUpdateUnlocked(t *testing.T) {
+	a := assert.New(t)
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(fetching)
+		<-release
+		_, _ = w.Write([]byte(`[{"type":"add","domains":["a.com"]}]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "test", http.Client{})
+	done := make(chan error)
+	go func() {
+		done <- c.Update()
+	}()
+	<-fetching
+	//checks must not block while the request is in flight
+	a.False(c.Check("a.com"))
+	close(release)
+	a.NoError(<-done)
+	a.True(c.Check("a.com"))
+	a.False(c.lastUpdated.IsZero())
+}
+
+func TestApplyUpdates(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	updates := c.UpdateChannel()
+	var applied []AppliedUpdate
+	c.OnUpdate(func(au AppliedUpdate) {
+		applied = append(applied, au)
+	})
+
+	c.ApplyUpdates([]DomainUpdate{
+		{Add: true, Domains: []string{"a.com", "b.com"}},
+		{Add: false, Domains: []string{"a.com"}},
+		{Add: true, Domains: []string{"c.com"}},
+	}, SourceWebhook)
+	a.ElementsMatch([]string{"b.com", "c.com"}, c.Domains())
+	a.Len(applied, 3)
+	for _, au := range applied {
+		a.Equal(SourceWebhook, au.Source)
+	}
+	a.Len(updates, 1)
+
+	c.ApplyUpdates(nil, SourceWebhook)
+	a.Len(updates, 1)
+}
+
+func TestReset(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["fresh.com"]`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test", http.Client{})
+	c.EnableMetadata()
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	c.AddLocal(0, "local.com")
+	var removed []string
+	remove := c.OnUpdate(func(au AppliedUpdate) {
+		a.Equal(SourceReset, au.Source)
+		a.False(au.Update.Add)
+		removed = append(removed, au.Update.Domains...)
+	})
+	updates := c.UpdateChannel()
+	before := c.Generation()
+
+	c.Reset()
+	a.ElementsMatch([]string{"a.com", "b.com"}, removed)
+	a.Zero(c.Size())
+	a.Empty(c.LocalDomains())
+	a.False(c.Check("local.com"))
+	a.True(c.lastUpdated.IsZero())
+	a.Greater(c.Generation(), before)
+	a.Len(updates, 1)
+	_, ok := c.Metadata("a.com")
+	a.False(ok)
+
+	remove()
+	a.NoError(c.InvalidateAndResync())
+	a.Equal([]string{"fresh.com"}, c.Domains())
+}
+
+func TestPause(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["fresh.com"]`))
+	}))
+	defer srv.Close()
+	m, err := NewManager(Config{Endpoint: srv.URL, Identity: "test"})
+	a.NoError(err)
+	c := m.Client()
+	c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: []string{"a.com"}}}, SourceFeed)
+
+	m.Pause()
+	a.True(m.Paused())
+	a.True(c.Stats().Paused)
+	c.ApplyUpdates([]DomainUpdate{{Add: false, Domains: []string{"a.com"}}}, SourceFeed)
+	a.ErrorIs(c.FullSync(), ErrPaused)
+	a.ErrorIs(c.Update(), ErrPaused)
+	a.True(c.Check("a.com"))
+	c.AddLocal(0, "local.com")
+	a.True(c.Check("local.com"))
+	m.synced()
+	a.NoError(m.Ready(time.Nanosecond))
+
+	a.NoError(m.Resume())
+	a.False(m.Paused())
+	a.Equal([]string{"fresh.com"}, c.Domains())
+	a.True(c.Check("local.com"))
+}
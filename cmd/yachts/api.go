@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/thunder33345/sinkingyachts"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//apiFlags are the flags shared by commands that call the api
+type apiFlags struct {
+	fs       *flag.FlagSet
+	endpoint string
+	identity string
+	timeout  time.Duration
+	output   string
+}
+
+func newAPIFlags(name string) *apiFlags {
+	f := &apiFlags{fs: flag.NewFlagSet(name, flag.ExitOnError)}
+	endpoint := os.Getenv("YACHTS_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://phish.sinking.yachts"
+	}
+	f.fs.StringVar(&f.endpoint, "endpoint", endpoint, "root of the api, defaults to YACHTS_ENDPOINT if set")
+	f.fs.StringVar(&f.identity, "identity", os.Getenv("YACHTS_IDENTITY"), "identity of your application and your contact, defaults to YACHTS_IDENTITY")
+	f.fs.DurationVar(&f.timeout, "timeout", time.Second*30, "timeout of api requests")
+	f.fs.StringVar(&f.output, "output", outputPlain, "output format, plain, table or json")
+	return f
+}
+
+//parse parses args and returns the RawClient configured by the flags
+func (f *apiFlags) parse(args []string) (sinkingyachts.RawClient, error) {
+	if err := f.parseFlags(args); err != nil {
+		return sinkingyachts.RawClient{}, err
+	}
+	if f.identity == "" {
+		return sinkingyachts.RawClient{}, errIdentity
+	}
+	return sinkingyachts.NewRawClient(f.endpoint, f.identity, sinkingyachts.NewHTTPClient(f.timeout)), nil
+}
+
+//parseFlags parses args without creating a client
+func (f *apiFlags) parseFlags(args []string) error {
+	_ = f.fs.Parse(args)
+	return (result{}).write(io.Discard, f.output)
+}
+
+//client returns a Client configured by the flags
+func (f *apiFlags) client() (*sinkingyachts.Client, error) {
+	if f.identity == "" {
+		return nil, errIdentity
+	}
+	return sinkingyachts.New(f.endpoint, f.identity, sinkingyachts.NewHTTPClient(f.timeout)), nil
+}
+
+var errIdentity = errors.New("-identity or YACHTS_IDENTITY is required")
+
+//print writes the result to stdout in the output format
+func (f *apiFlags) print(r result) error {
+	return r.write(os.Stdout, f.output)
+}
+
+//checkResult is a checked domain in json output
+type checkResult struct {
+	Domain   string `json:"domain"`
+	Phishing bool   `json:"phishing"`
+}
+
+//runCheck checks domains, exiting with exitFlagged if any of them is phishing
+func runCheck(args []string) error {
+	f := newAPIFlags("check")
+	r, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	domains := f.fs.Args()
+	if len(domains) == 0 {
+		return errors.New("usage: yachts check [flags] <domain>...")
+	}
+	res, err := r.CheckMany(context.Background(), domains)
+	if err != nil {
+		return err
+	}
+	out := result{header: []string{"DOMAIN", "PHISHING"}}
+	checks := make([]checkResult, 0, len(domains))
+	flagged := false
+	for _, domain := range domains {
+		checks = append(checks, checkResult{Domain: domain, Phishing: res[domain]})
+		out.rows = append(out.rows, []string{domain, strconv.FormatBool(res[domain])})
+		flagged = flagged || res[domain]
+	}
+	out.value = checks
+	if err = f.print(out); err != nil {
+		return err
+	}
+	if flagged {
+		return exitError{code: exitFlagged}
+	}
+	return nil
+}
+
+func runSize(args []string) error {
+	f := newAPIFlags("size")
+	r, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	size, err := r.Size()
+	if err != nil {
+		return err
+	}
+	return f.print(result{
+		header: []string{"SIZE"},
+		rows:   [][]string{{strconv.Itoa(size)}},
+		value:  map[string]int{"size": size},
+	})
+}
+
+func runRecent(args []string) error {
+	f := newAPIFlags("recent")
+	seconds := f.fs.Int("seconds", 300, "how many seconds back to fetch updates from")
+	r, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	mods, err := r.Recent(*seconds)
+	if err != nil {
+		return err
+	}
+	out := result{header: []string{"TYPE", "DOMAINS"}, value: mods}
+	if mods == nil {
+		out.value = []sinkingyachts.DomainUpdate{}
+	}
+	for _, mod := range mods {
+		kind := "delete"
+		if mod.Add {
+			kind = "add"
+		}
+		out.rows = append(out.rows, []string{kind, strings.Join(mod.Domains, ",")})
+	}
+	return f.print(out)
+}
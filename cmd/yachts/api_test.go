@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAPIFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		endpoint string
+		timeout  time.Duration
+		rest     []string
+		err      error
+	}{
+		{name: "defaults", env: map[string]string{"YACHTS_IDENTITY": "env"}, endpoint: "https://phish.sinking.yachts", timeout: time.Second * 30},
+		{name: "env", env: map[string]string{"YACHTS_IDENTITY": "env", "YACHTS_ENDPOINT": "https://mirror.test"}, endpoint: "https://mirror.test", timeout: time.Second * 30},
+		{
+			name:     "flags",
+			env:      map[string]string{"YACHTS_ENDPOINT": "https://mirror.test"},
+			args:     []string{"-endpoint", "https://other.test", "-identity", "flag", "-timeout", "5s", "-output", "json", "a.com"},
+			endpoint: "https://other.test",
+			timeout:  time.Second * 5,
+			rest:     []string{"a.com"},
+		},
+		{name: "missing identity", err: errIdentity},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			t.Setenv("YACHTS_IDENTITY", "")
+			t.Setenv("YACHTS_ENDPOINT", "")
+			for k, v := range data.env {
+				t.Setenv(k, v)
+			}
+			f := newAPIFlags("test")
+			_, err := f.parse(data.args)
+			if data.err != nil {
+				a.ErrorIs(err, data.err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(data.endpoint, f.endpoint)
+			a.Equal(data.timeout, f.timeout)
+			a.Equal(len(data.rest), f.fs.NArg())
+			a.Equal(data.rest, f.fs.Args()[:len(data.rest)])
+		})
+	}
+
+	f := newAPIFlags("test")
+	a := assert.New(t)
+	a.Error(f.parseFlags([]string{"-output", "yaml"}), "unknown output formats are rejected")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+//runBackfill prints recent updates dated by when they happened, see sinkingyachts.Backfill
+func runBackfill(args []string) error {
+	f := newAPIFlags("backfill")
+	window := f.fs.Duration("window", time.Hour*24, "how far back to reconstruct updates")
+	chunk := f.fs.Duration("chunk", time.Hour, "resolution of the reconstructed times")
+	format := f.fs.String("format", watchJSONL, "dataset format, jsonl or csv")
+	r, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if *format != watchJSONL && *format != "csv" {
+		return fmt.Errorf("unknown format %q, expected jsonl or csv", *format)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	updates, err := sinkingyachts.Backfill(ctx, r, *window, *chunk)
+	if err != nil {
+		return err
+	}
+	if *format == "csv" {
+		return sinkingyachts.WriteUpdatesCSV(os.Stdout, updates)
+	}
+	j := sinkingyachts.NewJournal(os.Stdout)
+	for _, au := range updates {
+		if err = j.Publish(ctx, au); err != nil {
+			return err
+		}
+	}
+	return nil
+}
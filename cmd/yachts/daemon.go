@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+//daemonOptions are the flags of the daemon command
+type daemonOptions struct {
+	configPath string
+	adminAddr  string
+	healthAddr string
+	mirrorAddr string
+	accessLog  string
+	rateLimit  float64
+	rateBurst  int
+	threshold  time.Duration
+}
+
+func parseDaemon(name string, args []string) (daemonOptions, error) {
+	var opts daemonOptions
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&opts.configPath, "config", "", "path to the config file, json, yaml or toml")
+	fs.StringVar(&opts.adminAddr, "admin", "", "address to serve the admin api on, the token is read from YACHTS_ADMIN_TOKEN")
+	fs.StringVar(&opts.healthAddr, "health", "", "address to serve /healthz, /readyz and /stats on")
+	fs.StringVar(&opts.mirrorAddr, "mirror", "", "address to serve the cache on, over the same endpoints as the api, tokens required are read from YACHTS_MIRROR_TOKENS separated by commas")
+	fs.StringVar(&opts.accessLog, "access-log", "", "file to append json access logs of the mirror to, - for stderr")
+	fs.Float64Var(&opts.rateLimit, "rate-limit", 0, "requests per second allowed per client of the admin api and mirror, 0 disables limiting")
+	fs.IntVar(&opts.rateBurst, "rate-burst", 20, "requests a client may burst beyond -rate-limit")
+	fs.DurationVar(&opts.threshold, "ready-threshold", time.Minute*10, "how stale the cache may get without a connected feed before it's not ready")
+	_ = fs.Parse(args)
+	if opts.configPath == "" {
+		return opts, errors.New("-config is required")
+	}
+	abs, err := filepath.Abs(opts.configPath)
+	if err != nil {
+		return opts, err
+	}
+	opts.configPath = abs
+	return opts, nil
+}
+
+//args returns the flags that parse back into the options
+func (opts daemonOptions) args() []string {
+	return []string{
+		"-config", opts.configPath,
+		"-admin", opts.adminAddr,
+		"-health", opts.healthAddr,
+		"-mirror", opts.mirrorAddr,
+		"-access-log", opts.accessLog,
+		"-rate-limit", strconv.FormatFloat(opts.rateLimit, 'g', -1, 64),
+		"-rate-burst", strconv.Itoa(opts.rateBurst),
+		"-ready-threshold", opts.threshold.String(),
+	}
+}
+
+func runDaemon(args []string) error {
+	opts, err := parseDaemon("daemon", args)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return opts.run(ctx, func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+	})
+}
+
+//run runs the daemon until cancelled by ctx, errors that don't stop the daemon are passed to logErr
+func (opts daemonOptions) run(ctx context.Context, logErr func(error)) error {
+	cfg, err := sinkingyachts.LoadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	m, err := sinkingyachts.NewManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	//the log level is checked on every warning, so reloading it takes effect immediately
+	logWarn := func(err error) {
+		if warnings, _ := m.Config().Warnings(); warnings {
+			logErr(err)
+		}
+	}
+	m.OnError(logWarn)
+	m.Client().OnReviewDue(func(lr sinkingyachts.LocalReview) {
+		logWarn(fmt.Errorf("warning: local domain %s was due for review on %s", lr.Domain, lr.Review.Format(time.RFC3339)))
+	})
+	m.Client().OnAnomaly(func(an sinkingyachts.Anomaly) {
+		action := "applied"
+		if an.Held {
+			action = fmt.Sprintf("held as %d", an.ID)
+		}
+		logWarn(fmt.Errorf("warning: %s update %s, %s", an.Source, an.Reason, action))
+	})
+	m.Client().OnMissedWindow(func(mw sinkingyachts.MissedWindow) {
+		logWarn(fmt.Errorf("warning: cache last updated %s is older than the recent window of %s, doing a full sync", mw.LastUpdated.Format(time.RFC3339), mw.Window))
+	})
+	go m.ReloadOnSignal(ctx, opts.configPath, logErr)
+	go m.RunAlerts(ctx, logErr)
+	go func() {
+		if err := sinkingyachts.NotifySystemd(ctx, m, opts.threshold); err != nil {
+			logErr(err)
+		}
+	}()
+	if opts.adminAddr != "" {
+		token := os.Getenv("YACHTS_ADMIN_TOKEN")
+		if token == "" {
+			return errors.New("YACHTS_ADMIN_TOKEN is required to serve the admin api")
+		}
+		serve(ctx, opts.adminAddr, opts.limit(sinkingyachts.AdminHandler(m, token)), logErr)
+	}
+	if opts.healthAddr != "" {
+		serve(ctx, opts.healthAddr, sinkingyachts.HealthHandler(m, opts.threshold), logErr)
+	}
+	if opts.mirrorAddr != "" {
+		mirror := sinkingyachts.NewMirror(m.Client())
+		defer mirror.Close()
+		var handler http.Handler = mirror
+		if tokens := os.Getenv("YACHTS_MIRROR_TOKENS"); tokens != "" {
+			handler = sinkingyachts.BearerAuth(handler, strings.Split(tokens, ",")...)
+		}
+		//limited before authorizing, so guessing tokens is limited too
+		handler = opts.limit(handler)
+		if opts.accessLog != "" {
+			sink, closeSink, err := openAccessLog(opts.accessLog)
+			if err != nil {
+				return err
+			}
+			defer closeSink()
+			handler = sinkingyachts.AccessLog(handler, sink)
+		}
+		serve(ctx, opts.mirrorAddr, handler, logErr)
+	}
+	return m.Run(ctx)
+}
+
+//openAccessLog opens the access log file for appending, or stderr for "-"
+func openAccessLog(path string) (sinkingyachts.AccessSink, func() error, error) {
+	if path == "-" {
+		return sinkingyachts.JSONAccessSink(os.Stderr), func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sinkingyachts.JSONAccessSink(f), f.Close, nil
+}
+
+//limit wraps handler with the rate limit, if any
+func (opts daemonOptions) limit(handler http.Handler) http.Handler {
+	if opts.rateLimit <= 0 {
+		return handler
+	}
+	return sinkingyachts.NewRateLimiter(opts.rateLimit, opts.rateBurst).Wrap(handler)
+}
+
+//serve serves handler on addr in the background until ctx is cancelled
+func serve(ctx context.Context, addr string, handler http.Handler, onError func(error)) {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: time.Second * 10}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			onError(err)
+		}
+	}()
+}
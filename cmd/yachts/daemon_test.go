@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDaemon(t *testing.T) {
+	abs, err := filepath.Abs("config.yaml")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		args []string
+		want daemonOptions
+		err  bool
+	}{
+		{name: "missing config", args: []string{"-admin", ":8080"}, err: true},
+		{name: "defaults", args: []string{"-config", "config.yaml"}, want: daemonOptions{
+			configPath: abs,
+			rateBurst:  20,
+			threshold:  time.Minute * 10,
+		}},
+		{name: "all", args: []string{
+			"-config", "config.yaml",
+			"-admin", ":8080",
+			"-health", ":8081",
+			"-mirror", ":8082",
+			"-access-log", "-",
+			"-rate-limit", "2.5",
+			"-rate-burst", "5",
+			"-ready-threshold", "1m",
+		}, want: daemonOptions{
+			configPath: abs,
+			adminAddr:  ":8080",
+			healthAddr: ":8081",
+			mirrorAddr: ":8082",
+			accessLog:  "-",
+			rateLimit:  2.5,
+			rateBurst:  5,
+			threshold:  time.Minute,
+		}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			opts, err := parseDaemon("daemon", data.args)
+			if data.err {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(data.want, opts)
+
+			//the options are passed on as flags when installed as a service
+			parsed, err := parseDaemon("daemon", opts.args())
+			a.NoError(err)
+			a.Equal(opts, parsed)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//exporters are the formats of the export command by name
+var exporters = map[string]sinkingyachts.Exporter{
+	"text":    sinkingyachts.ExportText,
+	"csv":     sinkingyachts.ExportCSV,
+	"stix":    sinkingyachts.STIXExporter{},
+	"misp":    sinkingyachts.MISPExporter{},
+	"squid":   sinkingyachts.ExportSquid,
+	"envoy":   sinkingyachts.ExportEnvoy,
+	"unbound": sinkingyachts.ExportUnbound,
+	"knot":    sinkingyachts.ExportKnot,
+}
+
+//runExport prints every known domain, from the api or a cache saved by the daemon
+//-format picks an exporter, otherwise the domains are printed in the -output format
+func runExport(args []string) error {
+	f := newAPIFlags("export")
+	format := f.fs.String("format", "", "export format, one of "+strings.Join(exporterNames(), ", "))
+	tmpl := f.fs.String("template", "", "export through a text/template file, executed with sinkingyachts.TemplateData")
+	cache := f.fs.String("cache", "", "export from a cache file saved by the daemon instead of the api, .msgpack files are read as msgpack")
+	if err := f.parseFlags(args); err != nil {
+		return err
+	}
+
+	var e sinkingyachts.Exporter
+	if *format != "" {
+		var ok bool
+		if e, ok = exporters[*format]; !ok {
+			return fmt.Errorf("unknown format %q", *format)
+		}
+	}
+	if *tmpl != "" {
+		t, err := template.ParseFiles(*tmpl)
+		if err != nil {
+			return err
+		}
+		e = sinkingyachts.TemplateExporter(t)
+	}
+	c, err := exportSource(f, *cache)
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		return sinkingyachts.WriteExport(c, os.Stdout, e)
+	}
+
+	domains := c.Domains()
+	sort.Strings(domains)
+	out := result{header: []string{"DOMAIN"}, value: domains}
+	for _, domain := range domains {
+		out.rows = append(out.rows, []string{domain})
+	}
+	return f.print(out)
+}
+
+//exportSource returns a Client loaded from the cache file, or synced from the api if cache is empty
+func exportSource(f *apiFlags, cache string) (*sinkingyachts.Client, error) {
+	if cache != "" {
+		c := sinkingyachts.New("", "", http.Client{})
+		return c, cacheStore(cache).Load(c)
+	}
+	c, err := f.client()
+	if err != nil {
+		return nil, err
+	}
+	return c, c.FullSync()
+}
+
+func exporterNames() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
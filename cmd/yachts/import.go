@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//importFormats are the list formats of the import command by name
+var importFormats = map[string]sinkingyachts.ImportFormat{
+	"text":  sinkingyachts.ImportText,
+	"csv":   sinkingyachts.ImportCSV,
+	"hosts": sinkingyachts.ImportHosts,
+}
+
+//runImport compares external lists against the cache, and adds new domains as local domains with -apply
+//without -apply it's a dry run that only prints the changes
+func runImport(args []string) error {
+	f := newAPIFlags("import")
+	format := f.fs.String("format", "text", "list format, text, csv or hosts")
+	cache := f.fs.String("cache", "", "cache file saved by the daemon to import into, compared against the api if empty")
+	apply := f.fs.Bool("apply", false, "add new domains as local domains into -cache, instead of only printing them")
+	ttl := f.fs.Duration("ttl", 0, "how long applied domains stay local domains, 0 keeps them until removed")
+	review := f.fs.Duration("review", 0, "when applied domains should be reviewed, 0 sets no review date")
+	if err := f.parseFlags(args); err != nil {
+		return err
+	}
+	importFormat, ok := importFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *apply && *cache == "" {
+		return errors.New("-apply requires -cache, local domains would otherwise be lost")
+	}
+
+	var domains []string
+	files := f.fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, file := range files {
+		read, err := readImportFile(file, importFormat)
+		if err != nil {
+			return err
+		}
+		domains = append(domains, read...)
+	}
+
+	c, err := exportSource(f, *cache)
+	if err != nil {
+		return err
+	}
+	warnReviews(c)
+	plan := c.PlanImport(domains)
+	out := result{header: []string{"CHANGE", "DOMAIN"}, value: plan}
+	for _, domain := range plan.Add {
+		out.rows = append(out.rows, []string{"+", domain})
+	}
+	for _, domain := range plan.Invalid {
+		out.rows = append(out.rows, []string{"!", domain})
+	}
+	if err = f.print(out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d new, %d known, %d invalid\n", len(plan.Add), len(plan.Known), len(plan.Invalid))
+	if !*apply {
+		return nil
+	}
+	c.ApplyImport(plan, *ttl)
+	if *review > 0 {
+		c.SetLocalReview(time.Now().Add(*review), plan.Add...)
+	}
+	return cacheStore(*cache).Save(c)
+}
+
+//warnReviews warns about local domains of the cache that passed their review date
+func warnReviews(c *sinkingyachts.Client) {
+	for _, lr := range c.DueReviews() {
+		fmt.Fprintf(os.Stderr, "warning: local domain %s was due for review on %s\n", lr.Domain, lr.Review.Format(time.RFC3339))
+	}
+}
+
+//readImportFile reads a list from file, or stdin if file is "-"
+func readImportFile(file string, format sinkingyachts.ImportFormat) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		fl, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer fl.Close()
+		r = fl
+	}
+	return sinkingyachts.ReadImport(r, format)
+}
+
+//cacheStore returns the FileStore of a cache file, .msgpack files are msgpack and everything else json
+func cacheStore(path string) *sinkingyachts.FileStore {
+	format := sinkingyachts.CacheJSON
+	if strings.EqualFold(filepath.Ext(path), ".msgpack") {
+		format = sinkingyachts.CacheMsgpack
+	}
+	return sinkingyachts.NewFileStore(path, format)
+}
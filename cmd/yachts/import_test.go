@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/thunder33345/sinkingyachts"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunImport(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "list.txt")
+	assert.NoError(t, os.WriteFile(list, []byte("known.com\nnew.com\n"), 0644))
+	hosts := filepath.Join(dir, "hosts")
+	assert.NoError(t, os.WriteFile(hosts, []byte("0.0.0.0 hosts.com\n"), 0644))
+	cache := filepath.Join(dir, "cache.json")
+	c := sinkingyachts.New("", "test", http.Client{})
+	c.ApplyUpdates([]sinkingyachts.DomainUpdate{{Add: true, Domains: []string{"known.com"}}}, sinkingyachts.SourceFeed)
+	assert.NoError(t, cacheStore(cache).Save(c))
+
+	tests := []struct {
+		name  string
+		args  []string
+		err   bool
+		local []string
+	}{
+		{name: "unknown format", args: []string{"-format", "xml", "-cache", cache, list}, err: true},
+		{name: "apply without cache", args: []string{"-apply", list}, err: true},
+		{name: "missing file", args: []string{"-cache", cache, filepath.Join(dir, "missing.txt")}, err: true},
+		{name: "dry run", args: []string{"-cache", cache, list}},
+		{name: "apply", args: []string{"-cache", cache, "-apply", list}, local: []string{"new.com"}},
+		{name: "apply hosts", args: []string{"-cache", cache, "-format", "hosts", "-apply", hosts}, local: []string{"new.com", "hosts.com"}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			err := runImport(data.args)
+			if data.err {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			loaded := sinkingyachts.New("", "test", http.Client{})
+			a.NoError(cacheStore(cache).Load(loaded))
+			a.Equal([]string{"known.com"}, loaded.Domains())
+			var local []string
+			for domain := range loaded.LocalDomains() {
+				local = append(local, domain)
+			}
+			a.ElementsMatch(data.local, local)
+		})
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	tests := []struct {
+		path   string
+		format sinkingyachts.CacheFormat
+	}{
+		{path: "cache.json", format: sinkingyachts.CacheJSON},
+		{path: "cache.msgpack", format: sinkingyachts.CacheMsgpack},
+		{path: "cache.MSGPACK", format: sinkingyachts.CacheMsgpack},
+		{path: "cache", format: sinkingyachts.CacheJSON},
+	}
+	for _, data := range tests {
+		assert.Equal(t, sinkingyachts.NewFileStore(data.path, data.format), cacheStore(data.path), data.path)
+	}
+}
@@ -0,0 +1,63 @@
+//Command yachts is a command line client and daemon for the sinking yachts api
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+//command is a subcommand of yachts, args excludes the name of the command
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "check", usage: "check if domains are phishing, exits with 3 if any is", run: runCheck},
+	{name: "size", usage: "print the amount of known phishing domains", run: runSize},
+	{name: "recent", usage: "print recent updates", run: runRecent},
+	{name: "export", usage: "print every known phishing domain", run: runExport},
+	{name: "import", usage: "compare external lists against the cache and add new domains as local domains", run: runImport},
+	{name: "restore", usage: "list the backups of a cache file, or roll it back to one", run: runRestore},
+	{name: "watch", usage: "stream feed updates", run: runWatch},
+	{name: "backfill", usage: "print recent updates dated by when they happened", run: runBackfill},
+	{name: "daemon", usage: "keep a synced cache as described by a config file", run: runDaemon},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+//run runs the command named by the first of args, and returns the exit code
+func run(args []string) int {
+	if len(args) < 1 {
+		usage()
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:]); err != nil {
+			var exit exitError
+			if errors.As(err, &exit) {
+				return exit.code
+			}
+			fmt.Fprintf(os.Stderr, "yachts %s: %s\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: yachts <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strconv.FormatBool(r.URL.Path == "/v2/check/bad.com")))
+	}))
+	defer srv.Close()
+	t.Setenv("YACHTS_IDENTITY", "")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no command", code: 2},
+		{name: "unknown command", args: []string{"unknown"}, code: 2},
+		{name: "command error", args: []string{"check", "-endpoint", srv.URL, "good.com"}, code: 1},
+		{name: "check", args: []string{"check", "-endpoint", srv.URL, "-identity", "test", "good.com"}, code: 0},
+		{name: "check flagged", args: []string{"check", "-endpoint", srv.URL, "-identity", "test", "good.com", "bad.com"}, code: exitFlagged},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			assert.Equal(t, data.code, run(data.args))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+//output formats selected with -output
+const (
+	outputPlain = "plain"
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+//exitFlagged is the exit code when a checked domain is flagged as phishing
+const exitFlagged = 3
+
+//exitError makes yachts exit with code without printing an error
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+//result is the output of a command
+//plain prints rows separated by tabs, table prints rows aligned under the header, and json prints value
+type result struct {
+	header []string
+	rows   [][]string
+	value  interface{}
+}
+
+//write writes the result in the output format
+func (r result) write(w io.Writer, format string) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, strings.Join(r.header, "\t"))
+		for _, row := range r.rows {
+			_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case outputPlain:
+		for _, row := range r.rows {
+			_, err := fmt.Fprintln(w, strings.Join(row, "\t"))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, expected plain, table or json", format)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"os"
+	"time"
+)
+
+//runRestore lists the backups of a cache file, or rolls the cache back to one of them
+//with -before, the newest backup made at or before the time is restored instead of a named one
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	cache := fs.String("cache", "", "cache file saved by the daemon")
+	output := fs.String("output", outputPlain, "output format of the listed backups, plain, table or json")
+	before := fs.String("before", "", "restore the newest backup made at or before this rfc3339 time")
+	_ = fs.Parse(args)
+	if *cache == "" {
+		return errors.New("-cache is required")
+	}
+	store := cacheStore(*cache)
+	backup := fs.Arg(0)
+	if *before != "" {
+		t, err := time.Parse(time.RFC3339, *before)
+		if err != nil {
+			return fmt.Errorf("invalid -before: %w", err)
+		}
+		if backup, err = store.BackupAt(t); err != nil {
+			return err
+		}
+	}
+	if backup == "" {
+		backups, err := store.Backups()
+		if err != nil {
+			return err
+		}
+		out := result{header: []string{"BACKUP"}, value: backups}
+		for _, backup := range backups {
+			out.rows = append(out.rows, []string{backup})
+		}
+		return out.write(os.Stdout, *output)
+	}
+
+	c := sinkingyachts.New("", "", sinkingyachts.NewHTTPClient(0))
+	if err := store.Restore(c, backup); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "restored %d domains from %s\n", c.Size(), backup)
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+	"os"
+	"time"
+)
+
+//serviceName is the name the daemon is registered as with the service control manager
+const serviceName = "yachts"
+
+func init() {
+	commands = append(commands, command{name: "service", usage: "install, uninstall or run the daemon as a windows service", run: runService})
+}
+
+//runService handles "service install [daemon flags]", "service uninstall" and "service run [daemon flags]"
+//install registers the service to run "service run" with the given daemon flags, starting automatically
+func runService(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: yachts service install|uninstall|run [daemon flags]")
+	}
+	switch args[0] {
+	case "install":
+		opts, err := parseDaemon("service install", args[1:])
+		if err != nil {
+			return err
+		}
+		return installService(opts)
+	case "uninstall":
+		return uninstallService()
+	case "run":
+		opts, err := parseDaemon("service run", args[1:])
+		if err != nil {
+			return err
+		}
+		return svc.Run(serviceName, &service{opts: opts})
+	default:
+		return fmt.Errorf("unknown service command %q", args[0])
+	}
+}
+
+func installService(opts daemonOptions) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(serviceName)
+	if err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+	s, err = m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "Sinking Yachts mirror",
+		Description: "Keeps a local synced cache of the sinking yachts phishing domain list",
+		StartType:   mgr.StartAutomatic,
+	}, append([]string{"service", "run"}, opts.args()...)...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	err = eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil {
+		_ = s.Delete()
+		return err
+	}
+	return nil
+}
+
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", serviceName)
+	}
+	defer s.Close()
+	err = s.Delete()
+	if err != nil {
+		return err
+	}
+	return eventlog.Remove(serviceName)
+}
+
+//service runs the daemon under the service control manager, logging errors into the event log
+type service struct {
+	opts daemonOptions
+}
+
+func (s *service) Execute(args []string, r <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	elog, err := eventlog.Open(serviceName)
+	if err != nil {
+		return true, 1
+	}
+	defer elog.Close()
+	logErr := func(err error) {
+		_ = elog.Error(1, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.opts.run(ctx, logErr)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				logErr(err)
+				return true, 1
+			}
+			return false, 0
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				status <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32(time.Second * 15 / time.Millisecond)}
+				cancel()
+			}
+		}
+	}
+}
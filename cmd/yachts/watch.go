@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/thunder33345/sinkingyachts"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
+)
+
+//watch formats selected with -format
+const (
+	watchPlain = "plain"
+	watchJSONL = "jsonl"
+)
+
+//watchOptions are the flags selecting what and how the watch command prints
+type watchOptions struct {
+	addsOnly bool
+	match    string
+	format   string
+}
+
+//validate checks that the format is known and the pattern is valid
+func (opts watchOptions) validate() error {
+	if opts.format != watchPlain && opts.format != watchJSONL {
+		return fmt.Errorf("unknown format %q, expected plain or jsonl", opts.format)
+	}
+	if _, err := path.Match(opts.match, ""); err != nil {
+		return fmt.Errorf("invalid -match pattern: %w", err)
+	}
+	return nil
+}
+
+//runWatch streams feed updates to stdout until interrupted
+func runWatch(args []string) error {
+	f := newAPIFlags("watch")
+	var opts watchOptions
+	f.fs.BoolVar(&opts.addsOnly, "adds-only", false, "only print added domains")
+	f.fs.StringVar(&opts.match, "match", "", "only print domains matching the glob pattern, such as 'discord*'")
+	f.fs.StringVar(&opts.format, "format", watchPlain, "line format, plain prints \"<type> <domain>\" per domain, jsonl prints an update per line")
+	r, err := f.parse(args)
+	if err != nil {
+		return err
+	}
+	if err = opts.validate(); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	mods := make(chan sinkingyachts.DomainUpdate, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Feed(ctx, mods)
+	}()
+
+	w := bufio.NewWriter(os.Stdout)
+	for {
+		select {
+		case err := <-done:
+			_ = w.Flush()
+			return err
+		case mod := <-mods:
+			err = opts.write(w, mod, time.Now())
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//write writes the update in the format if it's selected, only domains matching the pattern are written
+func (opts watchOptions) write(w io.Writer, mod sinkingyachts.DomainUpdate, now time.Time) error {
+	if opts.addsOnly && !mod.Add {
+		return nil
+	}
+	mod.Domains = filterMatch(mod.Domains, opts.match)
+	if len(mod.Domains) == 0 {
+		return nil
+	}
+	if opts.format == watchJSONL {
+		return json.NewEncoder(w).Encode(sinkingyachts.AppliedUpdate{Update: mod, Time: now, Source: sinkingyachts.SourceFeed})
+	}
+	return writeUpdate(w, mod)
+}
+
+//filterMatch returns the domains matching the glob pattern, or all domains if pattern is empty
+func filterMatch(domains []string, pattern string) []string {
+	if pattern == "" {
+		return domains
+	}
+	var matched []string
+	for _, domain := range domains {
+		if ok, _ := path.Match(pattern, domain); ok {
+			matched = append(matched, domain)
+		}
+	}
+	return matched
+}
+
+//writeUpdate writes a line of "<type> <domain>" per domain of the update
+func writeUpdate(w io.Writer, mod sinkingyachts.DomainUpdate) error {
+	kind := "delete"
+	if mod.Add {
+		kind = "add"
+	}
+	for _, domain := range mod.Domains {
+		if _, err := fmt.Fprintf(w, "%s %s\n", kind, domain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/thunder33345/sinkingyachts"
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	domains := []string{"discord-gift.com", "discordapp.gift", "steam-gift.com"}
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "empty pattern", pattern: "", want: domains},
+		{name: "glob", pattern: "discord*", want: []string{"discord-gift.com", "discordapp.gift"}},
+		{name: "suffix glob", pattern: "*-gift.com", want: []string{"discord-gift.com", "steam-gift.com"}},
+		{name: "no match", pattern: "nitro*", want: nil},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			assert.Equal(t, data.want, filterMatch(domains, data.pattern))
+		})
+	}
+}
+
+func TestWatchWrite(t *testing.T) {
+	at := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	add := sinkingyachts.DomainUpdate{Add: true, Domains: []string{"a.com", "b.net"}}
+	remove := sinkingyachts.DomainUpdate{Domains: []string{"c.com"}}
+	tests := []struct {
+		name string
+		opts watchOptions
+		mod  sinkingyachts.DomainUpdate
+		want string
+	}{
+		{name: "plain add", opts: watchOptions{format: watchPlain}, mod: add, want: "add a.com\nadd b.net\n"},
+		{name: "plain delete", opts: watchOptions{format: watchPlain}, mod: remove, want: "delete c.com\n"},
+		{name: "plain match", opts: watchOptions{format: watchPlain, match: "*.net"}, mod: add, want: "add b.net\n"},
+		{name: "plain no match", opts: watchOptions{format: watchPlain, match: "*.org"}, mod: add, want: ""},
+		{name: "adds only add", opts: watchOptions{format: watchPlain, addsOnly: true}, mod: add, want: "add a.com\nadd b.net\n"},
+		{name: "adds only delete", opts: watchOptions{format: watchPlain, addsOnly: true}, mod: remove, want: ""},
+		{name: "jsonl", opts: watchOptions{format: watchJSONL}, mod: add, want: `{"time":"2022-10-01T12:00:00Z","source":"feed","type":"add","domains":["a.com","b.net"]}` + "\n"},
+		{name: "jsonl match", opts: watchOptions{format: watchJSONL, match: "a.*"}, mod: add, want: `{"time":"2022-10-01T12:00:00Z","source":"feed","type":"add","domains":["a.com"]}` + "\n"},
+		{name: "jsonl adds only delete", opts: watchOptions{format: watchJSONL, addsOnly: true}, mod: remove, want: ""},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			var buf bytes.Buffer
+			a.NoError(data.opts.write(&buf, data.mod, at))
+			a.Equal(data.want, buf.String())
+		})
+	}
+}
+
+func TestRunWatchInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "unknown format", args: []string{"-identity", "test", "-format", "csv"}, err: `unknown format "csv"`},
+		{name: "invalid pattern", args: []string{"-identity", "test", "-match", "[discord"}, err: "invalid -match pattern"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			err := runWatch(data.args)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), data.err)
+			}
+		})
+	}
+}
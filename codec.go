@@ -0,0 +1,173 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+//subprotocolMsgpack is the websocket subprotocol MsgpackCodec is negotiated as
+const subprotocolMsgpack = "sinkingyachts.msgpack"
+
+//errSingleUpdate is returned when encoding several updates into a frame of a codec that carries a single update
+var errSingleUpdate = errors.New("codec: frames carry exactly one update")
+
+//Codec encodes and decodes the frames of the websocket feed
+//codecs are negotiated as websocket subprotocols, the client offers its codecs and the server picks one it also supports
+//see WithFeedCodecs for the client, and Replicator.SetCodecs for the server
+type Codec interface {
+	//Subprotocol is the websocket subprotocol the codec is negotiated as, such as "sinkingyachts.v2"
+	Subprotocol() string
+	//Binary reports if frames are sent as binary messages instead of text messages
+	Binary() bool
+	//Encode encodes updates into a single frame
+	Encode(updates []DomainUpdate) ([]byte, error)
+	//Decode decodes the updates of a single frame, frames may carry no updates at all
+	Decode(frame []byte) ([]DomainUpdate, error)
+}
+
+var (
+	//JSONCodec is the api's format, where every frame is a single update as JSON
+	//decoding also understands envelopes, so it keeps working if the server moves to them, see FeedProtocolAuto
+	JSONCodec Codec = protocolCodec{protocol: FeedProtocolAuto}
+	//EnvelopeCodec is the enveloped JSON format, where every frame is a versioned envelope carrying any amount of updates, see FeedProtocolV2
+	EnvelopeCodec Codec = protocolCodec{protocol: FeedProtocolV2}
+	//MsgpackCodec is the enveloped format encoded as MessagePack binary frames, which are smaller and cheaper to decode than JSON
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+//protocolCodec is a Codec of a JSON FeedProtocol
+type protocolCodec struct {
+	protocol FeedProtocol
+}
+
+//Subprotocol returns the subprotocol of the protocol
+func (pc protocolCodec) Subprotocol() string {
+	if pc.protocol == FeedProtocolV2 {
+		return subprotocolV2
+	}
+	return subprotocolV1
+}
+
+//Binary returns false, as JSON frames are text
+func (pc protocolCodec) Binary() bool {
+	return false
+}
+
+//Encode encodes updates into an envelope for FeedProtocolV2, or a single update otherwise
+func (pc protocolCodec) Encode(updates []DomainUpdate) ([]byte, error) {
+	if pc.protocol == FeedProtocolV2 {
+		return json.Marshal(envelope{Version: envelopeVersion, Kind: envelopeUpdates, Updates: updates})
+	}
+	if len(updates) != 1 {
+		return nil, errSingleUpdate
+	}
+	return json.Marshal(updates[0])
+}
+
+//Decode decodes a frame in the protocol
+func (pc protocolCodec) Decode(frame []byte) ([]DomainUpdate, error) {
+	return pc.protocol.decodeFrame(frame)
+}
+
+//msgpackCodec is the Codec of MsgpackCodec
+type msgpackCodec struct{}
+
+//msgpackEnvelope is a frame of MsgpackCodec, it mirrors envelope
+type msgpackEnvelope struct {
+	Version int             `json:"version"`
+	Kind    string          `json:"kind,omitempty"`
+	Updates []msgpackUpdate `json:"updates,omitempty"`
+}
+
+//msgpackUpdate is a DomainUpdate in a msgpackEnvelope, it mirrors modEntry
+type msgpackUpdate struct {
+	Type     string    `json:"type"`
+	Domains  []string  `json:"domains"`
+	Category string    `json:"category,omitempty"`
+	Time     time.Time `json:"timestamp,omitempty"`
+	Origin   string    `json:"origin,omitempty"`
+}
+
+//Subprotocol returns "sinkingyachts.msgpack"
+func (msgpackCodec) Subprotocol() string {
+	return subprotocolMsgpack
+}
+
+//Binary returns true, as MessagePack frames are binary
+func (msgpackCodec) Binary() bool {
+	return true
+}
+
+//Encode encodes updates into a MessagePack envelope
+func (msgpackCodec) Encode(updates []DomainUpdate) ([]byte, error) {
+	env := msgpackEnvelope{Version: envelopeVersion, Kind: envelopeUpdates, Updates: make([]msgpackUpdate, 0, len(updates))}
+	for _, mod := range updates {
+		me := newModEntry(mod)
+		env.Updates = append(env.Updates, msgpackUpdate{Type: me.Type, Domains: me.Domains, Category: me.Category, Time: mod.Time, Origin: mod.Origin})
+	}
+	return msgpackMarshal(env)
+}
+
+//Decode decodes the updates of a MessagePack envelope, envelopes of unknown kinds carry no updates
+func (msgpackCodec) Decode(frame []byte) ([]DomainUpdate, error) {
+	var env msgpackEnvelope
+	err := msgpackUnmarshal(frame, &env)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkEnvelopeVersion(env.Version); err != nil {
+		return nil, err
+	}
+	if env.Kind != "" && env.Kind != envelopeUpdates {
+		return nil, nil
+	}
+	updates := make([]DomainUpdate, 0, len(env.Updates))
+	for _, mu := range env.Updates {
+		var mod DomainUpdate
+		err = mod.fromModEntry(modEntry{Type: mu.Type, Domains: mu.Domains, Category: mu.Category, Origin: mu.Origin})
+		if err != nil {
+			return nil, err
+		}
+		mod.Time = mu.Time
+		updates = append(updates, mod)
+	}
+	return updates, nil
+}
+
+//WithFeedCodecs sets the codecs offered to the server when connecting to the feed, in order of preference
+//frames are decoded with the codec the server picks, or as set by WithFeedProtocol if it doesn't pick any
+//this replaces the codecs offered for the FeedProtocol, which are EnvelopeCodec and JSONCodec by default
+func WithFeedCodecs(codecs ...Codec) Option {
+	return func(client *RawClient) {
+		client.feedCodecs = codecs
+	}
+}
+
+//offeredCodecs returns the codecs offered when dialing the feed
+func (c RawClient) offeredCodecs() []Codec {
+	if c.feedCodecs != nil {
+		return c.feedCodecs
+	}
+	return c.feedProtocol.codecs()
+}
+
+//subprotocols returns the subprotocols of codecs
+func subprotocols(codecs []Codec) []string {
+	var protocols []string
+	for _, codec := range codecs {
+		protocols = append(protocols, codec.Subprotocol())
+	}
+	return protocols
+}
+
+//negotiatedCodec returns the codec of the subprotocol the server picked
+//servers that don't pick any are decoded as set by WithFeedProtocol
+func (c RawClient) negotiatedCodec(subprotocol string) Codec {
+	for _, codec := range c.offeredCodecs() {
+		if subprotocol != "" && codec.Subprotocol() == subprotocol {
+			return codec
+		}
+	}
+	return protocolCodec{protocol: c.feedProtocol}
+}
@@ -0,0 +1,84 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodecs(t *testing.T) {
+	updates := []DomainUpdate{
+		{Add: true, Domains: []string{"a.com", "b.com"}, Category: "scam", Time: time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC), Origin: "reporter"},
+		{Add: false, Domains: []string{"c.com"}},
+	}
+	tests := []struct {
+		name   string
+		codec  Codec
+		single bool
+	}{
+		{name: "json", codec: JSONCodec, single: true},
+		{name: "envelope", codec: EnvelopeCodec},
+		{name: "msgpack", codec: MsgpackCodec},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			frame, err := data.codec.Encode(updates)
+			if data.single {
+				a.ErrorIs(err, errSingleUpdate)
+				frame, err = data.codec.Encode(updates[:1])
+				a.NoError(err)
+				decoded, err := data.codec.Decode(frame)
+				a.NoError(err)
+				assertUpdates(a, updates[:1], decoded)
+				return
+			}
+			a.NoError(err)
+			decoded, err := data.codec.Decode(frame)
+			a.NoError(err)
+			assertUpdates(a, updates, decoded)
+		})
+	}
+}
+
+//assertUpdates asserts that updates are equal, comparing their times by instant
+func assertUpdates(a *assert.Assertions, want, got []DomainUpdate) {
+	if !a.Len(got, len(want)) {
+		return
+	}
+	for i := range want {
+		a.True(want[i].Time.Equal(got[i].Time), "expected %s, got %s", want[i].Time, got[i].Time)
+		w, g := want[i], got[i]
+		w.Time, g.Time = time.Time{}, time.Time{}
+		a.Equal(w, g)
+	}
+}
+
+func TestReplicatorCodecs(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, EnvelopeCodec, MsgpackCodec} {
+		t.Run(codec.Subprotocol(), func(t *testing.T) {
+			a := assert.New(t)
+			primary := New("", "test", http.Client{})
+			primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+			r := NewReplicator(primary, "")
+			defer r.Close()
+			r.SetCodecs(MsgpackCodec, EnvelopeCodec, JSONCodec)
+			srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+			defer srv.Close()
+
+			follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{}, WithFeedCodecs(codec))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				_ = follower.ListenForUpdates(ctx)
+			}()
+			a.Eventually(func() bool { return follower.Check("a.com") }, time.Second, time.Millisecond*10)
+			primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com"}}, SourceFeed)
+			a.Eventually(func() bool { return follower.Check("b.com") }, time.Second, time.Millisecond*10)
+		})
+	}
+}
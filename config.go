@@ -0,0 +1,636 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//Config is a declarative setup of a Client and everything around it, see NewManager
+//it can be written as json, yaml or toml, all formats use the json names of the fields
+type Config struct {
+	//Endpoint is the root of the api, see NewRawClient
+	Endpoint string `json:"endpoint"`
+	//Identity identifies your application and your contact, see NewRawClient
+	Identity string `json:"identity"`
+	//Timeout is the timeout of api requests, see WithTimeout
+	Timeout Duration `json:"timeout,omitempty"`
+	//FeedTimeout is the timeout of dialing the websocket feed, see WithFeedTimeout
+	FeedTimeout Duration `json:"feed_timeout,omitempty"`
+	//FeedMessageTimeout is how long the feed may go without a message, see WithFeedMessageTimeout
+	FeedMessageTimeout Duration `json:"feed_message_timeout,omitempty"`
+	//FeedPing is how often the feed is pinged, see WithFeedPing
+	FeedPing Duration `json:"feed_ping,omitempty"`
+	//FeedPingTimeout is how long to wait for a pong, it defaults to FeedPing
+	FeedPingTimeout Duration `json:"feed_ping_timeout,omitempty"`
+	//RecentWindow is how far back the api serves recent updates, see WithRecentWindow
+	RecentWindow Duration `json:"recent_window,omitempty"`
+	//FeedBuffer is the amount of feed updates buffered for applying, see WithFeedBuffer
+	FeedBuffer int `json:"feed_buffer,omitempty"`
+	//FeedBackpressure is what to do with feed updates that can't be applied in time, "block" or "drop", defaults to "block", see WithFeedBackpressure
+	FeedBackpressure string `json:"feed_backpressure,omitempty"`
+	//FeedConsumerTimeout closes a blocked feed with an error once an update can't be applied within it, see WithFeedBackpressure
+	FeedConsumerTimeout Duration `json:"feed_consumer_timeout,omitempty"`
+	//FeedProtocol pins the frame format of the feed, "auto", "v1" or "v2", defaults to "auto", see WithFeedProtocol
+	FeedProtocol string `json:"feed_protocol,omitempty"`
+	//Headers are additional headers sent with every request
+	Headers map[string]string `json:"headers,omitempty"`
+	//StrictValidation drops invalid domains received from the api, see WithStrictValidation
+	StrictValidation bool `json:"strict_validation,omitempty"`
+	//Categories restricts the cache to the categories, see Client.FilterCategories
+	Categories []string `json:"categories,omitempty"`
+	//Metadata enables tracking of domain metadata, see Client.EnableMetadata
+	Metadata bool `json:"metadata,omitempty"`
+	//MaxEntries bounds the amount of known domains by evicting the oldest, 0 is unbounded, see Client.SetMaxEntries
+	MaxEntries int `json:"max_entries,omitempty"`
+	//Privacy is how checked domains appear in logs and hooks, "off", "hash" or "omit", see Client.SetPrivacy
+	Privacy string `json:"privacy,omitempty"`
+	//RegexRules are patterns of domains to consider phishing, see Client.SetRegexRules
+	RegexRules []string `json:"regex_rules,omitempty"`
+	//Normalization is how checked domains are normalized, "strict" or "lenient", see Client.SetNormalization
+	Normalization string `json:"normalization,omitempty"`
+	//Anomaly flags or holds abnormally large updates, see Client.SetAnomalyGuard
+	Anomaly AnomalyGuard `json:"anomaly,omitempty"`
+	//Alerts configures alerting on sync problems, see Manager.RunAlerts
+	Alerts AlertConfig `json:"alerts,omitempty"`
+	//Transport tunes the connections to the api, leave empty for the defaults of NewHTTPClient
+	Transport TransportConfig `json:"transport,omitempty"`
+	//Sync configures how the cache is kept up to date, see AutoSync
+	Sync SyncConfig `json:"sync"`
+	//Bootstrap configures loading an initial cache from a mirror, leave empty to always start with a full sync
+	Bootstrap BootstrapConfig `json:"bootstrap,omitempty"`
+	//Publish configures uploading the cache for other instances to bootstrap from, leave empty to not upload it
+	Publish PublishConfig `json:"publish,omitempty"`
+	//Store configures where the cache is persisted, leave empty to not persist it
+	Store StoreConfig `json:"store,omitempty"`
+	//Query configures serving the cache to other processes, leave empty to not serve it, see ServeQueries
+	Query QueryConfig `json:"query,omitempty"`
+	//SweepInterval is how often expired local domains are removed and passed review dates are reported, 0 disables sweeping, see SweepLocal
+	SweepInterval Duration `json:"sweep_interval,omitempty"`
+	//LogLevel is the least severe level logged by the daemon, "warning" or "error", defaults to "warning", see Manager.Config
+	LogLevel string `json:"log_level,omitempty"`
+}
+
+//SyncConfig configures AutoSync
+type SyncConfig struct {
+	//Realtime listens to the websocket feed
+	Realtime bool `json:"realtime"`
+	//RecentInterval is how often recent updates are fetched, 0 disables it
+	RecentInterval Duration `json:"recent_interval,omitempty"`
+	//Delta fetches changes with Client.DeltaSync instead of recent updates, the endpoint must be a Mirror
+	Delta bool `json:"delta,omitempty"`
+	//FullSyncInterval is how often a full sync is done, 0 disables it
+	FullSyncInterval Duration `json:"full_sync_interval,omitempty"`
+	//FullSyncAt schedules a daily full sync at "HH:MM", in addition to FullSyncInterval, see Daily
+	FullSyncAt string `json:"full_sync_at,omitempty"`
+	//FullSyncJitter delays the daily full sync by a random duration up to it, see WithJitter
+	FullSyncJitter Duration `json:"full_sync_jitter,omitempty"`
+	//TimeZone is the IANA time zone of FullSyncAt, such as "Europe/Berlin", defaults to the local time zone
+	TimeZone string `json:"time_zone,omitempty"`
+	//MaxFailures is how many periodic syncs may fail in a row before Manager.Run fails, 0 fails on the first error
+	//the initial sync of Manager.Run always fails on the first error
+	MaxFailures int `json:"max_failures,omitempty"`
+}
+
+//schedule returns the Schedule of the daily full sync, or nil if it's not scheduled
+func (sc SyncConfig) schedule() (Schedule, error) {
+	if sc.FullSyncAt == "" {
+		return nil, nil
+	}
+	loc := time.Local
+	if sc.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(sc.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("config: unknown time zone %q", sc.TimeZone)
+		}
+	}
+	s, err := ParseDaily(sc.FullSyncAt, loc)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return WithJitter(s, time.Duration(sc.FullSyncJitter)), nil
+}
+
+//StoreConfig configures a FileStore
+type StoreConfig struct {
+	//Path is the file the cache is saved into
+	Path string `json:"path,omitempty"`
+	//Format is the format of the file, "json" or "msgpack", defaults to "json"
+	Format string `json:"format,omitempty"`
+	//Backups is the amount of backups kept, 0 disables backups, see FileStore.EnableBackups
+	Backups int `json:"backups,omitempty"`
+	//BackupInterval is the minimum time between backups
+	BackupInterval Duration `json:"backup_interval,omitempty"`
+	//CompressBackups gzips the backups
+	CompressBackups bool `json:"compress_backups,omitempty"`
+	//KeyEnv is the environment variable of a base64 AES key the file is encrypted with, see FileStore.EnableEncryption
+	KeyEnv string `json:"key_env,omitempty"`
+}
+
+//BootstrapConfig configures Bootstrap
+//the mirror is only used when the stored cache is empty, and falls back to a full sync if it fails
+type BootstrapConfig struct {
+	//URL is the mirror to download the cache from
+	URL string `json:"url,omitempty"`
+	//Format is the format of the cache, "json" or "msgpack", defaults to "json"
+	Format string `json:"format,omitempty"`
+	//MaxAge is how old the mirrored cache may be to only fetch recent updates after it, defaults to 1h
+	//older caches are followed by a full sync
+	MaxAge Duration `json:"max_age,omitempty"`
+	//Key is a PEM file of the ed25519 public key the cache must be signed with, see BootstrapVerified
+	Key string `json:"key,omitempty"`
+	//MaxSize is the largest cache downloaded in bytes, larger caches are followed by a full sync, defaults to DefaultBootstrapMaxSize
+	MaxSize int64 `json:"max_size,omitempty"`
+}
+
+//PublishConfig configures a SnapshotPublisher uploading with HTTPUploader
+type PublishConfig struct {
+	//URL is the prefix the objects are uploaded under
+	URL string `json:"url,omitempty"`
+	//Name is the name of the cache object, defaults to "cache.<format>"
+	Name string `json:"name,omitempty"`
+	//Format is the format of the cache, "json" or "msgpack", defaults to "json"
+	Format string `json:"format,omitempty"`
+	//Interval is how often the cache is uploaded if it changed, defaults to 10m
+	Interval Duration `json:"interval,omitempty"`
+	//Key is a PEM file of the ed25519 private key the cache is signed with, leave empty to not sign it
+	Key string `json:"key,omitempty"`
+}
+
+//QueryConfig configures the listener of ServeQueries
+type QueryConfig struct {
+	//Network is the network to listen on, defaults to "unix"
+	Network string `json:"network,omitempty"`
+	//Address is the address to listen on, such as "/run/sinkingyachts.sock"
+	Address string `json:"address,omitempty"`
+}
+
+//TransportConfig tunes the transport of api requests, see WithMaxIdleConns
+type TransportConfig struct {
+	//HTTPVersion is "auto", "1.1" or "2", see WithHTTPVersion
+	HTTPVersion string `json:"http_version,omitempty"`
+	//MaxIdleConns is how many idle connections are kept open in total
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+	//MaxIdleConnsPerHost is how many idle connections are kept open per host
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+	//IdleConnTimeout is how long idle connections are kept open
+	IdleConnTimeout Duration `json:"idle_conn_timeout,omitempty"`
+	//TCPKeepAlive is the interval of tcp keepalive probes, negative disables them
+	TCPKeepAlive Duration `json:"tcp_keepalive,omitempty"`
+	//DoH is a dns over https endpoint the api's host is resolved with, see DoHResolver
+	DoH string `json:"doh,omitempty"`
+	//CertFile and KeyFile are a PEM client certificate and its key for mutual tls, see WithClientCertificate
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	//CAFile is a PEM file of the cas server certificates are verified with instead of the system roots, see WithRootCAs
+	CAFile string `json:"ca_file,omitempty"`
+	//IP is which ip versions to connect over, "auto", "prefer_ipv4", "prefer_ipv6", "ipv4" or "ipv6", see WithIPPreference
+	IP string `json:"ip,omitempty"`
+	//FallbackDelay is how long the preferred ip version is tried before racing the other, see WithFallbackDelay
+	FallbackDelay Duration `json:"fallback_delay,omitempty"`
+}
+
+//version returns the HTTPVersion of the transport
+func (t TransportConfig) version() (HTTPVersion, error) {
+	switch t.HTTPVersion {
+	case "", "auto":
+		return HTTPAuto, nil
+	case "1.1", "1":
+		return HTTP1, nil
+	case "2":
+		return HTTP2, nil
+	default:
+		return 0, fmt.Errorf("config: unknown http version %q", t.HTTPVersion)
+	}
+}
+
+//preference returns the IPPreference of the transport
+func (t TransportConfig) preference() (IPPreference, error) {
+	switch t.IP {
+	case "", "auto":
+		return IPAuto, nil
+	case "prefer_ipv4":
+		return PreferIPv4, nil
+	case "prefer_ipv6":
+		return PreferIPv6, nil
+	case "ipv4":
+		return OnlyIPv4, nil
+	case "ipv6":
+		return OnlyIPv6, nil
+	default:
+		return 0, fmt.Errorf("config: unknown ip version %q", t.IP)
+	}
+}
+
+//options returns the Option that apply the transport settings
+func (t TransportConfig) options() []Option {
+	var options []Option
+	if version, _ := t.version(); version != HTTPAuto {
+		options = append(options, WithHTTPVersion(version))
+	}
+	if t.MaxIdleConns > 0 || t.MaxIdleConnsPerHost > 0 {
+		options = append(options, WithMaxIdleConns(t.MaxIdleConns, t.MaxIdleConnsPerHost))
+	}
+	if t.IdleConnTimeout > 0 {
+		options = append(options, WithIdleConnTimeout(time.Duration(t.IdleConnTimeout)))
+	}
+	if t.TCPKeepAlive != 0 {
+		options = append(options, WithTCPKeepAlive(time.Duration(t.TCPKeepAlive)))
+	}
+	if preference, _ := t.preference(); preference != IPAuto {
+		options = append(options, WithIPPreference(preference))
+	}
+	if t.FallbackDelay != 0 {
+		options = append(options, WithFallbackDelay(time.Duration(t.FallbackDelay)))
+	}
+	if t.DoH != "" {
+		client := NewHTTPClient(time.Second * 10)
+		options = append(options, WithResolver(&DoHResolver{URL: t.DoH, Client: &client}))
+	}
+	certs, pool, _ := t.tls()
+	if len(certs) > 0 {
+		options = append(options, WithClientCertificate(certs...))
+	}
+	if pool != nil {
+		options = append(options, WithRootCAs(pool))
+	}
+	return options
+}
+
+//tls loads the client certificate and the cas of the transport, they are nil if not configured
+func (t TransportConfig) tls() ([]tls.Certificate, *x509.CertPool, error) {
+	var certs []tls.Certificate
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: client certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if t.CAFile == "" {
+		return certs, nil, nil
+	}
+	data, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, nil, fmt.Errorf("config: ca file %s has no certificates", t.CAFile)
+	}
+	return certs, pool, nil
+}
+
+//Duration is a time.Duration that is written as a string such as "1h30m" in configs
+//plain numbers are read as nanoseconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+//LoadConfig reads a Config from a file, the format is picked by the extension of the file
+//".json", ".yaml", ".yml" and ".toml" are supported
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	cfg, err := ParseConfig(data, strings.TrimPrefix(ext, "."))
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+//ParseConfig parses a Config in the format, which is "json", "yaml", "yml" or "toml"
+//unknown fields are rejected, to catch typos in the config
+func ParseConfig(data []byte, format string) (Config, error) {
+	switch format {
+	case "json":
+	case "yaml", "yml":
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return Config{}, err
+		}
+		return configFromValue(v)
+	case "toml":
+		var v map[string]interface{}
+		if err := toml.Unmarshal(data, &v); err != nil {
+			return Config{}, err
+		}
+		return configFromValue(v)
+	default:
+		return Config{}, fmt.Errorf("unknown config format %q", format)
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.validate()
+}
+
+//configFromValue converts a generic decoded document into Config through json, so every format shares the json names
+func configFromValue(v interface{}) (Config, error) {
+	if v == nil {
+		v = map[string]interface{}{}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data, "json")
+}
+
+//validate checks for settings that can't be used
+func (cfg Config) validate() error {
+	if cfg.Endpoint == "" {
+		return fmt.Errorf("config: endpoint is required")
+	}
+	if cfg.Identity == "" {
+		return fmt.Errorf("config: identity is required")
+	}
+	if _, err := cfg.Store.format(); err != nil {
+		return err
+	}
+	if _, err := cfg.Bootstrap.format(); err != nil {
+		return err
+	}
+	if _, err := cfg.Publish.format(); err != nil {
+		return err
+	}
+	if _, err := cfg.privacy(); err != nil {
+		return err
+	}
+	if _, err := cfg.Transport.version(); err != nil {
+		return err
+	}
+	if _, _, err := cfg.Transport.tls(); err != nil {
+		return err
+	}
+	if _, err := cfg.Transport.preference(); err != nil {
+		return err
+	}
+	if _, err := cfg.normalization(); err != nil {
+		return err
+	}
+	if _, err := cfg.Warnings(); err != nil {
+		return err
+	}
+	if _, err := cfg.backpressure(); err != nil {
+		return err
+	}
+	if _, err := cfg.feedProtocol(); err != nil {
+		return err
+	}
+	if _, err := cfg.Sync.schedule(); err != nil {
+		return err
+	}
+	if _, err := compileRegexRules(cfg.RegexRules); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := cfg.Anomaly.validate(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return nil
+}
+
+//backpressure returns the Backpressure of the feed
+func (cfg Config) backpressure() (Backpressure, error) {
+	switch cfg.FeedBackpressure {
+	case "", "block":
+		return BackpressureBlock, nil
+	case "drop":
+		return BackpressureDrop, nil
+	default:
+		return 0, fmt.Errorf("config: unknown feed backpressure %q", cfg.FeedBackpressure)
+	}
+}
+
+//feedProtocol returns the FeedProtocol of the feed
+func (cfg Config) feedProtocol() (FeedProtocol, error) {
+	switch cfg.FeedProtocol {
+	case "", "auto":
+		return FeedProtocolAuto, nil
+	case "v1":
+		return FeedProtocolV1, nil
+	case "v2":
+		return FeedProtocolV2, nil
+	default:
+		return 0, fmt.Errorf("config: unknown feed protocol %q", cfg.FeedProtocol)
+	}
+}
+
+//options returns the Option of the Config
+func (cfg Config) options() []Option {
+	var options []Option
+	for key, value := range cfg.Headers {
+		options = append(options, WithHeader(key, value))
+	}
+	if cfg.FeedTimeout > 0 {
+		options = append(options, WithFeedTimeout(time.Duration(cfg.FeedTimeout)))
+	}
+	if cfg.FeedMessageTimeout > 0 {
+		options = append(options, WithFeedMessageTimeout(time.Duration(cfg.FeedMessageTimeout)))
+	}
+	if cfg.FeedPing > 0 {
+		options = append(options, WithFeedPing(time.Duration(cfg.FeedPing), time.Duration(cfg.FeedPingTimeout)))
+	}
+	if cfg.RecentWindow != 0 {
+		options = append(options, WithRecentWindow(time.Duration(cfg.RecentWindow)))
+	}
+	if cfg.FeedBuffer > 0 {
+		options = append(options, WithFeedBuffer(cfg.FeedBuffer))
+	}
+	if backpressure, _ := cfg.backpressure(); backpressure != BackpressureBlock || cfg.FeedConsumerTimeout > 0 {
+		options = append(options, WithFeedBackpressure(backpressure, time.Duration(cfg.FeedConsumerTimeout)))
+	}
+	if protocol, _ := cfg.feedProtocol(); protocol != FeedProtocolAuto {
+		options = append(options, WithFeedProtocol(protocol))
+	}
+	if cfg.StrictValidation {
+		options = append(options, WithStrictValidation(nil))
+	}
+	options = append(options, cfg.Transport.options()...)
+	return options
+}
+
+//timeout returns the timeout of api requests, defaults to 30s
+func (cfg Config) timeout() time.Duration {
+	if cfg.Timeout <= 0 {
+		return time.Second * 30
+	}
+	return time.Duration(cfg.Timeout)
+}
+
+//client creates a Client as configured
+func (cfg Config) client() (*Client, error) {
+	c := New(cfg.Endpoint, cfg.Identity, NewHTTPClient(cfg.timeout()), cfg.options()...)
+	if len(cfg.Categories) > 0 {
+		c.FilterCategories(cfg.Categories...)
+	}
+	if cfg.Metadata {
+		c.EnableMetadata()
+	}
+	if cfg.MaxEntries > 0 {
+		c.SetMaxEntries(cfg.MaxEntries)
+	}
+	if privacy, _ := cfg.privacy(); privacy != PrivacyOff {
+		c.SetPrivacy(privacy, nil)
+	}
+	if normalization, _ := cfg.normalization(); normalization != NormalizeStrict {
+		c.SetNormalization(normalization)
+	}
+	if err := c.SetRegexRules(cfg.RegexRules...); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	c.SetAnomalyGuard(cfg.Anomaly)
+	return c, nil
+}
+
+//Warnings returns if warnings should be logged under LogLevel, only errors are logged otherwise
+func (cfg Config) Warnings() (bool, error) {
+	switch strings.ToLower(cfg.LogLevel) {
+	case "", "warning":
+		return true, nil
+	case "error":
+		return false, nil
+	default:
+		return false, fmt.Errorf("config: unknown log level %q", cfg.LogLevel)
+	}
+}
+
+//privacy returns the PrivacyMode of the Config
+func (cfg Config) privacy() (PrivacyMode, error) {
+	switch strings.ToLower(cfg.Privacy) {
+	case "", "off":
+		return PrivacyOff, nil
+	case "hash":
+		return PrivacyHash, nil
+	case "omit":
+		return PrivacyOmit, nil
+	default:
+		return 0, fmt.Errorf("config: unknown privacy mode %q", cfg.Privacy)
+	}
+}
+
+//normalization returns the Normalization of the Config
+func (cfg Config) normalization() (Normalization, error) {
+	switch strings.ToLower(cfg.Normalization) {
+	case "", "strict":
+		return NormalizeStrict, nil
+	case "lenient":
+		return NormalizeLenient, nil
+	default:
+		return Normalization{}, fmt.Errorf("config: unknown normalization %q", cfg.Normalization)
+	}
+}
+
+//format returns the CacheFormat of the store
+func (s StoreConfig) format() (CacheFormat, error) {
+	format, ok := parseCacheFormat(s.Format)
+	if !ok {
+		return 0, fmt.Errorf("config: unknown store format %q", s.Format)
+	}
+	return format, nil
+}
+
+//format returns the CacheFormat of the mirror
+func (b BootstrapConfig) format() (CacheFormat, error) {
+	format, ok := parseCacheFormat(b.Format)
+	if !ok {
+		return 0, fmt.Errorf("config: unknown bootstrap format %q", b.Format)
+	}
+	return format, nil
+}
+
+//maxSize returns the largest cache downloaded from the mirror
+func (b BootstrapConfig) maxSize() int64 {
+	if b.MaxSize <= 0 {
+		return DefaultBootstrapMaxSize
+	}
+	return b.MaxSize
+}
+
+//format returns the CacheFormat of the published cache
+func (p PublishConfig) format() (CacheFormat, error) {
+	format, ok := parseCacheFormat(p.Format)
+	if !ok {
+		return 0, fmt.Errorf("config: unknown publish format %q", p.Format)
+	}
+	return format, nil
+}
+
+//publisher creates the SnapshotPublisher as configured, or nil if publishing isn't configured
+func (p PublishConfig) publisher(client *http.Client) (*SnapshotPublisher, error) {
+	if p.URL == "" {
+		return nil, nil
+	}
+	format, err := p.format()
+	if err != nil {
+		return nil, err
+	}
+	name := p.Name
+	if name == "" {
+		name = "cache.json"
+		if format == CacheMsgpack {
+			name = "cache.msgpack"
+		}
+	}
+	publisher := &SnapshotPublisher{
+		Uploader: HTTPUploader{URL: p.URL, Client: client},
+		Name:     name,
+		Format:   format,
+	}
+	if p.Key != "" {
+		publisher.Key, err = LoadSigningKey(p.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return publisher, nil
+}
+
+//parseCacheFormat returns the CacheFormat by name, an empty name is json
+func parseCacheFormat(name string) (CacheFormat, bool) {
+	switch strings.ToLower(name) {
+	case "", "json":
+		return CacheJSON, true
+	case "msgpack":
+		return CacheMsgpack, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,215 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"nhooyr.io/websocket"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	want := Config{
+		Endpoint: "https://example.com",
+		Identity: "Foo Bot (foo@example.com)",
+		Timeout:  Duration(time.Second * 10),
+		Headers:  map[string]string{"X-Foo": "bar"},
+		Sync: SyncConfig{
+			Realtime:         true,
+			FullSyncInterval: Duration(time.Hour),
+		},
+		Store: StoreConfig{Path: "/var/cache/yachts.json"},
+	}
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		err    bool
+	}{
+		{name: "json", format: "json", data: `{"endpoint":"https://example.com","identity":"Foo Bot (foo@example.com)","timeout":"10s",
+"headers":{"X-Foo":"bar"},"sync":{"realtime":true,"full_sync_interval":"1h"},"store":{"path":"/var/cache/yachts.json"}}`},
+		{name: "yaml", format: "yaml", data: `
+endpoint: https://example.com
+identity: Foo Bot (foo@example.com)
+timeout: 10s
+headers:
+  X-Foo: bar
+sync:
+  realtime: true
+  full_sync_interval: 1h
+store:
+  path: /var/cache/yachts.json
+`},
+		{name: "toml", format: "toml", data: `
+endpoint = "https://example.com"
+identity = "Foo Bot (foo@example.com)"
+timeout = "10s"
+[headers]
+X-Foo = "bar"
+[sync]
+realtime = true
+full_sync_interval = "1h"
+[store]
+path = "/var/cache/yachts.json"
+`},
+		{name: "unknown field", format: "json", data: `{"endpoint":"https://example.com","identity":"foo","endpont":"typo"}`, err: true},
+		{name: "missing endpoint", format: "yaml", data: `identity: foo`, err: true},
+		{name: "invalid store format", format: "json", data: `{"endpoint":"https://example.com","identity":"foo","store":{"format":"xml"}}`, err: true},
+		{name: "invalid full sync time", format: "json", data: `{"endpoint":"https://example.com","identity":"foo","sync":{"full_sync_at":"25:00"}}`, err: true},
+		{name: "unknown time zone", format: "json", data: `{"endpoint":"https://example.com","identity":"foo","sync":{"full_sync_at":"04:00","time_zone":"Nowhere/Nothing"}}`, err: true},
+		{name: "unknown log level", format: "json", data: `{"endpoint":"https://example.com","identity":"foo","log_level":"verbose"}`, err: true},
+		{name: "unknown format", format: "ini", data: ``, err: true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg, err := ParseConfig([]byte(data.data), data.format)
+			if data.err {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(want, cfg)
+		})
+	}
+}
+
+func TestManager(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != endpointAll {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`["a.com","b.com"]`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cache.msgpack")
+	m, err := NewManager(Config{
+		Endpoint: srv.URL,
+		Identity: "test",
+		Store:    StoreConfig{Path: path, Format: "msgpack"},
+	})
+	a.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+	a.Eventually(func() bool { return m.Client().Size() == 2 }, time.Second, time.Millisecond*10)
+	cancel()
+	a.NoError(<-done)
+
+	loaded := New("", "test", http.Client{})
+	a.NoError(NewFileStore(path, CacheMsgpack).Load(loaded))
+	a.True(loaded.Check("b.com"))
+}
+
+func TestManagerReload(t *testing.T) {
+	a := assert.New(t)
+	var recent int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == endpointAll:
+			_, _ = w.Write([]byte(`["a.com"]`))
+		case strings.HasPrefix(r.URL.Path, endpointRecent):
+			atomic.AddInt32(&recent, 1)
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := Config{Endpoint: srv.URL, Identity: "test"}
+	m, err := NewManager(cfg)
+	a.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+	a.Eventually(func() bool { return m.Client().Size() == 1 }, time.Second, time.Millisecond*10)
+	a.Equal(int32(0), atomic.LoadInt32(&recent))
+
+	changed := cfg
+	changed.Endpoint = "https://example.com"
+	a.Error(m.Reload(changed))
+	changed = cfg
+	changed.Bootstrap.URL = "https://example.com/cache.json"
+	a.Error(m.Reload(changed), "the cache is only bootstrapped on start")
+	changed = cfg
+	changed.RegexRules = []string{"("}
+	a.Error(m.Reload(changed))
+	warnings, err := m.Config().Warnings()
+	a.NoError(err)
+	a.True(warnings)
+
+	cfg.Sync.RecentInterval = Duration(time.Millisecond * 10)
+	cfg.Categories = []string{"malware"}
+	cfg.LogLevel = "error"
+	a.NoError(m.Reload(cfg))
+	warnings, err = m.Config().Warnings()
+	a.NoError(err)
+	a.False(warnings, "the log level is reloaded")
+	a.Eventually(func() bool { return atomic.LoadInt32(&recent) >= 2 }, time.Second, time.Millisecond*10)
+	a.True(m.Client().Check("a.com"))
+
+	cancel()
+	a.NoError(<-done)
+}
+
+func TestManagerFeedReconnect(t *testing.T) {
+	a := assert.New(t)
+	var feeds int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case endpointAll:
+			_, _ = w.Write([]byte(`["a.com"]`))
+		case endpointFeed:
+			if atomic.AddInt32(&feeds, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			cn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer cn.Close(websocket.StatusNormalClosure, "")
+			_ = cn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"add","domains":["b.com"]}`))
+			<-cn.CloseRead(r.Context()).Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	m, err := NewManager(Config{Endpoint: srv.URL, Identity: "test", Sync: SyncConfig{Realtime: true}})
+	a.NoError(err)
+	errs := make(chan error, 4)
+	m.OnError(func(err error) {
+		errs <- err
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+	a.Eventually(func() bool { return m.Client().Check("b.com") }, time.Second*5, time.Millisecond*10, "the feed is reconnected")
+	var syncErr SyncError
+	if a.ErrorAs(<-errs, &syncErr) {
+		a.Equal(SyncOpFeed, syncErr.Op)
+		a.Equal(SeverityTransient, syncErr.Severity)
+	}
+	a.True(m.Client().Check("a.com"))
+
+	cancel()
+	a.NoError(<-done)
+}
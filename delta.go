@@ -0,0 +1,138 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//endpointDelta is the endpoint of changes served by Mirror
+const endpointDelta = "/delta"
+
+//Delta is the response of a Mirror's /delta endpoint
+type Delta struct {
+	//Instance identifies the Mirror, generations are only comparable within the same instance
+	Instance string `json:"instance"`
+	//Generation is the generation of the Mirror's cache the delta brings the follower to
+	Generation uint64 `json:"generation"`
+	//Full is true when Added is the full list, and the follower's cache should be replaced with it
+	Full bool `json:"full,omitempty"`
+	//Added are the domains added since the requested generation
+	Added []string `json:"added,omitempty"`
+	//Removed are the domains removed since the requested generation
+	Removed []string `json:"removed,omitempty"`
+}
+
+//deltaCursor is where the last DeltaSync left off
+type deltaCursor struct {
+	instance   string
+	generation uint64
+}
+
+//Delta returns the changes of a Mirror's cache since the generation of the instance
+//an empty instance, or a generation the Mirror no longer has the changes since, returns the full list
+func (c RawClient) Delta(instance string, since uint64) (Delta, error) {
+	query := url.Values{}
+	query.Set("instance", instance)
+	query.Set("since", strconv.FormatUint(since, 10))
+	resp, err := c.doReq(endpointDelta + "?" + query.Encode())
+	if err != nil {
+		return Delta{}, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != 200 {
+		return Delta{}, unexpectedStatusError{
+			endpoint: c.domain + endpointDelta,
+			status:   resp.StatusCode,
+		}
+	}
+	var d Delta
+	err = json.NewDecoder(resp.Body).Decode(&d)
+	d.Added = c.filterDomains(d.Added)
+	return d, err
+}
+
+//DeltaSync updates the cache from a Mirror, transferring only the changes since the last DeltaSync
+//the first DeltaSync, and any after the Mirror lost track of the changes, transfers the full list like FullSync
+//the Client must be pointed at a Mirror, the api itself doesn't serve deltas
+func (c *Client) DeltaSync() (err error) {
+	c.startSync(SyncOpDeltaSync)
+	defer func() { c.recordSync(SyncOpDeltaSync, err) }()
+	c.m.Lock()
+	cursor, paused := c.delta, c.paused
+	c.m.Unlock()
+	if paused {
+		return ErrPaused
+	}
+	d, err := c.r.Delta(cursor.instance, cursor.generation)
+	if err != nil {
+		return err
+	}
+
+	var dMap map[string]empty
+	var a *arena
+	if d.Full {
+		dMap, a = internDomains(d.Added)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.paused {
+		return ErrPaused
+	}
+	if d.Full {
+		//a held list isn't synced yet, the cursor stays so the next DeltaSync transfers the full list again
+		if c.replaceDomains(dMap, a, SourceDelta) {
+			c.sendUpdate()
+			return nil
+		}
+	} else {
+		if len(d.Removed) > 0 {
+			c.applyMod(DomainUpdate{Add: false, Domains: d.Removed}, SourceDelta)
+		}
+		if len(d.Added) > 0 {
+			c.applyMod(DomainUpdate{Add: true, Domains: d.Added}, SourceDelta)
+		}
+	}
+	c.lastUpdated = time.Now()
+	c.delta = deltaCursor{instance: d.Instance, generation: d.Generation}
+	c.sendUpdate()
+	return nil
+}
+
+//serveDelta responds with the changes since the requested generation, or the full list if they're unknown
+func (m *Mirror) serveDelta(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	since, err := strconv.ParseUint(query.Get("since"), 10, 64)
+	if err != nil && query.Get("since") != "" {
+		return badRequest{errors.New("invalid since")}
+	}
+	d := Delta{Instance: m.instance}
+	var updates []AppliedUpdate
+	if query.Get("instance") == m.instance {
+		updates, d.Generation, err = m.c.updatesAfterGeneration(since)
+	} else {
+		err = ErrHistoryTruncated
+	}
+	if err != nil {
+		//the generation is read before the domains, a change in between is sent again with the next delta
+		d.Full = true
+		d.Generation, _ = m.c.version()
+		d.Added = m.c.Domains()
+		sort.Strings(d.Added)
+		return writeJSON(w, d)
+	}
+	for _, au := range netChanges(updates) {
+		if au.Update.Add {
+			d.Added = append(d.Added, au.Update.Domains...)
+		} else {
+			d.Removed = append(d.Removed, au.Update.Domains...)
+		}
+	}
+	return writeJSON(w, d)
+}
@@ -0,0 +1,198 @@
+package sinkingyachts
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+//defaultFallbackDelay is how long the preferred address family is tried before racing the other, the same as net.Dialer
+const defaultFallbackDelay = time.Millisecond * 300
+
+//IPPreference is which ip versions connections to the api are made over, see WithIPPreference
+type IPPreference int
+
+const (
+	//IPAuto prefers the family of the first resolved address, and races the other family after the fallback delay
+	IPAuto IPPreference = iota
+	//PreferIPv4 tries ipv4 first, and races ipv6 after the fallback delay
+	PreferIPv4
+	//PreferIPv6 tries ipv6 first, and races ipv4 after the fallback delay
+	PreferIPv6
+	//OnlyIPv4 never connects over ipv6, for networks with broken ipv6
+	OnlyIPv4
+	//OnlyIPv6 never connects over ipv4
+	OnlyIPv6
+)
+
+//WithIPPreference sets which ip versions connections are made over, for both requests and the websocket feed
+//networks with broken ipv6 stall on every dial until ipv4 is tried, PreferIPv4 or OnlyIPv4 avoid the stall
+//it replaces the dialer of the transport, see WithMaxIdleConns for how transports are tuned
+func WithIPPreference(preference IPPreference) Option {
+	return func(client *RawClient) {
+		client.transport.preference = preference
+		client.transport.dirty = true
+	}
+}
+
+//WithFallbackDelay sets how long the preferred ip version is tried before the other is raced, as described by happy eyeballs
+//the default is 300ms, a negative delay tries the addresses one by one without racing
+//it replaces the dialer of the transport, see WithMaxIdleConns for how transports are tuned
+func WithFallbackDelay(delay time.Duration) Option {
+	return func(client *RawClient) {
+		client.transport.fallbackDelay = delay
+		client.transport.dirty = true
+	}
+}
+
+//customDialer checks if the transport settings need a dialer other than the transport's
+func (cfg transportConfig) customDialer() bool {
+	return cfg.keepAlive != 0 || cfg.resolver != nil || cfg.preference != IPAuto || cfg.fallbackDelay != 0
+}
+
+//dialContext returns the dial function of the transport settings
+func (cfg transportConfig) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:       time.Second * 10,
+		KeepAlive:     cfg.keepAlive,
+		FallbackDelay: cfg.fallbackDelay,
+	}
+	if cfg.resolver == nil && cfg.preference == IPAuto {
+		return d.DialContext
+	}
+	var resolver Resolver = net.DefaultResolver
+	if cfg.resolver != nil {
+		resolver = cfg.resolver
+	}
+	delay := cfg.fallbackDelay
+	if delay == 0 {
+		delay = defaultFallbackDelay
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return d.DialContext(ctx, network, addr)
+		}
+		ips := []string{host}
+		if net.ParseIP(host) == nil {
+			if ips, err = resolver.LookupHost(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+		primaries, fallbacks := splitFamilies(ips, cfg.preference)
+		if len(primaries) == 0 {
+			return nil, &net.AddrError{Err: "no address of the allowed ip version", Addr: host}
+		}
+		if delay < 0 {
+			return dialSerial(ctx, d, network, append(primaries, fallbacks...), port)
+		}
+		return dialParallel(ctx, d, network, primaries, fallbacks, port, delay)
+	}
+}
+
+//splitFamilies splits addresses into the preferred ip version and the fallback version, keeping their order
+func splitFamilies(ips []string, preference IPPreference) (primaries, fallbacks []string) {
+	var v4, v6 []string
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	switch preference {
+	case PreferIPv4:
+		return v4, v6
+	case PreferIPv6:
+		return v6, v4
+	case OnlyIPv4:
+		return v4, nil
+	case OnlyIPv6:
+		return v6, nil
+	}
+	if len(ips) > 0 && len(v6) > 0 && v6[0] == ips[0] {
+		return v6, v4
+	}
+	return v4, v6
+}
+
+//dialSerial dials the addresses one by one, returning the first connection or the first error
+func dialSerial(ctx context.Context, d *net.Dialer, network string, ips []string, port string) (net.Conn, error) {
+	var firstErr error
+	for _, ip := range ips {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+//dialResult is the result of a dialSerial racing in dialParallel
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+//dialParallel dials the primaries, and races the fallbacks once delay passes or the primaries fail
+//the first connection wins, connections that lose the race are closed
+func dialParallel(ctx context.Context, d *net.Dialer, network string, primaries, fallbacks []string, port string, delay time.Duration) (net.Conn, error) {
+	if len(fallbacks) == 0 {
+		return dialSerial(ctx, d, network, primaries, port)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan dialResult, 2)
+	race := func(ips []string) {
+		go func() {
+			conn, err := dialSerial(ctx, d, network, ips, port)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+	race(primaries)
+	pending := 1
+	fallback := time.NewTimer(delay)
+	defer fallback.Stop()
+	startFallback := func() {
+		if fallbacks != nil {
+			race(fallbacks)
+			fallbacks = nil
+			pending++
+		}
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-fallback.C:
+			startFallback()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if pending > 0 {
+					go func(pending int) {
+						for i := 0; i < pending; i++ {
+							if lost := <-results; lost.conn != nil {
+								_ = lost.conn.Close()
+							}
+						}
+					}(pending)
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			startFallback()
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
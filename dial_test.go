@@ -0,0 +1,94 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//staticResolver resolves every host to the same addresses
+type staticResolver []string
+
+func (r staticResolver) LookupHost(context.Context, string) ([]string, error) {
+	return r, nil
+}
+
+func TestSplitFamilies(t *testing.T) {
+	ips := []string{"2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2"}
+	tests := []struct {
+		name       string
+		ips        []string
+		preference IPPreference
+		primaries  []string
+		fallbacks  []string
+	}{
+		{"auto follows the first address", ips, IPAuto, []string{"2001:db8::1", "2001:db8::2"}, []string{"192.0.2.1", "192.0.2.2"}},
+		{"auto with ipv4 first", ips[1:], IPAuto, []string{"192.0.2.1", "192.0.2.2"}, []string{"2001:db8::2"}},
+		{"prefer ipv4", ips, PreferIPv4, []string{"192.0.2.1", "192.0.2.2"}, []string{"2001:db8::1", "2001:db8::2"}},
+		{"prefer ipv6", ips, PreferIPv6, []string{"2001:db8::1", "2001:db8::2"}, []string{"192.0.2.1", "192.0.2.2"}},
+		{"only ipv4", ips, OnlyIPv4, []string{"192.0.2.1", "192.0.2.2"}, nil},
+		{"only ipv6", ips, OnlyIPv6, []string{"2001:db8::1", "2001:db8::2"}, nil},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			primaries, fallbacks := splitFamilies(data.ips, data.preference)
+			a.Equal(data.primaries, primaries)
+			a.Equal(data.fallbacks, fallbacks)
+		})
+	}
+}
+
+func TestDialParallel(t *testing.T) {
+	a := assert.New(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	//127.0.0.2 stands in for a broken address family, dials to it stall
+	d := &net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		if strings.HasPrefix(address, "127.0.0.2:") {
+			time.Sleep(time.Second * 2)
+		}
+		return nil
+	}}
+
+	start := time.Now()
+	conn, err := dialParallel(context.Background(), d, "tcp", []string{"127.0.0.2"}, []string{"127.0.0.1"}, port, time.Millisecond*50)
+	a.NoError(err)
+	a.Less(time.Since(start), time.Second, "the fallback is raced after the delay")
+	a.Equal(l.Addr().String(), conn.RemoteAddr().String())
+	_ = conn.Close()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	_ = closed.Close()
+	_, err = dialParallel(context.Background(), &net.Dialer{}, "tcp", []string{"127.0.0.1"}, []string{"127.0.0.1"}, closedPort, time.Hour)
+	a.Error(err, "failing primaries start the fallback right away, and both failing is an error")
+}
+
+func TestWithIPPreference(t *testing.T) {
+	a := assert.New(t)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("42"))
+	}))
+	defer api.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(api.URL, "http://"))
+
+	c := NewRawClient("http://api.test:"+port, "test", NewHTTPClient(0), WithResolver(staticResolver{"127.0.0.1"}), WithIPPreference(OnlyIPv4))
+	size, err := c.Size()
+	a.NoError(err)
+	a.Equal(42, size)
+
+	c = c.With(WithIPPreference(OnlyIPv6))
+	_, err = c.Size()
+	var addrErr *net.AddrError
+	a.ErrorAs(err, &addrErr)
+}
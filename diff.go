@@ -0,0 +1,74 @@
+package sinkingyachts
+
+import "sort"
+
+//Diff represents the difference between two sets of domains
+type Diff struct {
+	//Added are domains that are present in the new set but not in the old set
+	Added []string
+	//Removed are domains that are present in the old set but not in the new set
+	Removed []string
+}
+
+//DiffDomains compares two slices of domains and returns the changes needed to turn old into new
+//duplicates are ignored, and both Diff.Added and Diff.Removed will be sorted
+func DiffDomains(old, new []string) Diff {
+	oldSet := make(map[string]empty, len(old))
+	for _, d := range old {
+		oldSet[d] = empty{}
+	}
+	newSet := make(map[string]empty, len(new))
+	for _, d := range new {
+		newSet[d] = empty{}
+	}
+	return diffSets(oldSet, newSet)
+}
+
+//diffSets compares two domain sets and return the changes needed to turn old into new
+func diffSets(old, new map[string]empty) Diff {
+	var diff Diff
+	for d := range new {
+		if _, found := old[d]; !found {
+			diff.Added = append(diff.Added, d)
+		}
+	}
+	for d := range old {
+		if _, found := new[d]; !found {
+			diff.Removed = append(diff.Removed, d)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+//Empty returns true if the diff contains no changes
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+//Updates converts the diff into a slice of DomainUpdate
+//removals are emitted before additions, empty halves are omitted
+//the result can be applied to another instance to replicate the changes
+func (d Diff) Updates() []DomainUpdate {
+	var mods []DomainUpdate
+	if len(d.Removed) > 0 {
+		mods = append(mods, DomainUpdate{Add: false, Domains: d.Removed})
+	}
+	if len(d.Added) > 0 {
+		mods = append(mods, DomainUpdate{Add: true, Domains: d.Added})
+	}
+	return mods
+}
+
+//Diff returns the changes needed to turn the given domains into Client's known domains
+//this can be used to bring another instance up to date with this Client
+func (c *Client) Diff(domains []string) Diff {
+	old := make(map[string]empty, len(domains))
+	for _, d := range domains {
+		old[d] = empty{}
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	return diffSets(old, c.domains)
+}
@@ -0,0 +1,51 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffDomains(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      []string
+		new      []string
+		expected []DomainUpdate
+	}{
+		{
+			name:     "Same",
+			old:      []string{"a.com", "b.com"},
+			new:      []string{"b.com", "a.com"},
+			expected: nil,
+		},
+		{
+			name:     "Added",
+			old:      []string{"a.com"},
+			new:      []string{"a.com", "c.com", "b.com"},
+			expected: []DomainUpdate{{Add: true, Domains: []string{"b.com", "c.com"}}},
+		},
+		{
+			name:     "Removed",
+			old:      []string{"a.com", "b.com"},
+			new:      nil,
+			expected: []DomainUpdate{{Add: false, Domains: []string{"a.com", "b.com"}}},
+		},
+		{
+			name: "Both",
+			old:  []string{"a.com", "b.com"},
+			new:  []string{"b.com", "c.com", "c.com"},
+			expected: []DomainUpdate{
+				{Add: false, Domains: []string{"a.com"}},
+				{Add: true, Domains: []string{"c.com"}},
+			},
+		},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			diff := DiffDomains(data.old, data.new)
+			a.Equal(data.expected, diff.Updates())
+			a.Equal(data.expected == nil, diff.Empty())
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//dns record types resolved by DoHResolver
+const (
+	dnsTypeA    = 1
+	dnsTypeAAAA = 28
+)
+
+//dohMaxTTL caps how long DoHResolver caches an answer
+const dohMaxTTL = time.Hour
+
+//errDNSMessage is returned for malformed dns responses
+var errDNSMessage = errors.New("malformed dns message")
+
+//Resolver resolves host names into ip addresses, *net.Resolver and *DoHResolver implement it
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+//WithResolver resolves the api's host name with r instead of the system resolver, for both requests and the websocket feed
+//this helps deployments behind dns filtering that blocks or poisons security feeds, see DoHResolver
+//it replaces the dialer of the transport, see WithMaxIdleConns for how transports are tuned
+func WithResolver(r Resolver) Option {
+	return func(client *RawClient) {
+		client.transport.resolver = r
+		client.transport.dirty = true
+	}
+}
+
+//DoHResolver resolves host names with dns over https as described by rfc 8484, such as "https://cloudflare-dns.com/dns-query"
+//the DoH server itself is resolved by Client, so a URL with an ip address avoids depending on the system resolver at all
+//answers are cached for their ttl, up to an hour
+type DoHResolver struct {
+	//URL is the dns over https endpoint
+	URL string
+	//Client sends the queries, defaults to http.DefaultClient
+	Client *http.Client
+
+	m     sync.Mutex
+	cache map[string]dohEntry
+}
+
+//dohEntry is a cached answer
+type dohEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+//LookupHost returns the ipv4 and ipv6 addresses of host, ipv4 addresses first
+func (r *DoHResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	r.m.Lock()
+	entry, ok := r.cache[host]
+	r.m.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	var addrs []string
+	var firstErr error
+	ttl := dohMaxTTL
+	for _, qtype := range []uint16{dnsTypeA, dnsTypeAAAA} {
+		ips, recordTTL, err := r.query(ctx, host, qtype)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		addrs = append(addrs, ips...)
+		if len(ips) > 0 && recordTTL < ttl {
+			ttl = recordTTL
+		}
+	}
+	if len(addrs) == 0 {
+		if firstErr == nil {
+			firstErr = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, firstErr
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.cache == nil {
+		r.cache = map[string]dohEntry{}
+	}
+	r.cache[host] = dohEntry{addrs: addrs, expires: time.Now().Add(ttl)}
+	return addrs, nil
+}
+
+//query resolves a single record type of host, returning the addresses and their lowest ttl
+func (r *DoHResolver) query(ctx context.Context, host string, qtype uint16) ([]string, time.Duration, error) {
+	msg, err := dnsQuery(host, qtype)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(msg))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, unexpectedStatusError{
+			endpoint: r.URL,
+			status:   resp.StatusCode,
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseDNSAnswer(body, host, qtype)
+}
+
+//dnsQuery encodes a recursive dns query for a record type of host, with an id of 0 as recommended for DoH
+func dnsQuery(host string, qtype uint16) ([]byte, error) {
+	msg := []byte{0, 0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, &net.DNSError{Err: "invalid host name", Name: host}
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0, byte(qtype>>8), byte(qtype), 0, 1), nil
+}
+
+//parseDNSAnswer returns the addresses of the records of qtype in a dns response, and their lowest ttl
+func parseDNSAnswer(msg []byte, host string, qtype uint16) ([]string, time.Duration, error) {
+	if len(msg) < 12 {
+		return nil, 0, errDNSMessage
+	}
+	switch rcode := msg[3] & 0x0f; rcode {
+	case 0:
+	case 3:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	default:
+		return nil, 0, &net.DNSError{Err: "dns server failure", Name: host, IsTemporary: rcode == 2}
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	answers := int(binary.BigEndian.Uint16(msg[6:]))
+	off := 12
+	var ok bool
+	for i := 0; i < questions; i++ {
+		if off, ok = skipDNSName(msg, off); !ok || off+4 > len(msg) {
+			return nil, 0, errDNSMessage
+		}
+		off += 4
+	}
+
+	var ips []string
+	ttl := dohMaxTTL
+	for i := 0; i < answers; i++ {
+		if off, ok = skipDNSName(msg, off); !ok || off+10 > len(msg) {
+			return nil, 0, errDNSMessage
+		}
+		rtype := binary.BigEndian.Uint16(msg[off:])
+		recordTTL := time.Duration(binary.BigEndian.Uint32(msg[off+4:])) * time.Second
+		length := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+length > len(msg) {
+			return nil, 0, errDNSMessage
+		}
+		data := msg[off : off+length]
+		off += length
+		if rtype != qtype || (rtype == dnsTypeA && length != net.IPv4len) || (rtype == dnsTypeAAAA && length != net.IPv6len) {
+			continue
+		}
+		ips = append(ips, net.IP(data).String())
+		if recordTTL < ttl {
+			ttl = recordTTL
+		}
+	}
+	return ips, ttl, nil
+}
+
+//skipDNSName returns the offset after the name at off, names may end in a compression pointer
+func skipDNSName(msg []byte, off int) (int, bool) {
+	for off < len(msg) {
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1, true
+		case n&0xc0 == 0xc0:
+			return off + 2, off+2 <= len(msg)
+		default:
+			off += n + 1
+		}
+	}
+	return 0, false
+}
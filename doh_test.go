@@ -0,0 +1,83 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//dohServer answers A queries for api.test with 127.0.0.1, and NXDOMAIN for anything else
+func dohServer(queries *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries++
+		msg, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != "application/dns-message" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := append([]byte(nil), msg...)
+		resp[2] |= 0x80
+		end, _ := skipDNSName(msg, 12)
+		name := msg[12:end]
+		qtype := uint16(msg[end])<<8 | uint16(msg[end+1])
+		switch {
+		case string(name) != "\x03api\x04test\x00":
+			resp[3] |= 3
+		case qtype == dnsTypeA:
+			resp[7] = 1
+			resp = append(resp, 0xc0, 12, 0, dnsTypeA, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(resp)
+	}))
+}
+
+func TestDoHResolver(t *testing.T) {
+	a := assert.New(t)
+	var queries int
+	doh := dohServer(&queries)
+	defer doh.Close()
+	r := &DoHResolver{URL: doh.URL}
+
+	addrs, err := r.LookupHost(context.Background(), "API.test.")
+	a.NoError(err)
+	a.Equal([]string{"127.0.0.1"}, addrs)
+	a.Equal(2, queries)
+	_, err = r.LookupHost(context.Background(), "api.test")
+	a.NoError(err)
+	a.Equal(2, queries, "answers are cached")
+
+	_, err = r.LookupHost(context.Background(), "missing.test")
+	var dnsErr *net.DNSError
+	a.ErrorAs(err, &dnsErr)
+	a.True(dnsErr.IsNotFound)
+
+	_, _, err = parseDNSAnswer([]byte{0, 0, 0x80, 0, 0, 1, 0, 1, 0, 0, 0, 0, 3, 'a'}, "a", dnsTypeA)
+	a.ErrorIs(err, errDNSMessage)
+}
+
+func TestWithResolver(t *testing.T) {
+	a := assert.New(t)
+	var queries int
+	doh := dohServer(&queries)
+	defer doh.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("42"))
+	}))
+	defer api.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(api.URL, "http://"))
+
+	c := NewRawClient("http://api.test:"+port, "test", NewHTTPClient(0), WithResolver(&DoHResolver{URL: doh.URL}))
+	size, err := c.Size()
+	a.NoError(err)
+	a.Equal(42, size)
+
+	c = NewRawClient("http://missing.test:"+port, "test", NewHTTPClient(0), WithResolver(&DoHResolver{URL: doh.URL}))
+	_, err = c.Size()
+	a.Error(err)
+}
@@ -0,0 +1,35 @@
+package sinkingyachts
+
+//SetDryRun toggles dry run mode, where checks observe matches without reporting them as phishing
+//this lets operators evaluate false positives before enforcing, matches are counted in Stats.DryRunHits
+//onHit is called with every match while in dry run mode if not nil, it should not block
+//domains of the matches passed to onHit are redacted as set by SetPrivacy
+func (c *Client) SetDryRun(enabled bool, onHit func(Match)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.dryRun = enabled
+	c.onDryRun = onHit
+}
+
+//DryRun returns true if Client is in dry run mode
+func (c *Client) DryRun() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.dryRun
+}
+
+//observeDryRun records a match if in dry run mode, and returns true if it should not be acted upon
+func (c *Client) observeDryRun(m Match) bool {
+	c.m.Lock()
+	if !c.dryRun {
+		c.m.Unlock()
+		return false
+	}
+	c.dryRunHits++
+	onHit := c.onDryRun
+	c.m.Unlock()
+	if onHit != nil {
+		onHit(c.redactMatch(m))
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package sinkingyachts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Elector decides which instance in a cluster is allowed to sync against the api
+type Elector interface {
+	//Campaign blocks until this instance becomes the leader, or ctx is cancelled
+	//the returned channel is closed when leadership is lost
+	Campaign(ctx context.Context) (<-chan struct{}, error)
+	//Resign gives up leadership, it is a no op if this instance is not the leader
+	Resign(ctx context.Context) error
+}
+
+//LeaderSync runs sync only while this instance is the leader
+//ctx given to sync is cancelled when leadership is lost, after which LeaderSync campaigns again
+//this function blocks and return only when cancelled by ctx, or when campaigning or sync returns an error
+//for example LeaderSync(ctx, e, func(ctx context.Context) error { return AutoSync(ctx, c, true, 0, time.Hour) })
+//instances that are not leading should get their domains from the leader instead, such as with Replicator
+func LeaderSync(ctx context.Context, e Elector, sync func(ctx context.Context) error) error {
+	for {
+		lost, err := e.Campaign(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		leadCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lost:
+				cancel()
+			case <-leadCtx.Done():
+			}
+		}()
+		err = sync(leadCtx)
+		cancel()
+
+		resignCtx, resignCancel := context.WithTimeout(context.Background(), time.Second*5)
+		errResign := e.Resign(resignCtx)
+		resignCancel()
+		if err != nil {
+			return err
+		}
+		if errResign != nil {
+			return errResign
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+//Lease is a shared lock with an expiry, such as a redis key set with NX and PX, or a row in a sql table
+type Lease interface {
+	//Acquire attempts to take the lease for ttl, returns false if it is held by someone else
+	Acquire(ctx context.Context, ttl time.Duration) (bool, error)
+	//Renew extends a held lease by ttl, returns false if the lease has been lost
+	Renew(ctx context.Context, ttl time.Duration) (bool, error)
+	//Release gives up a held lease
+	Release(ctx context.Context) error
+}
+
+//LeaseElector is an Elector backed by a Lease
+//the lease is renewed every third of the TTL while leading
+type LeaseElector struct {
+	lease Lease
+	ttl   time.Duration
+	retry time.Duration
+	m     sync.Mutex
+	stop  context.CancelFunc
+	done  chan struct{}
+}
+
+//NewLeaseElector creates a LeaseElector
+//ttl is how long the lease is held without renewal, retry is how often to try acquiring the lease
+func NewLeaseElector(lease Lease, ttl, retry time.Duration) *LeaseElector {
+	return &LeaseElector{
+		lease: lease,
+		ttl:   ttl,
+		retry: retry,
+	}
+}
+
+//Campaign blocks until the lease is acquired, or ctx is cancelled
+func (e *LeaseElector) Campaign(ctx context.Context) (<-chan struct{}, error) {
+	ticker := time.NewTicker(e.retry)
+	defer ticker.Stop()
+	for {
+		ok, err := e.lease.Acquire(ctx, e.ttl)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	lost := make(chan struct{})
+	e.m.Lock()
+	e.stop = cancel
+	e.done = lost
+	e.m.Unlock()
+	go e.renew(renewCtx, lost)
+	return lost, nil
+}
+
+//Resign stops renewing and releases the lease
+func (e *LeaseElector) Resign(ctx context.Context) error {
+	e.m.Lock()
+	stop, done := e.stop, e.done
+	e.stop, e.done = nil, nil
+	e.m.Unlock()
+	if stop == nil {
+		return nil
+	}
+	stop()
+	<-done
+	return e.lease.Release(ctx)
+}
+
+//renew keeps the lease alive until ctx is cancelled or renewal fails
+//lost is closed once renewing stops
+func (e *LeaseElector) renew(ctx context.Context, lost chan struct{}) {
+	defer close(lost)
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := e.lease.Renew(ctx, e.ttl)
+			if err != nil || !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//memoryLease is a Lease shared between electors in the same process
+type memoryLease struct {
+	m      *sync.Mutex
+	holder *int
+	id     int
+}
+
+func (l memoryLease) Acquire(context.Context, time.Duration) (bool, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if *l.holder != 0 && *l.holder != l.id {
+		return false, nil
+	}
+	*l.holder = l.id
+	return true, nil
+}
+
+func (l memoryLease) Renew(context.Context, time.Duration) (bool, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return *l.holder == l.id, nil
+}
+
+func (l memoryLease) Release(context.Context) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if *l.holder == l.id {
+		*l.holder = 0
+	}
+	return nil
+}
+
+func TestLeaderSync(t *testing.T) {
+	a := assert.New(t)
+	var m sync.Mutex
+	var holder int
+	var running, max, runs int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		e := NewLeaseElector(memoryLease{m: &m, holder: &holder, id: i}, time.Millisecond*30, time.Millisecond*5)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := LeaderSync(ctx, e, func(ctx context.Context) error {
+				n := atomic.AddInt32(&running, 1)
+				if n > atomic.LoadInt32(&max) {
+					atomic.StoreInt32(&max, n)
+				}
+				atomic.AddInt32(&runs, 1)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Millisecond * 20):
+				}
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+			a.NoError(err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 150)
+	cancel()
+	wg.Wait()
+	a.Equal(int32(1), atomic.LoadInt32(&max))
+	a.Greater(atomic.LoadInt32(&runs), int32(1))
+}
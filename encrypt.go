@@ -0,0 +1,131 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+//encryptedMagic prefixes data sealed by Encrypt
+const encryptedMagic = "SYE1"
+
+//ErrDecrypt is returned when encrypted data is not valid or was encrypted with another key
+var ErrDecrypt = errors.New("decrypting failed, the data is invalid or was encrypted with another key")
+
+//KeyFunc returns the AES key caches and journals are encrypted with, 16, 24 or 32 bytes long for AES-128, AES-192 or AES-256
+//it's called for every encryption and decryption, keys fetched from a KMS should be cached by it
+type KeyFunc func() ([]byte, error)
+
+//EnvKey returns a KeyFunc reading a base64 encoded key from the environment variable
+func EnvKey(name string) KeyFunc {
+	return func() ([]byte, error) {
+		encoded := os.Getenv(name)
+		if encoded == "" {
+			return nil, fmt.Errorf("encryption key %s is not set", name)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", name, err)
+		}
+		return key, nil
+	}
+}
+
+//Encrypt seals plaintext with AES-GCM under the key
+func Encrypt(key KeyFunc, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(encryptedMagic)+gcm.NonceSize(), len(encryptedMagic)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	copy(out, encryptedMagic)
+	nonce := out[len(encryptedMagic):]
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
+
+//Decrypt opens data sealed by Encrypt, ErrDecrypt is returned if it's invalid or sealed under another key
+func Decrypt(key KeyFunc, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(encryptedMagic)+gcm.NonceSize() || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, ErrDecrypt
+	}
+	data = data[len(encryptedMagic):]
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+//newGCM creates an AES-GCM cipher with the key
+func newGCM(key KeyFunc) (cipher.AEAD, error) {
+	k, err := key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//NewEncryptedJournal creates a Journal that writes every update as a line of base64 data sealed by Encrypt
+//read it back with DecryptJournal
+func NewEncryptedJournal(w io.Writer, key KeyFunc) *Journal {
+	return &Journal{w: w, key: key}
+}
+
+//DecryptJournal returns a reader of the json lines of a journal written by NewEncryptedJournal, such as for Replay
+func DecryptJournal(r io.Reader, key KeyFunc) io.Reader {
+	return &journalDecrypter{r: bufio.NewReader(r), key: key}
+}
+
+//journalDecrypter decrypts an encrypted journal line by line
+type journalDecrypter struct {
+	r    *bufio.Reader
+	key  KeyFunc
+	line []byte
+	err  error
+}
+
+func (d *journalDecrypter) Read(p []byte) (int, error) {
+	for len(d.line) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		var line []byte
+		line, d.err = d.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		sealed, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			d.err = ErrDecrypt
+			return 0, d.err
+		}
+		d.line, err = Decrypt(d.key, sealed)
+		if err != nil {
+			d.err = err
+			return 0, d.err
+		}
+		d.line = append(d.line, '\n')
+	}
+	n := copy(p, d.line)
+	d.line = d.line[n:]
+	return n, nil
+}
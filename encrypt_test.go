@@ -0,0 +1,59 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEncryption(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("YACHTS_TEST_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	key := EnvKey("YACHTS_TEST_KEY")
+	other := KeyFunc(func() ([]byte, error) { return bytes.Repeat([]byte{2}, 32), nil })
+	_, err := EnvKey("YACHTS_TEST_MISSING")()
+	a.Error(err)
+
+	sealed, err := Encrypt(key, []byte("bad.com"))
+	a.NoError(err)
+	a.NotContains(string(sealed), "bad.com")
+	plaintext, err := Decrypt(key, sealed)
+	a.NoError(err)
+	a.Equal("bad.com", string(plaintext))
+	_, err = Decrypt(other, sealed)
+	a.ErrorIs(err, ErrDecrypt)
+	_, err = Decrypt(key, sealed[:8])
+	a.ErrorIs(err, ErrDecrypt)
+
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	store := NewFileStore(path, CacheJSON)
+	store.EnableEncryption(key)
+	a.NoError(store.Save(c))
+	data, err := os.ReadFile(path)
+	a.NoError(err)
+	a.NotContains(string(data), "bad.com")
+	loaded := New("", "test", http.Client{})
+	a.NoError(store.Load(loaded))
+	a.True(loaded.Check("bad.com"))
+	wrong := NewFileStore(path, CacheJSON)
+	wrong.EnableEncryption(other)
+	a.ErrorIs(wrong.Load(New("", "test", http.Client{})), ErrDecrypt)
+
+	var buf bytes.Buffer
+	j := NewEncryptedJournal(&buf, key)
+	a.NoError(j.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, Time: time.Now(), Source: SourceFeed}))
+	a.NoError(j.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: false, Domains: []string{"a.com"}}, Time: time.Now(), Source: SourceFeed}))
+	a.NotContains(buf.String(), "a.com")
+	replayed := New("", "test", http.Client{})
+	a.NoError(ReplayInto(context.Background(), replayed, DecryptJournal(bytes.NewReader(buf.Bytes()), key), 0))
+	a.Equal([]string{"b.com"}, replayed.Domains())
+	a.ErrorIs(ReplayInto(context.Background(), replayed, DecryptJournal(bytes.NewReader(buf.Bytes()), other), 0), ErrDecrypt)
+}
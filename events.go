@@ -0,0 +1,150 @@
+package sinkingyachts
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//EventKind is what happened in an Event
+type EventKind int
+
+const (
+	//EventSyncStarted is published when a sync starts, Event.Op is the SyncOp of the sync
+	EventSyncStarted EventKind = iota
+	//EventSyncFinished is published when a sync finishes, Event.Err is set if it failed, including with ErrPaused
+	EventSyncFinished
+	//EventUpdateApplied is published for every update applied to the cache, Event.Update is the update, see Client.OnUpdate
+	EventUpdateApplied
+	//EventFeedStarted is published when Client starts listening on the feed
+	EventFeedStarted
+	//EventFeedStopped is published when Client stops listening on the feed, Event.Err is what the feed stopped with
+	EventFeedStopped
+	//EventAnomaly is published when the AnomalyGuard flags an update, Event.Anomaly is the anomaly, see Client.OnAnomaly
+	EventAnomaly
+	//EventMissedWindow is published when Client.Update falls back to a full sync, Event.MissedWindow is set, see Client.OnMissedWindow
+	EventMissedWindow
+	//EventReviewDue is published for local domains passing their review date, Event.Review is set, see Client.OnReviewDue
+	EventReviewDue
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSyncStarted:
+		return "sync_started"
+	case EventSyncFinished:
+		return "sync_finished"
+	case EventUpdateApplied:
+		return "update_applied"
+	case EventFeedStarted:
+		return "feed_started"
+	case EventFeedStopped:
+		return "feed_stopped"
+	case EventAnomaly:
+		return "anomaly"
+	case EventMissedWindow:
+		return "missed_window"
+	case EventReviewDue:
+		return "review_due"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+//Event is something that happened in a Client, only the fields of its Kind are set
+type Event struct {
+	//Kind is what happened
+	Kind EventKind
+	//Time is when it happened
+	Time time.Time
+	//Op is the SyncOp of sync events, or SyncOpFeed for feed events
+	Op string
+	//Err is the error a sync failed or the feed stopped with
+	Err error
+	//Update is the update of EventUpdateApplied
+	Update *AppliedUpdate
+	//Anomaly is the anomaly of EventAnomaly
+	Anomaly *Anomaly
+	//MissedWindow is the missed window of EventMissedWindow
+	MissedWindow *MissedWindow
+	//Review is the local domain of EventReviewDue
+	Review *LocalReview
+}
+
+//subscriber is a function subscribed to events of a Client
+type subscriber struct {
+	fn    func(Event)
+	kinds map[EventKind]empty
+}
+
+//Subscribe registers fn to be called with every event of the given kinds published by Client, or all events if no kinds are given
+//it's the one place to observe the Client, the other hooks such as OnUpdate and OnAnomaly are called for the same events
+//fn is called while Client is locked, it must not block or call back into Client, see SubscribeChan for receiving events asynchronously
+//calling the returned function unregisters fn
+func (c *Client) Subscribe(fn func(Event), kinds ...EventKind) func() {
+	sub := subscriber{fn: fn}
+	if len(kinds) > 0 {
+		sub.kinds = map[EventKind]empty{}
+		for _, kind := range kinds {
+			sub.kinds[kind] = empty{}
+		}
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.subscribers == nil {
+		c.subscribers = map[int]subscriber{}
+	}
+	id := c.subscriberID
+	c.subscriberID++
+	c.subscribers[id] = sub
+	return func() {
+		c.m.Lock()
+		defer c.m.Unlock()
+		delete(c.subscribers, id)
+	}
+}
+
+//SubscribeChan returns a channel receiving the events of the given kinds published by Client, or all events if no kinds are given
+//the channel buffers size events, events published while the buffer is full are dropped so a slow receiver never stalls Client
+//the channel is closed once ctx is done
+func (c *Client) SubscribeChan(ctx context.Context, size int, kinds ...EventKind) <-chan Event {
+	ch := make(chan Event, size)
+	unsubscribe := c.Subscribe(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	}, kinds...)
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+		close(ch)
+	}()
+	return ch
+}
+
+//publish passes the event to all subscribers of its kind
+//should only be called when mutex is locked
+func (c *Client) publish(e Event) {
+	if len(c.subscribers) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, sub := range c.subscribers {
+		if sub.kinds != nil {
+			if _, ok := sub.kinds[e.Kind]; !ok {
+				continue
+			}
+		}
+		sub.fn(e)
+	}
+}
+
+//startSync publishes the start of a sync
+func (c *Client) startSync(op string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.publish(Event{Kind: EventSyncStarted, Op: op})
+}
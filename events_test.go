@@ -0,0 +1,116 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == endpointAll {
+			_, _ = w.Write([]byte(`["a.com","b.com"]`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	a := assert.New(t)
+	c := New(srv.URL, "test", http.Client{})
+	var all []Event
+	unsubscribe := c.Subscribe(func(e Event) {
+		all = append(all, e)
+	})
+	var syncs []Event
+	c.Subscribe(func(e Event) {
+		syncs = append(syncs, e)
+	}, EventSyncStarted, EventSyncFinished)
+
+	a.NoError(c.FullSync())
+	a.Len(all, 3)
+	a.Equal(EventSyncStarted, all[0].Kind)
+	a.Equal(SyncOpFullSync, all[0].Op)
+	a.Equal(EventUpdateApplied, all[1].Kind)
+	a.ElementsMatch([]string{"a.com", "b.com"}, all[1].Update.Update.Domains)
+	a.Equal(SourceFullSync, all[1].Update.Source)
+	a.Equal(EventSyncFinished, all[2].Kind)
+	a.NoError(all[2].Err)
+	for _, e := range all {
+		a.False(e.Time.IsZero())
+	}
+
+	unsubscribe()
+	a.Error(c.Update())
+	a.Len(all, 3)
+	a.Len(syncs, 4)
+	a.Equal(EventSyncStarted, syncs[2].Kind)
+	a.Equal(SyncOpUpdate, syncs[3].Op)
+	a.Error(syncs[3].Err)
+
+	c.Pause()
+	a.ErrorIs(c.FullSync(), ErrPaused)
+	a.Len(syncs, 6)
+	a.ErrorIs(syncs[5].Err, ErrPaused)
+	a.Equal(1, c.Stats().SyncFailures)
+}
+
+func TestSubscribeChan(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["a.com"]`))
+	}))
+	defer srv.Close()
+
+	a := assert.New(t)
+	c := New(srv.URL, "test", http.Client{})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := c.SubscribeChan(ctx, 1, EventUpdateApplied)
+
+	a.NoError(c.FullSync())
+	c.Reset()
+	select {
+	case e := <-ch:
+		a.Equal(EventUpdateApplied, e.Kind)
+		a.Equal(SourceFullSync, e.Update.Source)
+	case <-time.After(time.Second):
+		a.Fail("no event received")
+	}
+	select {
+	case e := <-ch:
+		a.Failf("event not dropped", "received %s", e.Kind)
+	default:
+	}
+
+	cancel()
+	for range ch {
+	}
+	c.m.Lock()
+	a.Empty(c.subscribers)
+	c.m.Unlock()
+}
+
+func TestSubscribeFeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	a := assert.New(t)
+	c := New(srv.URL, "test", http.Client{})
+	var events []Event
+	c.Subscribe(func(e Event) {
+		events = append(events, e)
+	}, EventFeedStarted, EventFeedStopped)
+
+	err := c.ListenForUpdates(context.Background())
+	a.Error(err)
+	if a.Len(events, 2) {
+		a.Equal(EventFeedStarted, events[0].Kind)
+		a.Equal(SyncOpFeed, events[0].Op)
+		a.Equal(EventFeedStopped, events[1].Kind)
+		a.Equal(err, events[1].Err)
+	}
+}
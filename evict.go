@@ -0,0 +1,173 @@
+package sinkingyachts
+
+import "sort"
+
+//evictQueue orders known domains from the oldest to the newest known, for evicting the oldest
+//removed domains are left in the queue and skipped once they reach the front, or pruned once they outnumber the known domains
+type evictQueue struct {
+	max     int
+	order   []queuedDomain
+	head    int
+	seq     map[string]uint64
+	next    uint64
+	evicted map[string]empty
+}
+
+//queuedDomain is a domain in the queue, it's stale if the domain has been removed or re-added since
+type queuedDomain struct {
+	domain string
+	seq    uint64
+}
+
+//newEvictQueue creates a queue of the domains, in sorted order as their age is unknown
+func newEvictQueue(max int, domains map[string]empty) *evictQueue {
+	q := &evictQueue{max: max, seq: make(map[string]uint64, len(domains)), evicted: map[string]empty{}}
+	sorted := make([]string, 0, len(domains))
+	for domain := range domains {
+		sorted = append(sorted, domain)
+	}
+	sort.Strings(sorted)
+	for _, domain := range sorted {
+		q.added(domain)
+	}
+	return q
+}
+
+//added queues a newly known domain, domains already known keep their place
+func (q *evictQueue) added(domain string) {
+	if _, ok := q.seq[domain]; ok {
+		return
+	}
+	delete(q.evicted, domain)
+	q.next++
+	q.seq[domain] = q.next
+	q.order = append(q.order, queuedDomain{domain: domain, seq: q.next})
+	q.prune()
+}
+
+//removed forgets a domain
+func (q *evictQueue) removed(domain string) {
+	delete(q.seq, domain)
+}
+
+//oldest removes and returns the oldest known domain
+func (q *evictQueue) oldest() (string, bool) {
+	for q.head < len(q.order) {
+		queued := q.order[q.head]
+		q.order[q.head] = queuedDomain{}
+		q.head++
+		if q.seq[queued.domain] == queued.seq {
+			delete(q.seq, queued.domain)
+			q.compact()
+			return queued.domain, true
+		}
+	}
+	q.compact()
+	return "", false
+}
+
+//compact drops the consumed front of the queue once it makes up most of it
+func (q *evictQueue) compact() {
+	if q.head < 1024 || q.head < len(q.order)/2 {
+		return
+	}
+	q.order = append([]queuedDomain(nil), q.order[q.head:]...)
+	q.head = 0
+}
+
+//prune drops the stale entries of the queue once they outnumber the known domains
+//so domains that keep getting added and removed without ever reaching the front don't grow the queue forever
+func (q *evictQueue) prune() {
+	queued := len(q.order) - q.head
+	if queued < 1024 || queued <= 2*len(q.seq) {
+		return
+	}
+	order := make([]queuedDomain, 0, len(q.seq))
+	for _, entry := range q.order[q.head:] {
+		if q.seq[entry.domain] == entry.seq {
+			order = append(order, entry)
+		}
+	}
+	q.order = order
+	q.head = 0
+}
+
+//withoutEvicted removes the domains that have been evicted from a set that replaces the known domains
+//so replacing the cache with the same list doesn't add the evicted domains back only to evict them again
+//evicted domains missing from the set are forgotten, as they're no longer listed
+func (q *evictQueue) withoutEvicted(dMap map[string]empty) {
+	evicted := make(map[string]empty, len(q.evicted))
+	for domain := range q.evicted {
+		if _, ok := dMap[domain]; ok {
+			delete(dMap, domain)
+			evicted[domain] = empty{}
+		}
+	}
+	q.evicted = evicted
+}
+
+//SetMaxEntries bounds the amount of known domains, for deployments with little memory such as routers
+//once the bound is exceeded the oldest known domains are evicted first, reported to OnUpdate as removals from SourceEviction
+//evicted domains are remembered until they're added by an update or no longer listed, so full syncs don't add them back
+//domains known before calling SetMaxEntries are considered older than any domain added afterwards
+//local domains are never evicted and don't count towards the bound, a max of 0 or less removes the bound
+func (c *Client) SetMaxEntries(max int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if max <= 0 {
+		c.evict = nil
+		return
+	}
+	c.evict = newEvictQueue(max, c.domains)
+	c.evictOverflow()
+}
+
+//trackEviction keeps the eviction queue in sync with an applied update
+//should only be called when mutex is locked
+func (c *Client) trackEviction(mod DomainUpdate) {
+	if c.evict == nil {
+		return
+	}
+	for _, domain := range mod.Domains {
+		if mod.Add {
+			c.evict.added(domain)
+		} else {
+			c.evict.removed(domain)
+		}
+	}
+}
+
+//evictOverflow evicts the oldest domains until the bound is met
+//should only be called when mutex is locked
+func (c *Client) evictOverflow() {
+	if c.evict == nil || len(c.domains) <= c.evict.max {
+		return
+	}
+	var evicted []string
+	for len(c.domains) > c.evict.max {
+		domain, ok := c.evict.oldest()
+		if !ok {
+			break
+		}
+		delete(c.domains, domain)
+		c.evict.evicted[domain] = empty{}
+		evicted = append(evicted, domain)
+	}
+	if len(evicted) == 0 {
+		return
+	}
+	c.evicted += uint64(len(evicted))
+	mod := DomainUpdate{Add: false, Domains: evicted}
+	c.trackMeta(mod, SourceEviction)
+	c.emit(mod, SourceEviction)
+}
+
+//resetEviction rebuilds the eviction queue after the cache got replaced
+//should only be called when mutex is locked
+func (c *Client) resetEviction() {
+	if c.evict == nil {
+		return
+	}
+	c.evict = newEvictQueue(c.evict.max, c.domains)
+	c.evictOverflow()
+}
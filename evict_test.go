@@ -0,0 +1,120 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMaxEntries(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com", "a.com"}}, SourceFeed)
+	c.AddLocal(0, "local.com")
+	var evicted []string
+	c.OnUpdate(func(au AppliedUpdate) {
+		if au.Source == SourceEviction {
+			evicted = append(evicted, au.Update.Domains...)
+		}
+	})
+
+	c.SetMaxEntries(3)
+	steps := []struct {
+		name    string
+		update  DomainUpdate
+		evicted []string
+		known   []string
+	}{
+		{"within bound", DomainUpdate{Add: true, Domains: []string{"c.com"}}, nil, []string{"a.com", "b.com", "c.com"}},
+		{"oldest first", DomainUpdate{Add: true, Domains: []string{"d.com", "e.com"}}, []string{"a.com", "b.com"}, []string{"c.com", "d.com", "e.com"}},
+		{"removed are skipped", DomainUpdate{Add: false, Domains: []string{"c.com"}}, nil, []string{"d.com", "e.com"}},
+		{"re-added keep their age", DomainUpdate{Add: true, Domains: []string{"d.com", "f.com", "g.com"}}, []string{"d.com"}, []string{"e.com", "f.com", "g.com"}},
+	}
+	for _, step := range steps {
+		evicted = nil
+		c.applyLiveUpdates(step.update, SourceFeed)
+		a.Equal(step.evicted, evicted, step.name)
+		a.ElementsMatch(step.known, c.Domains(), step.name)
+		a.True(c.Check("local.com"), step.name)
+	}
+	a.Equal(uint64(3), c.Stats().Evicted)
+
+	a.NoError(ReadCacheFrom(c, strings.NewReader(`{"domains":["1.com","2.com","3.com","4.com"]}`)))
+	a.Equal(3, c.Size())
+	a.False(c.Check("1.com"))
+
+	c.SetMaxEntries(0)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"5.com", "6.com"}}, SourceFeed)
+	a.Equal(5, c.Size())
+}
+
+func TestMaxEntriesFullSync(t *testing.T) {
+	a := assert.New(t)
+	var m sync.Mutex
+	list := `["a.com","b.com","c.com","d.com","e.com"]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		_, _ = w.Write([]byte(list))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test", http.Client{})
+	c.SetMaxEntries(3)
+	var updates []AppliedUpdate
+	c.OnUpdate(func(au AppliedUpdate) {
+		updates = append(updates, au)
+	})
+
+	a.NoError(c.FullSync())
+	a.Equal(3, c.Size())
+	a.NotEmpty(updates)
+	known := c.Domains()
+
+	updates = nil
+	a.NoError(c.FullSync())
+	a.Empty(updates, "evicted domains aren't added back by the same list")
+	a.ElementsMatch(known, c.Domains())
+	a.Equal(uint64(2), c.Stats().Evicted)
+
+	//evicted domains that are no longer listed are forgotten, and added like any other domain once listed again
+	m.Lock()
+	list = `["c.com","d.com","e.com"]`
+	m.Unlock()
+	a.NoError(c.FullSync())
+	m.Lock()
+	list = `["a.com","c.com","d.com","e.com"]`
+	m.Unlock()
+	updates = nil
+	a.NoError(c.FullSync())
+	a.True(c.Check("a.com"))
+	a.Equal(3, c.Size())
+	if a.Len(updates, 2) {
+		a.Equal(SourceFullSync, updates[0].Source)
+		a.Equal(SourceEviction, updates[1].Source)
+	}
+}
+
+func TestEvictQueuePrune(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	c.SetMaxEntries(10)
+	//domains churning below the bound are never evicted, so their stale entries never reach the front
+	for i := 0; i < 10000; i++ {
+		domain := strconv.Itoa(i) + ".com"
+		c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{domain}}, SourceFeed)
+		c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{domain}}, SourceFeed)
+	}
+	c.m.Lock()
+	a.LessOrEqual(len(c.evict.order)-c.evict.head, 1024)
+	c.m.Unlock()
+
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"1.com", "2.com", "3.com", "4.com", "5.com", "6.com", "7.com", "8.com", "9.com"}}, SourceFeed)
+	a.False(c.Check("a.com"), "the oldest known domain is still evicted first")
+	a.True(c.Check("b.com"))
+	a.Equal(10, c.Size())
+}
@@ -0,0 +1,121 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"sort"
+	"text/template"
+	"time"
+)
+
+//ExportEntry is a domain being exported
+type ExportEntry struct {
+	//Domain is the phishing domain
+	Domain string
+	//Metadata is the metadata of the domain, nil if unknown or metadata is not enabled
+	Metadata *Metadata
+}
+
+//ExportSet is a snapshot of a Client's known domains for exporting
+type ExportSet struct {
+	//Entries are the known domains sorted by domain
+	Entries []ExportEntry
+	//LastUpdated is when the cache was last updated
+	LastUpdated time.Time
+}
+
+//Exporter writes an ExportSet in some format, such as plain text or csv
+type Exporter interface {
+	Export(w io.Writer, set ExportSet) error
+}
+
+//ExporterFunc is a function that implements Exporter
+type ExporterFunc func(w io.Writer, set ExportSet) error
+
+//Export calls f(w, set)
+func (f ExporterFunc) Export(w io.Writer, set ExportSet) error {
+	return f(w, set)
+}
+
+//ExportText writes a domain per line
+var ExportText Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	bw := bufio.NewWriter(w)
+	for _, entry := range set.Entries {
+		_, _ = bw.WriteString(entry.Domain)
+		_ = bw.WriteByte('\n')
+	}
+	return bw.Flush()
+})
+
+//ExportCSV writes csv with a header of domain, added_at, source and category
+//added_at is formatted as RFC 3339, metadata columns are empty when unknown
+var ExportCSV Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"domain", "added_at", "source", "category"})
+	for _, entry := range set.Entries {
+		row := []string{entry.Domain, "", "", ""}
+		if md := entry.Metadata; md != nil {
+			if !md.AddedAt.IsZero() {
+				row[1] = md.AddedAt.UTC().Format(time.RFC3339)
+			}
+			row[2], row[3] = string(md.Source), md.Category
+		}
+		_ = cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+})
+
+//WriteExport exports the Client's known domains into the writer with Exporter
+func WriteExport(c *Client, w io.Writer, e Exporter) error {
+	return e.Export(w, c.exportSet())
+}
+
+//exportSet snapshots the known domains for exporting
+func (c *Client) exportSet() ExportSet {
+	c.m.Lock()
+	set := ExportSet{
+		Entries:     make([]ExportEntry, 0, len(c.domains)),
+		LastUpdated: c.lastUpdated,
+	}
+	for domain := range c.domains {
+		entry := ExportEntry{Domain: domain}
+		if md, ok := c.meta[domain]; ok {
+			entry.Metadata = &md
+		}
+		set.Entries = append(set.Entries, entry)
+	}
+	c.m.Unlock()
+	sort.Slice(set.Entries, func(i, j int) bool {
+		return set.Entries[i].Domain < set.Entries[j].Domain
+	})
+	return set
+}
+
+//TemplateData is the data templates are executed with by TemplateExporter
+type TemplateData struct {
+	//Count is the amount of domains
+	Count int
+	//LastUpdated is when the cache was last updated
+	LastUpdated time.Time
+	//Entries are the domains sorted by domain, with their metadata if known
+	Entries []ExportEntry
+}
+
+//TemplateExporter creates an Exporter executing tmpl with TemplateData, so any format can be produced without new code
+//for example {{range .Entries}}0.0.0.0 {{.Domain}}{{"\n"}}{{end}} produces a hosts file
+func TemplateExporter(tmpl *template.Template) Exporter {
+	return ExporterFunc(func(w io.Writer, set ExportSet) error {
+		return tmpl.Execute(w, TemplateData{
+			Count:       len(set.Entries),
+			LastUpdated: set.LastUpdated,
+			Entries:     set.Entries,
+		})
+	})
+}
+
+//Export renders the known domains through tmpl, see TemplateExporter
+func (c *Client) Export(w io.Writer, tmpl *template.Template) error {
+	return WriteExport(c, w, TemplateExporter(tmpl))
+}
@@ -0,0 +1,53 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestExporters(t *testing.T) {
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com"}}, SourceFeed)
+	c.EnableMetadata()
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}, Category: "scam"}, SourceWebhook)
+	c.m.Lock()
+	md := c.meta["a.com"]
+	md.AddedAt = time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	c.meta["a.com"] = md
+	c.m.Unlock()
+
+	tests := []struct {
+		name     string
+		exporter Exporter
+		want     string
+	}{
+		{name: "text", exporter: ExportText, want: "a.com\nb.com\n"},
+		{name: "csv", exporter: ExportCSV, want: "domain,added_at,source,category\na.com,2022-04-01T12:00:00Z,webhook,scam\nb.com,,,\n"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			var buf bytes.Buffer
+			a.NoError(WriteExport(c, &buf, data.exporter))
+			a.Equal(data.want, buf.String())
+		})
+	}
+}
+
+func TestClientExport(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com", "a.com"}}, SourceFeed)
+	c.lastUpdated = time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tmpl := template.Must(template.New("hosts").Parse(`# {{.Count}} domains as of {{.LastUpdated.Format "2006-01-02"}}
+{{range .Entries}}0.0.0.0 {{.Domain}}{{if .Metadata}} # {{.Metadata.Category}}{{end}}
+{{end}}`))
+	var buf bytes.Buffer
+	a.NoError(c.Export(&buf, tmpl))
+	a.Equal("# 2 domains as of 2022-04-01\n0.0.0.0 a.com\n0.0.0.0 b.com\n", buf.String())
+}
@@ -0,0 +1,120 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+//FeedProtocol is the frame format of the websocket feed
+type FeedProtocol int
+
+const (
+	//FeedProtocolAuto negotiates the protocol with the server, and detects it from every frame if the server doesn't negotiate
+	//this is the default, it keeps working when the server moves to enveloped frames
+	FeedProtocolAuto FeedProtocol = iota
+	//FeedProtocolV1 is the current format, where every frame is a single update
+	FeedProtocolV1
+	//FeedProtocolV2 is the enveloped format, where every frame is a versioned envelope of a kind, carrying any amount of updates
+	//frames without a version, or with a version newer than understood, are rejected with an error
+	FeedProtocolV2
+)
+
+//websocket subprotocols the feed protocols are negotiated as
+const (
+	subprotocolV1 = "sinkingyachts.v1"
+	subprotocolV2 = "sinkingyachts.v2"
+)
+
+//envelopeUpdates is the kind of envelope carrying updates, envelopes of other kinds are skipped
+const envelopeUpdates = "updates"
+
+//envelopeVersion is the newest envelope version understood, newer envelopes may change their meaning and are rejected
+const envelopeVersion = 2
+
+//envelope is a frame of FeedProtocolV2
+type envelope struct {
+	//Version is the protocol version the frame was written in
+	Version int `json:"version"`
+	//Kind is what the envelope carries, defaults to updates
+	Kind string `json:"kind,omitempty"`
+	//Updates are the updates carried by the envelope
+	Updates []DomainUpdate `json:"updates,omitempty"`
+}
+
+//WithFeedProtocol sets the frame format of the feed, defaults to FeedProtocolAuto
+//it only needs to be set to pin a protocol, such as for a server that can't be detected correctly
+func WithFeedProtocol(protocol FeedProtocol) Option {
+	return func(client *RawClient) {
+		client.feedProtocol = protocol
+	}
+}
+
+//codecs returns the codecs offered when dialing the feed, in order of preference
+func (p FeedProtocol) codecs() []Codec {
+	switch p {
+	case FeedProtocolV1:
+		return nil
+	case FeedProtocolV2:
+		return []Codec{EnvelopeCodec}
+	default:
+		return []Codec{EnvelopeCodec, JSONCodec}
+	}
+}
+
+//decodeFrame decodes the updates of a feed frame in the protocol, FeedProtocolAuto detects the protocol of the frame
+//envelopes of unknown kinds carry no updates, so servers can add kinds of frames without breaking older clients
+func (p FeedProtocol) decodeFrame(data []byte) ([]DomainUpdate, error) {
+	if p == FeedProtocolAuto {
+		p = detectProtocol(data)
+	}
+	switch p {
+	case FeedProtocolV1:
+		var mod DomainUpdate
+		err := json.Unmarshal(data, &mod)
+		if err != nil {
+			return nil, err
+		}
+		return []DomainUpdate{mod}, nil
+	case FeedProtocolV2:
+		var env envelope
+		err := json.Unmarshal(data, &env)
+		if err != nil {
+			return nil, err
+		}
+		if err = checkEnvelopeVersion(env.Version); err != nil {
+			return nil, err
+		}
+		if env.Kind != "" && env.Kind != envelopeUpdates {
+			return nil, nil
+		}
+		return env.Updates, nil
+	default:
+		return nil, fmt.Errorf("unknown feed protocol %d", p)
+	}
+}
+
+//checkEnvelopeVersion checks that an envelope's version is understood
+//a missing version is most likely a single update of FeedProtocolV1, which would otherwise silently decode as no updates
+func checkEnvelopeVersion(version int) error {
+	if version <= 0 {
+		return errors.New("feed frame is not an envelope, the server may not support feed protocol v2")
+	}
+	if version > envelopeVersion {
+		return fmt.Errorf("unsupported feed envelope version %d, newest supported is %d", version, envelopeVersion)
+	}
+	return nil
+}
+
+//detectProtocol detects the protocol of a frame, envelopes are told apart from single updates by their version field
+func detectProtocol(data []byte) FeedProtocol {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimSpace(data), &fields); err != nil {
+		return FeedProtocolV1
+	}
+	if _, ok := fields["version"]; ok {
+		return FeedProtocolV2
+	}
+	return FeedProtocolV1
+}
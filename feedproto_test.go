@@ -0,0 +1,75 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"nhooyr.io/websocket"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol FeedProtocol
+		frame    string
+		updates  []DomainUpdate
+		err      bool
+	}{
+		{name: "v1", protocol: FeedProtocolV1, frame: `{"type":"add","domains":["a.com"]}`, updates: []DomainUpdate{{Add: true, Domains: []string{"a.com"}}}},
+		{name: "v2", protocol: FeedProtocolV2, frame: `{"version":2,"kind":"updates","updates":[{"type":"add","domains":["a.com"]},{"type":"delete","domains":["b.com"]}]}`,
+			updates: []DomainUpdate{{Add: true, Domains: []string{"a.com"}}, {Add: false, Domains: []string{"b.com"}}}},
+		{name: "v2 unknown kind", protocol: FeedProtocolV2, frame: `{"version":2,"kind":"notice","message":"maintenance"}`},
+		{name: "v2 extra fields", protocol: FeedProtocolV2, frame: `{"version":2,"updates":[{"type":"add","domains":["a.com"]}],"extra":true}`,
+			updates: []DomainUpdate{{Add: true, Domains: []string{"a.com"}}}},
+		{name: "v2 future version", protocol: FeedProtocolV2, frame: `{"version":3,"updates":[{"type":"add","domains":["a.com"]}]}`, err: true},
+		{name: "v1 as v2", protocol: FeedProtocolV2, frame: `{"type":"add","domains":["a.com"]}`, err: true},
+		{name: "v2 zero version", protocol: FeedProtocolV2, frame: `{"version":0,"updates":[]}`, err: true},
+		{name: "auto v1", protocol: FeedProtocolAuto, frame: `{"type":"add","domains":["a.com"]}`, updates: []DomainUpdate{{Add: true, Domains: []string{"a.com"}}}},
+		{name: "auto future version", protocol: FeedProtocolAuto, frame: `{"version":3,"updates":[]}`, err: true},
+		{name: "auto v2", protocol: FeedProtocolAuto, frame: `{"version":2,"updates":[{"type":"add","domains":["a.com"]}]}`, updates: []DomainUpdate{{Add: true, Domains: []string{"a.com"}}}},
+		{name: "v1 invalid", protocol: FeedProtocolV1, frame: `{"type":"rename","domains":["a.com"]}`, err: true},
+		{name: "v2 as v1", protocol: FeedProtocolV1, frame: `{"version":2,"updates":[]}`, err: true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			updates, err := data.protocol.decodeFrame([]byte(data.frame))
+			if data.err {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(data.updates, updates)
+		})
+	}
+}
+
+func TestFeedProtocolNegotiation(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{subprotocolV2}})
+		if err != nil {
+			return
+		}
+		defer cn.Close(websocket.StatusNormalClosure, "")
+		a.Equal(subprotocolV2, cn.Subprotocol())
+		ctx := cn.CloseRead(r.Context())
+		_ = cn.Write(ctx, websocket.MessageText, []byte(`{"version":2,"kind":"hello"}`))
+		_ = cn.Write(ctx, websocket.MessageText, []byte(`{"version":2,"updates":[{"type":"add","domains":["a.com","b.com"]},{"type":"delete","domains":["b.com"]}]}`))
+		<-ctx.Done()
+	}))
+	defer srv.Close()
+
+	c := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = c.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return c.Check("a.com") && !c.Check("b.com") }, time.Second, time.Millisecond*10)
+	a.Equal(uint64(2), c.Stats().FeedMessages)
+}
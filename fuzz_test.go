@@ -0,0 +1,86 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func FuzzDomainUpdate(f *testing.F) {
+	f.Add([]byte(`{"type":"add","domains":["a.com","b.com"]}`))
+	f.Add([]byte(`{"type":"delete","domains":[]}`))
+	f.Add([]byte(`{"type":"add","domains":null}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var mod DomainUpdate
+		if err := json.Unmarshal(data, &mod); err != nil {
+			return
+		}
+		b, err := json.Marshal(mod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var again DomainUpdate
+		if err = json.Unmarshal(b, &again); err != nil {
+			t.Fatalf("failed to unmarshal marshaled update %s: %v", b, err)
+		}
+		if again.Add != mod.Add || len(again.Domains) != len(mod.Domains) {
+			t.Fatalf("round trip mismatch %+v != %+v", mod, again)
+		}
+	})
+}
+
+func FuzzWebhookPayload(f *testing.F) {
+	f.Add([]byte(`[{"type":"add","domains":["a.com"]},{"type":"delete","domains":["a.com"]}]`))
+	f.Add([]byte(` {"type":"add","domains":["a.com"]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = parseWebhook(data)
+	})
+}
+
+func FuzzCacheJSON(f *testing.F) {
+	f.Add([]byte(`{"last_updated":"2022-04-01T00:00:00Z","domains":["a.com"]}`))
+	f.Add([]byte(`{"domains":null}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var c Client
+		_ = c.UnmarshalJSON(data)
+	})
+}
+
+func FuzzCacheMsgpack(f *testing.F) {
+	var c Client
+	c.fromSave(save{Domains: []string{"a.com", "b.com"}})
+	b, _ := c.MarshalMsgpack()
+	f.Add(b)
+	f.Add([]byte{0x82, 0xa7, 'd', 'o', 'm', 'a', 'i', 'n', 's', 0xc0, 0xac, 'l', 'a', 's', 't', '_', 'u', 'p', 'd', 'a', 't', 'e', 'd', 0xd6, 0xff, 0, 0, 0, 1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var c Client
+		if err := c.UnmarshalMsgpack(data); err != nil {
+			return
+		}
+		b, err := c.MarshalMsgpack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.UnmarshalMsgpack(b); err != nil {
+			t.Fatalf("failed to unmarshal marshaled cache: %v", err)
+		}
+	})
+}
+
+func FuzzDomain(f *testing.F) {
+	f.Add("foo.bar.example.com")
+	f.Add("foo..example.com")
+	f.Add("https://example.com/")
+	f.Fuzz(func(t *testing.T, domain string) {
+		valid := ValidateDomain(domain) == nil
+		variants := GenerateVariants(domain)
+		if valid && len(variants) != strings.Count(domain, ".") {
+			t.Fatalf("expected %d variants of %q, got %d", strings.Count(domain, "."), domain, len(variants))
+		}
+		for _, v := range variants {
+			if !strings.HasSuffix(domain, v) {
+				t.Fatalf("variant %q is not a suffix of %q", v, domain)
+			}
+		}
+	})
+}
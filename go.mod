@@ -3,7 +3,11 @@ module github.com/thunder33345/sinkingyachts
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/sys v0.0.0-20220908164124-27713097b956
+	gopkg.in/yaml.v3 v3.0.1
 	nhooyr.io/websocket v1.8.7
 )
 
@@ -12,8 +16,6 @@ require (
 	github.com/klauspost/compress v1.15.1 // indirect
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20210809222454-d867a43fc93e // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,80 @@
+package yachtsgrpc
+
+import (
+	"context"
+	"errors"
+	"github.com/thunder33345/sinkingyachts"
+	"github.com/thunder33345/sinkingyachts/grpc/yachtspb"
+	"google.golang.org/grpc"
+	"io"
+)
+
+//Client queries a Server, it wraps the generated yachtspb.SinkingYachtsClient with the types of sinkingyachts
+type Client struct {
+	rpc yachtspb.SinkingYachtsClient
+}
+
+//NewClient creates a Client calling the Server over cc, such as a *grpc.ClientConn
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{rpc: yachtspb.NewSinkingYachtsClient(cc)}
+}
+
+//Check checks if the domain is phishing, see sinkingyachts.Client.Check
+func (c *Client) Check(ctx context.Context, domain string) (bool, error) {
+	resp, err := c.rpc.Check(ctx, &yachtspb.CheckRequest{Domain: domain})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetPhishing(), nil
+}
+
+//FuzzyCheck checks if the domain or any of its parent domains is phishing, see sinkingyachts.Client.FuzzyCheck
+func (c *Client) FuzzyCheck(ctx context.Context, domain string) (bool, error) {
+	resp, err := c.rpc.FuzzyCheck(ctx, &yachtspb.CheckRequest{Domain: domain})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetPhishing(), nil
+}
+
+//ListDomains returns every domain known to the Server
+func (c *Client) ListDomains(ctx context.Context) ([]string, error) {
+	stream, err := c.rpc.ListDomains(ctx, &yachtspb.ListDomainsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var domains []string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return domains, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, resp.GetDomains()...)
+	}
+}
+
+//StreamUpdates streams updates applied to the Server's Client into modFeed
+//this function blocks and returns only when cancelled by ctx, or when the stream fails
+func (c *Client) StreamUpdates(ctx context.Context, modFeed chan<- sinkingyachts.DomainUpdate) error {
+	stream, err := c.rpc.StreamUpdates(ctx, &yachtspb.StreamUpdatesRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		u, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case modFeed <- fromProto(u):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
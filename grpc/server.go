@@ -0,0 +1,131 @@
+//Package yachtsgrpc serves a synced sinkingyachts.Client over gRPC, so services in other languages can use its cache
+//the service is defined in yachtspb/sinkingyachts.proto, generate clients for other languages from it
+package yachtsgrpc
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative yachtspb/sinkingyachts.proto
+
+import (
+	"context"
+	"github.com/thunder33345/sinkingyachts"
+	"github.com/thunder33345/sinkingyachts/grpc/yachtspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//listBatch is the amount of domains sent per message of ListDomains
+const listBatch = 1000
+
+//streamBuffer is the amount of updates queued per StreamUpdates call before it's ended
+const streamBuffer = 256
+
+//Server implements yachtspb.SinkingYachtsServer backed by a Client
+//checks are answered from the Client's cache, the Client has to be kept synced separately, such as with AutoSync
+type Server struct {
+	yachtspb.UnimplementedSinkingYachtsServer
+	c *sinkingyachts.Client
+}
+
+//NewServer creates a Server answering from c
+func NewServer(c *sinkingyachts.Client) *Server {
+	return &Server{c: c}
+}
+
+//Register registers a Server answering from c on s
+func Register(s grpc.ServiceRegistrar, c *sinkingyachts.Client) {
+	yachtspb.RegisterSinkingYachtsServer(s, NewServer(c))
+}
+
+//Check checks the domain like Client.Check
+func (s *Server) Check(ctx context.Context, req *yachtspb.CheckRequest) (*yachtspb.CheckResponse, error) {
+	return s.lookup(req.GetDomain(), false)
+}
+
+//FuzzyCheck checks the domain like Client.FuzzyCheck
+func (s *Server) FuzzyCheck(ctx context.Context, req *yachtspb.CheckRequest) (*yachtspb.CheckResponse, error) {
+	return s.lookup(req.GetDomain(), true)
+}
+
+//lookup looks up the domain, returning an InvalidArgument error if it's empty
+func (s *Server) lookup(domain string, fuzzy bool) (*yachtspb.CheckResponse, error) {
+	if domain == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing domain")
+	}
+	m := s.c.Lookup(domain, sinkingyachts.CheckOpts{Fuzzy: fuzzy})
+	return &yachtspb.CheckResponse{Phishing: m.Phishing(), Matched: m.Matched}, nil
+}
+
+//ListDomains streams every known domain in batches of 1000
+func (s *Server) ListDomains(req *yachtspb.ListDomainsRequest, stream grpc.ServerStreamingServer[yachtspb.ListDomainsResponse]) error {
+	domains := s.c.Domains()
+	for len(domains) > 0 {
+		n := listBatch
+		if n > len(domains) {
+			n = len(domains)
+		}
+		err := stream.Send(&yachtspb.ListDomainsResponse{Domains: domains[:n]})
+		if err != nil {
+			return err
+		}
+		domains = domains[n:]
+	}
+	return nil
+}
+
+//StreamUpdates streams every update applied to the Client until the call is cancelled
+//a caller that falls behind by more than 256 updates is ended with ResourceExhausted, without slowing down the Client
+func (s *Server) StreamUpdates(req *yachtspb.StreamUpdatesRequest, stream grpc.ServerStreamingServer[yachtspb.DomainUpdate]) error {
+	updates := make(chan sinkingyachts.DomainUpdate, streamBuffer)
+	behind := make(chan struct{})
+	remove := s.c.OnUpdate(func(au sinkingyachts.AppliedUpdate) {
+		select {
+		case <-behind:
+		case updates <- au.Update:
+		default:
+			close(behind)
+		}
+	})
+	defer remove()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-behind:
+			return status.Error(codes.ResourceExhausted, "fell behind")
+		case mod := <-updates:
+			err := stream.Send(toProto(mod))
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//toProto converts an update to its message
+func toProto(mod sinkingyachts.DomainUpdate) *yachtspb.DomainUpdate {
+	u := &yachtspb.DomainUpdate{
+		Add:      mod.Add,
+		Domains:  mod.Domains,
+		Category: mod.Category,
+		Origin:   mod.Origin,
+	}
+	if !mod.Time.IsZero() {
+		u.Time = timestamppb.New(mod.Time)
+	}
+	return u
+}
+
+//fromProto converts a message to an update
+func fromProto(u *yachtspb.DomainUpdate) sinkingyachts.DomainUpdate {
+	mod := sinkingyachts.DomainUpdate{
+		Add:      u.GetAdd(),
+		Domains:  u.GetDomains(),
+		Category: u.GetCategory(),
+		Origin:   u.GetOrigin(),
+	}
+	if u.GetTime() != nil {
+		mod.Time = u.GetTime().AsTime()
+	}
+	return mod
+}
@@ -0,0 +1,191 @@
+package yachtsgrpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/thunder33345/sinkingyachts"
+	"github.com/thunder33345/sinkingyachts/grpc/yachtspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//serve serves c over an in memory listener, and returns a Client connected to it
+func serve(t *testing.T, c *sinkingyachts.Client) *Client {
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, c)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cc.Close()
+	})
+	return NewClient(cc)
+}
+
+func TestServer(t *testing.T) {
+	a := assert.New(t)
+	c := sinkingyachts.New("", "test", http.Client{})
+	c.ApplyUpdates([]sinkingyachts.DomainUpdate{{Add: true, Domains: []string{"bad.com"}}}, sinkingyachts.SourceFeed)
+	client := serve(t, c)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		check  func(context.Context, string) (bool, error)
+		domain string
+		want   bool
+	}{
+		{name: "check", check: client.Check, domain: "bad.com", want: true},
+		{name: "check subdomain", check: client.Check, domain: "sub.bad.com", want: false},
+		{name: "check unknown", check: client.Check, domain: "good.com", want: false},
+		{name: "fuzzy check subdomain", check: client.FuzzyCheck, domain: "sub.bad.com", want: true},
+		{name: "fuzzy check unknown", check: client.FuzzyCheck, domain: "sub.good.com", want: false},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			phishing, err := data.check(ctx, data.domain)
+			assert.NoError(t, err)
+			assert.Equal(t, data.want, phishing)
+		})
+	}
+	_, err := client.Check(ctx, "")
+	a.Equal(codes.InvalidArgument, status.Code(err))
+
+	c.SetDryRun(true, nil)
+	phishing, err := client.Check(ctx, "bad.com")
+	a.NoError(err)
+	a.False(phishing, "dry run hits aren't phishing")
+	c.SetDryRun(false, nil)
+}
+
+func TestServerListDomains(t *testing.T) {
+	a := assert.New(t)
+	c := sinkingyachts.New("", "test", http.Client{})
+	client := serve(t, c)
+	domains, err := client.ListDomains(context.Background())
+	a.NoError(err)
+	a.Empty(domains)
+
+	var all []string
+	for i := 0; i < listBatch*2+1; i++ {
+		all = append(all, strconv.Itoa(i)+".com")
+	}
+	c.ApplyUpdates([]sinkingyachts.DomainUpdate{{Add: true, Domains: all}}, sinkingyachts.SourceFeed)
+	domains, err = client.ListDomains(context.Background())
+	a.NoError(err)
+	a.ElementsMatch(all, domains)
+}
+
+func TestServerStreamUpdates(t *testing.T) {
+	a := assert.New(t)
+	c := sinkingyachts.New("", "test", http.Client{})
+	client := serve(t, c)
+	ctx, cancel := context.WithCancel(context.Background())
+	modFeed := make(chan sinkingyachts.DomainUpdate, 16)
+	done := make(chan error)
+	go func() {
+		done <- client.StreamUpdates(ctx, modFeed)
+	}()
+
+	//updates applied before the call is subscribed aren't streamed, so probe until one arrives
+	probe := func(i int) sinkingyachts.DomainUpdate {
+		return sinkingyachts.DomainUpdate{Add: true, Domains: []string{"probe" + strconv.Itoa(i) + ".com"}}
+	}
+	probes := 0
+	a.Eventually(func() bool {
+		c.ApplyUpdates([]sinkingyachts.DomainUpdate{probe(probes)}, sinkingyachts.SourceFeed)
+		probes++
+		select {
+		case <-modFeed:
+			return true
+		case <-time.After(time.Millisecond * 10):
+			return false
+		}
+	}, time.Second, time.Millisecond)
+
+	at := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	mods := []sinkingyachts.DomainUpdate{
+		{Add: true, Domains: []string{"a.com"}, Category: "scam", Time: at, Origin: "test"},
+		{Add: false, Domains: []string{"a.com"}},
+	}
+	c.ApplyUpdates(mods, sinkingyachts.SourceFeed)
+	var streamed []sinkingyachts.DomainUpdate
+	for len(streamed) < len(mods) {
+		mod := <-modFeed
+		if !strings.HasPrefix(mod.Domains[0], "probe") {
+			streamed = append(streamed, mod)
+		}
+	}
+	a.Equal(mods, streamed)
+
+	cancel()
+	a.NoError(<-done)
+}
+
+//blockedStream is a stream of StreamUpdates that blocks sending until released
+type blockedStream struct {
+	grpc.ServerStream
+	subscribed chan struct{}
+	once       sync.Once
+	sending    chan struct{}
+	release    chan struct{}
+}
+
+//Context signals subscribed, as StreamUpdates only waits on it once subscribed
+func (s *blockedStream) Context() context.Context {
+	s.once.Do(func() {
+		close(s.subscribed)
+	})
+	return context.Background()
+}
+
+func (s *blockedStream) Send(*yachtspb.DomainUpdate) error {
+	select {
+	case s.sending <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return nil
+}
+
+func TestServerStreamUpdatesBehind(t *testing.T) {
+	c := sinkingyachts.New("", "test", http.Client{})
+	stream := &blockedStream{subscribed: make(chan struct{}), sending: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- NewServer(c).StreamUpdates(&yachtspb.StreamUpdatesRequest{}, stream)
+	}()
+	<-stream.subscribed
+	apply := func(i int) {
+		c.ApplyUpdates([]sinkingyachts.DomainUpdate{{Add: true, Domains: []string{strconv.Itoa(i) + ".com"}}}, sinkingyachts.SourceFeed)
+	}
+	//the first update blocks the stream, the rest fill up the buffer until it overflows
+	apply(0)
+	<-stream.sending
+	for i := 1; i <= streamBuffer+1; i++ {
+		apply(i)
+	}
+	close(stream.release)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(<-done))
+}
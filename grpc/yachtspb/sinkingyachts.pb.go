@@ -0,0 +1,411 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: yachtspb/sinkingyachts.proto
+
+package yachtspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CheckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Domain is the domain to check.
+	Domain        string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type CheckResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Phishing is whether the domain is phishing.
+	Phishing bool `protobuf:"varint,1,opt,name=phishing,proto3" json:"phishing,omitempty"`
+	// Matched is the domain that matched, it differs from the checked domain for fuzzy checks.
+	// It is empty if nothing matched, and set for matches observed in dry run mode even though they aren't phishing.
+	Matched       string `protobuf:"bytes,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckResponse) GetPhishing() bool {
+	if x != nil {
+		return x.Phishing
+	}
+	return false
+}
+
+func (x *CheckResponse) GetMatched() string {
+	if x != nil {
+		return x.Matched
+	}
+	return ""
+}
+
+type ListDomainsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsRequest) Reset() {
+	*x = ListDomainsRequest{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsRequest) ProtoMessage() {}
+
+func (x *ListDomainsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsRequest.ProtoReflect.Descriptor instead.
+func (*ListDomainsRequest) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{2}
+}
+
+type ListDomainsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Domains is a batch of known domains.
+	Domains       []string `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsResponse) Reset() {
+	*x = ListDomainsResponse{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsResponse) ProtoMessage() {}
+
+func (x *ListDomainsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsResponse.ProtoReflect.Descriptor instead.
+func (*ListDomainsResponse) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListDomainsResponse) GetDomains() []string {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+type StreamUpdatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamUpdatesRequest) Reset() {
+	*x = StreamUpdatesRequest{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamUpdatesRequest) ProtoMessage() {}
+
+func (x *StreamUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{4}
+}
+
+type DomainUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Add is whether the domains were added, they were removed otherwise.
+	Add bool `protobuf:"varint,1,opt,name=add,proto3" json:"add,omitempty"`
+	// Domains are the domains of the update.
+	Domains []string `protobuf:"bytes,2,rep,name=domains,proto3" json:"domains,omitempty"`
+	// Category is the category of the domains, it is empty if the source doesn't provide one.
+	Category string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	// Time is when the update was made upstream, it is unset if the source doesn't provide it.
+	Time *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	// Origin is who or what made the update upstream, it is empty unless provided by the source.
+	Origin        string `protobuf:"bytes,5,opt,name=origin,proto3" json:"origin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DomainUpdate) Reset() {
+	*x = DomainUpdate{}
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DomainUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DomainUpdate) ProtoMessage() {}
+
+func (x *DomainUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_yachtspb_sinkingyachts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DomainUpdate.ProtoReflect.Descriptor instead.
+func (*DomainUpdate) Descriptor() ([]byte, []int) {
+	return file_yachtspb_sinkingyachts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DomainUpdate) GetAdd() bool {
+	if x != nil {
+		return x.Add
+	}
+	return false
+}
+
+func (x *DomainUpdate) GetDomains() []string {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *DomainUpdate) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *DomainUpdate) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *DomainUpdate) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+var File_yachtspb_sinkingyachts_proto protoreflect.FileDescriptor
+
+const file_yachtspb_sinkingyachts_proto_rawDesc = "" +
+	"\n" +
+	"\x1cyachtspb/sinkingyachts.proto\x12\x10sinkingyachts.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"&\n" +
+	"\fCheckRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\"E\n" +
+	"\rCheckResponse\x12\x1a\n" +
+	"\bphishing\x18\x01 \x01(\bR\bphishing\x12\x18\n" +
+	"\amatched\x18\x02 \x01(\tR\amatched\"\x14\n" +
+	"\x12ListDomainsRequest\"/\n" +
+	"\x13ListDomainsResponse\x12\x18\n" +
+	"\adomains\x18\x01 \x03(\tR\adomains\"\x16\n" +
+	"\x14StreamUpdatesRequest\"\x9e\x01\n" +
+	"\fDomainUpdate\x12\x10\n" +
+	"\x03add\x18\x01 \x01(\bR\x03add\x12\x18\n" +
+	"\adomains\x18\x02 \x03(\tR\adomains\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x16\n" +
+	"\x06origin\x18\x05 \x01(\tR\x06origin2\xe1\x02\n" +
+	"\rSinkingYachts\x12H\n" +
+	"\x05Check\x12\x1e.sinkingyachts.v1.CheckRequest\x1a\x1f.sinkingyachts.v1.CheckResponse\x12M\n" +
+	"\n" +
+	"FuzzyCheck\x12\x1e.sinkingyachts.v1.CheckRequest\x1a\x1f.sinkingyachts.v1.CheckResponse\x12\\\n" +
+	"\vListDomains\x12$.sinkingyachts.v1.ListDomainsRequest\x1a%.sinkingyachts.v1.ListDomainsResponse0\x01\x12Y\n" +
+	"\rStreamUpdates\x12&.sinkingyachts.v1.StreamUpdatesRequest\x1a\x1e.sinkingyachts.v1.DomainUpdate0\x01B5Z3github.com/thunder33345/sinkingyachts/grpc/yachtspbb\x06proto3"
+
+var (
+	file_yachtspb_sinkingyachts_proto_rawDescOnce sync.Once
+	file_yachtspb_sinkingyachts_proto_rawDescData []byte
+)
+
+func file_yachtspb_sinkingyachts_proto_rawDescGZIP() []byte {
+	file_yachtspb_sinkingyachts_proto_rawDescOnce.Do(func() {
+		file_yachtspb_sinkingyachts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_yachtspb_sinkingyachts_proto_rawDesc), len(file_yachtspb_sinkingyachts_proto_rawDesc)))
+	})
+	return file_yachtspb_sinkingyachts_proto_rawDescData
+}
+
+var file_yachtspb_sinkingyachts_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_yachtspb_sinkingyachts_proto_goTypes = []any{
+	(*CheckRequest)(nil),          // 0: sinkingyachts.v1.CheckRequest
+	(*CheckResponse)(nil),         // 1: sinkingyachts.v1.CheckResponse
+	(*ListDomainsRequest)(nil),    // 2: sinkingyachts.v1.ListDomainsRequest
+	(*ListDomainsResponse)(nil),   // 3: sinkingyachts.v1.ListDomainsResponse
+	(*StreamUpdatesRequest)(nil),  // 4: sinkingyachts.v1.StreamUpdatesRequest
+	(*DomainUpdate)(nil),          // 5: sinkingyachts.v1.DomainUpdate
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_yachtspb_sinkingyachts_proto_depIdxs = []int32{
+	6, // 0: sinkingyachts.v1.DomainUpdate.time:type_name -> google.protobuf.Timestamp
+	0, // 1: sinkingyachts.v1.SinkingYachts.Check:input_type -> sinkingyachts.v1.CheckRequest
+	0, // 2: sinkingyachts.v1.SinkingYachts.FuzzyCheck:input_type -> sinkingyachts.v1.CheckRequest
+	2, // 3: sinkingyachts.v1.SinkingYachts.ListDomains:input_type -> sinkingyachts.v1.ListDomainsRequest
+	4, // 4: sinkingyachts.v1.SinkingYachts.StreamUpdates:input_type -> sinkingyachts.v1.StreamUpdatesRequest
+	1, // 5: sinkingyachts.v1.SinkingYachts.Check:output_type -> sinkingyachts.v1.CheckResponse
+	1, // 6: sinkingyachts.v1.SinkingYachts.FuzzyCheck:output_type -> sinkingyachts.v1.CheckResponse
+	3, // 7: sinkingyachts.v1.SinkingYachts.ListDomains:output_type -> sinkingyachts.v1.ListDomainsResponse
+	5, // 8: sinkingyachts.v1.SinkingYachts.StreamUpdates:output_type -> sinkingyachts.v1.DomainUpdate
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_yachtspb_sinkingyachts_proto_init() }
+func file_yachtspb_sinkingyachts_proto_init() {
+	if File_yachtspb_sinkingyachts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_yachtspb_sinkingyachts_proto_rawDesc), len(file_yachtspb_sinkingyachts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_yachtspb_sinkingyachts_proto_goTypes,
+		DependencyIndexes: file_yachtspb_sinkingyachts_proto_depIdxs,
+		MessageInfos:      file_yachtspb_sinkingyachts_proto_msgTypes,
+	}.Build()
+	File_yachtspb_sinkingyachts_proto = out.File
+	file_yachtspb_sinkingyachts_proto_goTypes = nil
+	file_yachtspb_sinkingyachts_proto_depIdxs = nil
+}
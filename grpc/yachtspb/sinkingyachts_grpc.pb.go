@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: yachtspb/sinkingyachts.proto
+
+package yachtspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SinkingYachts_Check_FullMethodName         = "/sinkingyachts.v1.SinkingYachts/Check"
+	SinkingYachts_FuzzyCheck_FullMethodName    = "/sinkingyachts.v1.SinkingYachts/FuzzyCheck"
+	SinkingYachts_ListDomains_FullMethodName   = "/sinkingyachts.v1.SinkingYachts/ListDomains"
+	SinkingYachts_StreamUpdates_FullMethodName = "/sinkingyachts.v1.SinkingYachts/StreamUpdates"
+)
+
+// SinkingYachtsClient is the client API for SinkingYachts service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SinkingYachts serves the synced cache of a Client.
+type SinkingYachtsClient interface {
+	// Check checks if the domain is phishing, see Client.Check.
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// FuzzyCheck checks if the domain or any of its parent domains is phishing, see Client.FuzzyCheck.
+	FuzzyCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// ListDomains streams every known domain in batches.
+	ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListDomainsResponse], error)
+	// StreamUpdates streams every update applied to the cache until the call is cancelled.
+	// A subscriber that falls too far behind is ended with RESOURCE_EXHAUSTED.
+	StreamUpdates(ctx context.Context, in *StreamUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DomainUpdate], error)
+}
+
+type sinkingYachtsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSinkingYachtsClient(cc grpc.ClientConnInterface) SinkingYachtsClient {
+	return &sinkingYachtsClient{cc}
+}
+
+func (c *sinkingYachtsClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, SinkingYachts_Check_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sinkingYachtsClient) FuzzyCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, SinkingYachts_FuzzyCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sinkingYachtsClient) ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListDomainsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SinkingYachts_ServiceDesc.Streams[0], SinkingYachts_ListDomains_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListDomainsRequest, ListDomainsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SinkingYachts_ListDomainsClient = grpc.ServerStreamingClient[ListDomainsResponse]
+
+func (c *sinkingYachtsClient) StreamUpdates(ctx context.Context, in *StreamUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DomainUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SinkingYachts_ServiceDesc.Streams[1], SinkingYachts_StreamUpdates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamUpdatesRequest, DomainUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SinkingYachts_StreamUpdatesClient = grpc.ServerStreamingClient[DomainUpdate]
+
+// SinkingYachtsServer is the server API for SinkingYachts service.
+// All implementations must embed UnimplementedSinkingYachtsServer
+// for forward compatibility.
+//
+// SinkingYachts serves the synced cache of a Client.
+type SinkingYachtsServer interface {
+	// Check checks if the domain is phishing, see Client.Check.
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// FuzzyCheck checks if the domain or any of its parent domains is phishing, see Client.FuzzyCheck.
+	FuzzyCheck(context.Context, *CheckRequest) (*CheckResponse, error)
+	// ListDomains streams every known domain in batches.
+	ListDomains(*ListDomainsRequest, grpc.ServerStreamingServer[ListDomainsResponse]) error
+	// StreamUpdates streams every update applied to the cache until the call is cancelled.
+	// A subscriber that falls too far behind is ended with RESOURCE_EXHAUSTED.
+	StreamUpdates(*StreamUpdatesRequest, grpc.ServerStreamingServer[DomainUpdate]) error
+	mustEmbedUnimplementedSinkingYachtsServer()
+}
+
+// UnimplementedSinkingYachtsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSinkingYachtsServer struct{}
+
+func (UnimplementedSinkingYachtsServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedSinkingYachtsServer) FuzzyCheck(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FuzzyCheck not implemented")
+}
+func (UnimplementedSinkingYachtsServer) ListDomains(*ListDomainsRequest, grpc.ServerStreamingServer[ListDomainsResponse]) error {
+	return status.Error(codes.Unimplemented, "method ListDomains not implemented")
+}
+func (UnimplementedSinkingYachtsServer) StreamUpdates(*StreamUpdatesRequest, grpc.ServerStreamingServer[DomainUpdate]) error {
+	return status.Error(codes.Unimplemented, "method StreamUpdates not implemented")
+}
+func (UnimplementedSinkingYachtsServer) mustEmbedUnimplementedSinkingYachtsServer() {}
+func (UnimplementedSinkingYachtsServer) testEmbeddedByValue()                       {}
+
+// UnsafeSinkingYachtsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SinkingYachtsServer will
+// result in compilation errors.
+type UnsafeSinkingYachtsServer interface {
+	mustEmbedUnimplementedSinkingYachtsServer()
+}
+
+func RegisterSinkingYachtsServer(s grpc.ServiceRegistrar, srv SinkingYachtsServer) {
+	// If the following call panics, it indicates UnimplementedSinkingYachtsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SinkingYachts_ServiceDesc, srv)
+}
+
+func _SinkingYachts_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SinkingYachtsServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SinkingYachts_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SinkingYachtsServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SinkingYachts_FuzzyCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SinkingYachtsServer).FuzzyCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SinkingYachts_FuzzyCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SinkingYachtsServer).FuzzyCheck(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SinkingYachts_ListDomains_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListDomainsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SinkingYachtsServer).ListDomains(m, &grpc.GenericServerStream[ListDomainsRequest, ListDomainsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SinkingYachts_ListDomainsServer = grpc.ServerStreamingServer[ListDomainsResponse]
+
+func _SinkingYachts_StreamUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SinkingYachtsServer).StreamUpdates(m, &grpc.GenericServerStream[StreamUpdatesRequest, DomainUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SinkingYachts_StreamUpdatesServer = grpc.ServerStreamingServer[DomainUpdate]
+
+// SinkingYachts_ServiceDesc is the grpc.ServiceDesc for SinkingYachts service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SinkingYachts_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sinkingyachts.v1.SinkingYachts",
+	HandlerType: (*SinkingYachtsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    _SinkingYachts_Check_Handler,
+		},
+		{
+			MethodName: "FuzzyCheck",
+			Handler:    _SinkingYachts_FuzzyCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListDomains",
+			Handler:       _SinkingYachts_ListDomains_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamUpdates",
+			Handler:       _SinkingYachts_StreamUpdates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "yachtspb/sinkingyachts.proto",
+}
@@ -0,0 +1,126 @@
+package sinkingyachts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//endpointHash is the endpoint of the cache hash served by Mirror
+const endpointHash = "/hash"
+
+//Hash returns a canonical hash of the known domains, as lower case hex of a sha256
+//instances with the same domains hash the same no matter how they got them, so hashes can be compared across a fleet
+//the categories of domains with metadata are included as they come from upstream, but not when or where domains were first seen
+//domains in DefaultCategory hash the same as domains without metadata, local domains are not included as they are not replicated
+func (c *Client) Hash() string {
+	c.m.Lock()
+	entries := make([]hashEntry, 0, len(c.domains))
+	for domain := range c.domains {
+		entry := hashEntry{domain: domain}
+		if md, ok := c.meta[domain]; ok {
+			entry.category = md.Category
+		}
+		entries = append(entries, entry)
+	}
+	c.m.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].domain < entries[j].domain
+	})
+
+	h := sha256.New()
+	for _, entry := range entries {
+		writeHashEntry(h, entry)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//Hash returns the canonical hash of the domains in the snapshot
+//it equals the Client.Hash of the snapshotted Client, as long as all of its domains are in DefaultCategory
+func (s *Snapshot) Hash() string {
+	h := sha256.New()
+	for i := 0; i < s.count; i++ {
+		entry, _ := s.entry(i)
+		writeHashEntry(h, hashEntry{domain: string(entry)})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//hashEntry is a domain as it's hashed
+type hashEntry struct {
+	domain   string
+	category string
+}
+
+//writeHashEntry writes an entry into the hash as a line of the domain, followed by a tab and the category if it's not the default
+func writeHashEntry(h hash.Hash, entry hashEntry) {
+	_, _ = h.Write([]byte(entry.domain))
+	if entry.category != "" && entry.category != DefaultCategory {
+		_, _ = h.Write([]byte{'\t'})
+		_, _ = h.Write([]byte(entry.category))
+	}
+	_, _ = h.Write([]byte{'\n'})
+}
+
+//HashGroup is a set of replicas that have the same hash
+type HashGroup struct {
+	Hash     string
+	Replicas []string
+}
+
+//CompareHashes groups replicas by their hash, such as ones collected from the /hash endpoint of Mirror
+//the fleet has converged if there is at most one group, the groups are sorted from the most replicas to the least
+//so the first group is usually the correct state, and the rest are replicas that need to be repaired
+func CompareHashes(hashes map[string]string) []HashGroup {
+	byHash := map[string][]string{}
+	for replica, h := range hashes {
+		byHash[h] = append(byHash[h], replica)
+	}
+	groups := make([]HashGroup, 0, len(byHash))
+	for h, replicas := range byHash {
+		sort.Strings(replicas)
+		groups = append(groups, HashGroup{Hash: h, Replicas: replicas})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Replicas) != len(groups[j].Replicas) {
+			return len(groups[i].Replicas) > len(groups[j].Replicas)
+		}
+		return groups[i].Hash < groups[j].Hash
+	})
+	return groups
+}
+
+//Hash returns the hash of a Mirror's cache, see Client.Hash
+//the Client must be pointed at a Mirror, the api itself doesn't serve hashes
+func (c RawClient) Hash() (string, error) {
+	resp, err := c.doReq(endpointHash)
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != 200 {
+		return "", unexpectedStatusError{
+			endpoint: c.domain + endpointHash,
+			status:   resp.StatusCode,
+		}
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+//serveHash responds with the hash of the cache
+func (m *Mirror) serveHash(w http.ResponseWriter, r *http.Request) error {
+	if m.notModified(w, r) {
+		return nil
+	}
+	_, err := w.Write([]byte(m.c.Hash()))
+	return err
+}
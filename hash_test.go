@@ -0,0 +1,40 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	a := assert.New(t)
+	first := New("", "test", http.Client{})
+	first.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	second := New("", "test", http.Client{})
+	second.EnableMetadata()
+	second.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com"}}, SourceWebhook)
+	second.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}, Category: DefaultCategory}, SourceFeed)
+	second.AddLocal(0, "local.com")
+	a.Equal(first.Hash(), second.Hash())
+
+	var buf bytes.Buffer
+	a.NoError(WriteSnapshot(first, &buf))
+	s, err := newSnapshot(buf.Bytes())
+	a.NoError(err)
+	a.Equal(first.Hash(), s.Hash())
+
+	second.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}, Category: "malware"}, SourceFeed)
+	first.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}}, SourceFeed)
+	a.NotEqual(first.Hash(), second.Hash())
+
+	srv := httptest.NewServer(NewMirror(first))
+	defer srv.Close()
+	mirrored, err := NewRawClient(srv.URL, "test", http.Client{}).Hash()
+	a.NoError(err)
+	a.Equal(first.Hash(), mirrored)
+
+	groups := CompareHashes(map[string]string{"a": "x", "b": "y", "c": "x"})
+	a.Equal([]HashGroup{{Hash: "x", Replicas: []string{"a", "c"}}, {Hash: "y", Replicas: []string{"b"}}}, groups)
+}
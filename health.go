@@ -0,0 +1,66 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//Ready returns nil if the Manager is ready to serve lookups, or an error describing why it isn't
+//it is ready once the first sync has completed, and either the feed is connected or the api was synced within threshold
+//a paused Manager stays ready, as it's deliberately serving from a frozen cache
+func (m *Manager) Ready(threshold time.Duration) error {
+	m.m.Lock()
+	lastSync := m.lastSync
+	m.m.Unlock()
+	if lastSync.IsZero() {
+		return errors.New("first sync has not completed")
+	}
+	if m.client.Stats().FeedConnected || m.client.Paused() {
+		return nil
+	}
+	if since := time.Since(lastSync); since > threshold {
+		return fmt.Errorf("feed is not connected and last sync was %s ago", since.Truncate(time.Second))
+	}
+	return nil
+}
+
+//HealthHandler serves kubernetes style probes for a Manager
+//GET /healthz always responds with 200 while the process is serving, use it as the liveness probe
+//GET /readyz responds with 200 when Manager.Ready with threshold succeeds, and 503 with the reason otherwise
+//GET /stats responds with Stats as json, along with "ready" and the "not_ready" reason of Manager.Ready, always with 200
+//as the probes are served without authentication, /stats leaves out the rule hits and the last sync error, which can reveal
+//local domains, regex rules and internal addresses, they are only served by AdminHandler
+//threshold should be longer than the configured recent or full sync interval, so a feed outage isn't reported until polling also falls behind
+func HealthHandler(m *Manager, threshold time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := m.Ready(threshold); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		hs := healthStats{Ready: true, Stats: m.client.Stats().public()}
+		if err := m.Ready(threshold); err != nil {
+			hs.Ready = false
+			hs.NotReady = err.Error()
+		}
+		if err := writeJSON(w, hs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
+
+//healthStats is the body of the /stats endpoint of HealthHandler
+type healthStats struct {
+	Ready    bool   `json:"ready"`
+	NotReady string `json:"not_ready,omitempty"`
+	Stats    Stats  `json:"stats"`
+}
@@ -0,0 +1,92 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	a := assert.New(t)
+	m, err := NewManager(Config{Endpoint: "https://example.com", Identity: "test"})
+	a.NoError(err)
+	h := HealthHandler(m, time.Minute)
+	probe := func(path string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	a.Equal(http.StatusOK, probe("/healthz"))
+	a.Equal(http.StatusServiceUnavailable, probe("/readyz"))
+
+	m.synced()
+	a.Equal(http.StatusOK, probe("/readyz"))
+
+	m.lastSync = time.Now().Add(-time.Hour)
+	a.Equal(http.StatusServiceUnavailable, probe("/readyz"))
+	a.Error(m.Ready(time.Minute))
+	a.NoError(m.Ready(time.Hour * 2))
+}
+
+func TestHealthStats(t *testing.T) {
+	a := assert.New(t)
+	m, err := NewManager(Config{Endpoint: "https://example.com", Identity: "test"})
+	a.NoError(err)
+	m.Client().applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	m.Client().AddLocal(0, "secret.com")
+	a.True(m.Client().Check("secret.com"))
+	m.Client().recordSync(SyncOpFullSync, errors.New("dial tcp 10.0.0.1:443: connection refused"))
+	a.NotEmpty(m.Client().Stats().Rules)
+	h := HealthHandler(m, time.Minute)
+	stats := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+		a.Equal(http.StatusOK, rec.Code)
+		a.Equal("application/json", rec.Header().Get("Content-Type"))
+		return rec.Body.String()
+	}
+
+	body := stats()
+	a.Contains(body, `"ready":false`)
+	a.Contains(body, `"not_ready":"first sync has not completed"`)
+	a.Contains(body, `"domains":2`)
+	a.Contains(body, `"feed_connected":false`)
+	a.Contains(body, `"sync_failures":1`)
+	a.NotContains(body, `"last_sync_error"`)
+	a.NotContains(body, "10.0.0.1")
+	a.NotContains(body, `"rules"`)
+	a.NotContains(body, "secret.com")
+
+	m.synced()
+	body = stats()
+	a.Contains(body, `"ready":true`)
+	a.NotContains(body, `"not_ready"`)
+}
+
+func TestStatsJSON(t *testing.T) {
+	a := assert.New(t)
+	updated := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(Stats{
+		Domains:      3,
+		LastUpdated:  updated,
+		FeedConnects: 3,
+		FeedLag:      time.Millisecond * 1500,
+		Endpoints:    []EndpointTransfer{{Endpoint: endpointAll, Requests: 1, Bytes: 42}},
+		Rules:        []RuleHits{{Kind: RuleLocal, Rule: "local.com"}},
+	})
+	a.NoError(err)
+	var fields map[string]interface{}
+	a.NoError(json.Unmarshal(b, &fields))
+	a.Equal(float64(3), fields["domains"])
+	a.Equal("2022-03-01T12:00:00Z", fields["last_updated"])
+	a.Equal(float64(2), fields["feed_reconnects"])
+	a.Equal(1.5, fields["feed_lag_seconds"])
+	a.NotContains(fields, "feed_last_message")
+	a.Equal([]interface{}{map[string]interface{}{"endpoint": endpointAll, "requests": float64(1), "bytes": float64(42)}}, fields["endpoints"])
+	a.Equal([]interface{}{map[string]interface{}{"kind": RuleLocal, "rule": "local.com", "hits": float64(0)}}, fields["rules"])
+}
@@ -2,20 +2,60 @@ package sinkingyachts
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"time"
 )
 
+//CacheFormat is the serialization format of a stored cache
+type CacheFormat int
+
+const (
+	//CacheJSON stores the cache as JSON, this is the default format
+	CacheJSON CacheFormat = iota
+	//CacheMsgpack stores the cache as MessagePack, which is cheaper to encode for large caches
+	CacheMsgpack
+)
+
+//marshal encodes the Client's cache in the format
+func (f CacheFormat) marshal(c *Client) ([]byte, error) {
+	switch f {
+	case CacheJSON:
+		return c.MarshalJSON()
+	case CacheMsgpack:
+		return c.MarshalMsgpack()
+	default:
+		return nil, fmt.Errorf("unknown cache format %d", f)
+	}
+}
+
+//unmarshal decodes the Client's cache from the format
+func (f CacheFormat) unmarshal(c *Client, data []byte) error {
+	switch f {
+	case CacheJSON:
+		return c.UnmarshalJSON(data)
+	case CacheMsgpack:
+		return c.UnmarshalMsgpack(data)
+	default:
+		return fmt.Errorf("unknown cache format %d", f)
+	}
+}
+
 //ReadCacheFrom loads stored cache from the reader into Client
 func ReadCacheFrom(c *Client, r io.Reader) error {
+	return ReadCacheFormat(c, r, CacheJSON)
+}
+
+//ReadCacheFormat loads stored cache in the given format from the reader into Client
+func ReadCacheFormat(c *Client, r io.Reader, format CacheFormat) error {
 	bf, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
 	var data Client
-	err = data.UnmarshalJSON(bf)
+	err = format.unmarshal(&data, bf)
 	if err != nil {
 		return err
 	}
@@ -24,18 +64,32 @@ func ReadCacheFrom(c *Client, r io.Reader) error {
 	defer c.m.Unlock()
 	c.lastUpdated = data.lastUpdated
 	c.domains = data.domains
+	c.arena = data.arena
+	c.meta = data.meta
+	c.local = data.local
+	c.reviews = data.reviews
+	c.localAdded = data.localAdded
+	c.localRemoved = data.localRemoved
+	c.bumpGeneration()
+	c.history.reset(c.generation)
+	c.resetEviction()
 	return nil
 }
 
 //WriteCacheInto saves cache into the writer.
 func WriteCacheInto(c *Client, w io.Writer) error {
+	return WriteCacheFormat(c, w, CacheJSON)
+}
+
+//WriteCacheFormat saves cache in the given format into the writer.
+func WriteCacheFormat(c *Client, w io.Writer, format CacheFormat) error {
 	if s, ok := w.(io.Seeker); ok {
 		_, err := s.Seek(0, 0)
 		if err != nil {
 			return err
 		}
 	}
-	b, err := c.MarshalJSON()
+	b, err := format.marshal(c)
 
 	if err != nil {
 		return err
@@ -47,6 +101,11 @@ func WriteCacheInto(c *Client, w io.Writer) error {
 //SaveOnChange register listen for updates and writes it into the writer
 //this function blocks and returns only when update channel gets closed, use ctx to cancel
 func SaveOnChange(ctx context.Context, c *Client, w io.Writer) error {
+	return SaveOnChangeFormat(ctx, c, w, CacheJSON)
+}
+
+//SaveOnChangeFormat is SaveOnChange but writes the cache in the given format
+func SaveOnChangeFormat(ctx context.Context, c *Client, w io.Writer, format CacheFormat) error {
 	ch := c.UpdateChannel()
 	for {
 		select {
@@ -56,7 +115,7 @@ func SaveOnChange(ctx context.Context, c *Client, w io.Writer) error {
 			if !ok {
 				return nil
 			}
-			err := WriteCacheInto(c, w)
+			err := WriteCacheFormat(c, w, format)
 			if err != nil {
 				return err
 			}
@@ -64,49 +123,69 @@ func SaveOnChange(ctx context.Context, c *Client, w io.Writer) error {
 	}
 }
 
+//SweepLocal is a helper that periodically removes expired local domains, see Client.SweepExpired
+//it also reports local domains that passed their review date to the hook of Client.OnReviewDue
+//this function blocks and return only when cancelled by ctx
+func SweepLocal(ctx context.Context, c *Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.SweepExpired()
+			c.DueReviews()
+		}
+	}
+}
+
 //AutoSync is a helper that setup auto syncing functionality for Client
 //this function blocks and return only when cancelled by ctx, or occurrence of an error
 //you can use 0 to disable recent syncing and full syncing
 //though it's recommended to use full sync, especially when realtime is enabled
 //the recent interval is only useful when realtime is disabled
+//use AutoSyncResilient to keep syncing through transient errors instead of returning them
+//it always starts with a full sync, use AutoSyncWith to start from a store or a mirror instead
 func AutoSync(ctx context.Context, c *Client, realtime bool, recentInterval, fullSyncInterval time.Duration) error {
+	return AutoSyncWith(ctx, c, InitialFullSync, realtime, recentInterval, fullSyncInterval)
+}
+
+//AutoSyncWith is AutoSync that populates the cache with the initial strategy before syncing, nil uses InitialFullSync
+func AutoSyncWith(ctx context.Context, c *Client, initial InitialSync, realtime bool, recentInterval, fullSyncInterval time.Duration) error {
+	if initial == nil {
+		initial = InitialFullSync
+	}
+	ctx, cancel := c.r.withBase(ctx)
+	defer cancel()
 	var stream chan error
-	modChan := make(chan DomainUpdate, 2)
+	modChan := make(chan feedUpdate, c.r.feedBufferSize())
 	if realtime {
+		stream = make(chan error, 1)
 		go func() {
 			stream <- c.listenForUpdates(ctx, modChan)
-			close(stream)
 		}()
 	}
 
-	errSync := c.FullSync()
+	errSync := initial(ctx, c)
 	if errSync != nil {
 		return errSync
 	}
 
-	var recentTicker *time.Ticker
-	var fullSyncTicker *time.Ticker
-	if recentInterval > 0 {
-		recentTicker = time.NewTicker(recentInterval)
-	}
-	if fullSyncInterval > 0 {
-		fullSyncTicker = time.NewTicker(fullSyncInterval)
-	}
-	defer recentTicker.Stop()
-	defer fullSyncTicker.Stop()
+	recentTick, stopRecent := tick(recentInterval)
+	defer stopRecent()
+	fullSyncTick, stopFullSync := tick(fullSyncInterval)
+	defer stopFullSync()
 	for {
 		select {
-		case mod, ok := <-modChan:
-			if !ok {
-				return nil
-			}
-			c.applyLiveUpdates(mod)
-		case <-recentTicker.C:
+		case update := <-modChan:
+			c.applyUpdates([]feedUpdate{update}, SourceFeed)
+		case <-recentTick:
 			err := c.Update()
 			if err != nil {
 				return err
 			}
-		case <-fullSyncTicker.C:
+		case <-fullSyncTick:
 			err := c.FullSync()
 			if err != nil {
 				return err
@@ -115,8 +194,19 @@ func AutoSync(ctx context.Context, c *Client, realtime bool, recentInterval, ful
 			if err != nil {
 				return err
 			}
+			stream = nil
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
+
+//tick returns a channel ticking every interval and a function to stop it
+//the channel never ticks if interval is 0
+func tick(interval time.Duration) (<-chan time.Time, func()) {
+	if interval <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
@@ -0,0 +1,257 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//ErrHistoryTruncated is returned by ExportChanges when the history doesn't reach back to the requested time
+//downstream systems should re-ingest a full export instead
+var ErrHistoryTruncated = errors.New("history does not reach back far enough")
+
+var errHistoryDisabled = errors.New("history is not enabled, see EnableHistory")
+
+//history is a ring of the most recently applied updates
+type history struct {
+	entries []historyEntry
+	next    int
+	full    bool
+	//since is the time from which the history is complete
+	since time.Time
+	//sinceGeneration is the generation from which the history is complete
+	sinceGeneration uint64
+}
+
+//historyEntry is an applied update, and the generation of the cache after applying it
+type historyEntry struct {
+	update     AppliedUpdate
+	generation uint64
+}
+
+//newHistory creates a history of size updates, which is complete from the generation onwards
+func newHistory(size int, generation uint64) *history {
+	return &history{entries: make([]historyEntry, size), since: time.Now(), sinceGeneration: generation}
+}
+
+//record adds an update, dropping the oldest update if the ring is full
+func (h *history) record(au AppliedUpdate, generation uint64) {
+	if h.full {
+		h.since = h.entries[h.next].update.Time
+		h.sinceGeneration = h.entries[h.next].generation
+	}
+	h.entries[h.next] = historyEntry{update: au, generation: generation}
+	h.next = (h.next + 1) % len(h.entries)
+	h.full = h.full || h.next == 0
+}
+
+//reset discards the history, as the cache it describes got replaced and is now at the generation
+func (h *history) reset(generation uint64) {
+	if h == nil {
+		return
+	}
+	*h = *newHistory(len(h.entries), generation)
+}
+
+//after returns the updates of the entries accepted by fn, oldest first
+func (h *history) after(fn func(entry historyEntry) bool) []AppliedUpdate {
+	ordered := append(append([]historyEntry(nil), h.entries[h.next:]...), h.entries[:h.next]...)
+	if !h.full {
+		ordered = h.entries[:h.next]
+	}
+	var updates []AppliedUpdate
+	for _, entry := range ordered {
+		if fn(entry) {
+			updates = append(updates, entry.update)
+		}
+	}
+	return updates
+}
+
+//historyEnabled checks if EnableHistory has been called with a size
+func (c *Client) historyEnabled() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.history != nil
+}
+
+//EnableHistory keeps the last size applied updates, so changes since a time can be exported with ExportChanges
+//loading a cache replaces the whole cache, so history from before loading is discarded
+func (c *Client) EnableHistory(size int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if size <= 0 {
+		c.history = nil
+		return
+	}
+	c.history = newHistory(size, c.generation)
+}
+
+//updatesAfter returns the recorded updates applied after since, oldest first
+func (c *Client) updatesAfter(since time.Time) ([]AppliedUpdate, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.history == nil {
+		return nil, errHistoryDisabled
+	}
+	if since.Before(c.history.since) {
+		return nil, ErrHistoryTruncated
+	}
+	return c.history.after(func(entry historyEntry) bool {
+		return entry.update.Time.After(since)
+	}), nil
+}
+
+//updatesAfterGeneration returns the recorded updates that advanced the cache past the generation, oldest first
+//the current generation is returned along with them
+func (c *Client) updatesAfterGeneration(generation uint64) ([]AppliedUpdate, uint64, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.history == nil {
+		return nil, 0, errHistoryDisabled
+	}
+	if generation < c.history.sinceGeneration || generation > c.generation {
+		return nil, 0, ErrHistoryTruncated
+	}
+	return c.history.after(func(entry historyEntry) bool {
+		return entry.generation > generation
+	}), c.generation, nil
+}
+
+//Changes returns the net changes since a time, a domain added and then removed again is not a change
+//each changed domain is returned as its own update, with the time, source and category of its last change
+//ErrHistoryTruncated is returned if the history doesn't reach back to since
+func (c *Client) Changes(since time.Time) ([]AppliedUpdate, error) {
+	updates, err := c.updatesAfter(since)
+	if err != nil {
+		return nil, err
+	}
+	return netChanges(updates), nil
+}
+
+//netChanges reduces updates into a single update per changed domain, see Client.Changes
+func netChanges(updates []AppliedUpdate) []AppliedUpdate {
+	type change struct {
+		firstAdd bool
+		last     AppliedUpdate
+	}
+	changes := map[string]*change{}
+	for _, au := range updates {
+		for _, domain := range au.Update.Domains {
+			ch, ok := changes[domain]
+			if !ok {
+				ch = &change{firstAdd: au.Update.Add}
+				changes[domain] = ch
+			}
+			ch.last = AppliedUpdate{
+				Update: DomainUpdate{Add: au.Update.Add, Domains: []string{domain}, Category: au.Update.Category},
+				Time:   au.Time,
+				Source: au.Source,
+			}
+		}
+	}
+	var net []AppliedUpdate
+	for _, ch := range changes {
+		//a domain removed and added back, or added and removed again, ends up as it was
+		if ch.firstAdd == ch.last.Update.Add {
+			net = append(net, ch.last)
+		}
+	}
+	sort.Slice(net, func(i, j int) bool {
+		if !net[i].Time.Equal(net[j].Time) {
+			return net[i].Time.Before(net[j].Time)
+		}
+		return net[i].Update.Domains[0] < net[j].Update.Domains[0]
+	})
+	return net
+}
+
+//ChangeFormat writes net changes, see Client.ExportChanges
+type ChangeFormat interface {
+	WriteChanges(w io.Writer, changes []AppliedUpdate) error
+}
+
+//ChangeFormatFunc is a function that implements ChangeFormat
+type ChangeFormatFunc func(w io.Writer, changes []AppliedUpdate) error
+
+//WriteChanges calls f(w, changes)
+func (f ChangeFormatFunc) WriteChanges(w io.Writer, changes []AppliedUpdate) error {
+	return f(w, changes)
+}
+
+//ChangesText writes a line per change, "+bad.com" for additions and "-bad.com" for removals
+var ChangesText ChangeFormat = ChangeFormatFunc(func(w io.Writer, changes []AppliedUpdate) error {
+	bw := bufio.NewWriter(w)
+	for _, au := range changes {
+		prefix := byte('-')
+		if au.Update.Add {
+			prefix = '+'
+		}
+		for _, domain := range au.Update.Domains {
+			_ = bw.WriteByte(prefix)
+			_, _ = bw.WriteString(domain)
+			_ = bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+})
+
+//ChangesJSONL writes a json line per change, in the same format as Journal
+var ChangesJSONL ChangeFormat = ChangeFormatFunc(func(w io.Writer, changes []AppliedUpdate) error {
+	j := NewJournal(w)
+	for _, au := range changes {
+		if err := j.Publish(context.Background(), au); err != nil {
+			return err
+		}
+	}
+	return nil
+})
+
+//RPZChanges writes changes as an nsupdate script for a response policy zone
+//listed domains and their subdomains are answered with NXDOMAIN
+type RPZChanges struct {
+	//Zone is the name of the response policy zone, defaults to "rpz"
+	Zone string
+	//TTL is the ttl of added records, defaults to 300
+	TTL int
+}
+
+//WriteChanges writes update commands for the changes followed by send
+func (r RPZChanges) WriteChanges(w io.Writer, changes []AppliedUpdate) error {
+	zone, ttl := r.Zone, r.TTL
+	if zone == "" {
+		zone = "rpz"
+	}
+	if ttl <= 0 {
+		ttl = 300
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("zone " + zone + ".\n")
+	for _, au := range changes {
+		for _, domain := range au.Update.Domains {
+			for _, owner := range []string{domain, "*." + domain} {
+				if au.Update.Add {
+					_, _ = bw.WriteString("update add " + owner + "." + zone + ". " + strconv.Itoa(ttl) + " CNAME .\n")
+				} else {
+					_, _ = bw.WriteString("update delete " + owner + "." + zone + ". CNAME\n")
+				}
+			}
+		}
+	}
+	_, _ = bw.WriteString("send\n")
+	return bw.Flush()
+}
+
+//ExportChanges writes only the net changes since a time in the format, so downstream systems don't re-ingest everything
+//history must be enabled with EnableHistory, ErrHistoryTruncated is returned if it doesn't reach back to since
+func (c *Client) ExportChanges(w io.Writer, since time.Time, format ChangeFormat) error {
+	changes, err := c.Changes(since)
+	if err != nil {
+		return err
+	}
+	return format.WriteChanges(w, changes)
+}
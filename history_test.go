@@ -0,0 +1,82 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportChanges(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+
+	a.Error(c.ExportChanges(&bytes.Buffer{}, time.Now(), ChangesText))
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"old.com"}}, SourceFeed)
+	c.EnableHistory(4)
+	since := time.Now()
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com", "c.com"}}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"old.com"}}, SourceFeed)
+
+	tests := []struct {
+		name   string
+		format ChangeFormat
+		want   string
+	}{
+		{"text", ChangesText, "+b.com\n+c.com\n-old.com\n"},
+		{"rpz", RPZChanges{Zone: "rpz.example", TTL: 60}, "zone rpz.example.\n" +
+			"update add b.com.rpz.example. 60 CNAME .\nupdate add *.b.com.rpz.example. 60 CNAME .\n" +
+			"update add c.com.rpz.example. 60 CNAME .\nupdate add *.c.com.rpz.example. 60 CNAME .\n" +
+			"update delete old.com.rpz.example. CNAME\nupdate delete *.old.com.rpz.example. CNAME\nsend\n"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			var buf bytes.Buffer
+			a.NoError(c.ExportChanges(&buf, since, data.format))
+			a.Equal(data.want, buf.String())
+		})
+	}
+
+	var buf bytes.Buffer
+	a.NoError(c.ExportChanges(&buf, since, ChangesJSONL))
+	a.Equal(3, strings.Count(buf.String(), "\n"))
+	a.Contains(buf.String(), `"old.com"`)
+
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"d.com"}}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"e.com"}}, SourceFeed)
+	a.ErrorIs(c.ExportChanges(&buf, since, ChangesText), ErrHistoryTruncated)
+
+	cached, err := c.MarshalJSON()
+	a.NoError(err)
+	loaded := time.Now()
+	a.NoError(ReadCacheFrom(c, bytes.NewReader(cached)))
+	a.ErrorIs(c.ExportChanges(&buf, since, ChangesText), ErrHistoryTruncated)
+	changes, err := c.Changes(loaded.Add(time.Second))
+	a.NoError(err)
+	a.Empty(changes)
+}
+
+func TestChangesAfterReAdd(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"x.com"}}, SourceFeed)
+	c.EnableHistory(4)
+	since := time.Now()
+	generation := c.Generation()
+
+	//re-adding a known domain is not a change, so the removal afterwards must not be netted out
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"x.com"}}, SourceFeed)
+	a.Equal(generation, c.Generation())
+	c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"x.com"}}, SourceFeed)
+
+	changes, err := c.Changes(since)
+	a.NoError(err)
+	if a.Len(changes, 1) {
+		a.False(changes[0].Update.Add)
+		a.Equal([]string{"x.com"}, changes[0].Update.Domains)
+	}
+}
@@ -0,0 +1,139 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//ImportFormat is the format of an external list of domains
+type ImportFormat int
+
+const (
+	//ImportText is a domain per line, lines starting with # are comments
+	ImportText ImportFormat = iota
+	//ImportCSV takes domains from the first column, a header of "domain" is skipped
+	ImportCSV
+	//ImportHosts is a hosts file, such as "0.0.0.0 bad.com", with localhost entries skipped
+	ImportHosts
+)
+
+//ImportPlan is an imported list compared against a Client, see Client.PlanImport
+type ImportPlan struct {
+	//Add are valid domains that are not known yet
+	Add []string `json:"add"`
+	//Known are domains that are already known or local
+	Known []string `json:"known"`
+	//Invalid are entries that are not valid domains after normalizing
+	Invalid []string `json:"invalid"`
+}
+
+//ReadImport reads domains from an external list in the format
+//entries are normalized, urls are reduced to their host, and leading wildcards and trailing dots are removed
+func ReadImport(r io.Reader, format ImportFormat) ([]string, error) {
+	var domains []string
+	switch format {
+	case ImportText, ImportHosts:
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if i := strings.IndexByte(line, '#'); i >= 0 {
+				line = line[:i]
+			}
+			fields := strings.Fields(line)
+			if format == ImportText {
+				domains = append(domains, fields...)
+				continue
+			}
+			if len(fields) < 2 {
+				continue
+			}
+			for _, host := range fields[1:] {
+				if !hostsReserved(host) {
+					domains = append(domains, host)
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case ImportCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.Comment = '#'
+		for first := true; ; first = false {
+			record, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(record) == 0 || first && strings.EqualFold(strings.TrimSpace(record[0]), "domain") {
+				continue
+			}
+			domains = append(domains, record[0])
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %d", format)
+	}
+	for i, domain := range domains {
+		domains[i] = normalizeImport(domain)
+	}
+	return domains, nil
+}
+
+//normalizeImport normalizes an imported entry into a lower cased domain
+func normalizeImport(entry string) string {
+	entry = strings.TrimSpace(entry)
+	if strings.ContainsAny(entry, "/:") {
+		if host, _ := parseLink(entry); host != "" {
+			entry = host
+		}
+	}
+	entry = strings.ToLower(entry)
+	entry = strings.TrimPrefix(entry, "*.")
+	return strings.TrimSuffix(entry, ".")
+}
+
+//hostsReserved checks if a hosts file entry is a local name rather than a blocked domain
+func hostsReserved(host string) bool {
+	host = strings.ToLower(host)
+	return host == "localhost" || host == "broadcasthost" || host == "local" ||
+		strings.HasPrefix(host, "localhost.") || strings.HasPrefix(host, "ip6-")
+}
+
+//PlanImport compares imported domains against Client without changing it, to review an import before applying it
+//duplicates are removed, and domains are sorted into ones to add, already known ones and invalid ones
+func (c *Client) PlanImport(domains []string) ImportPlan {
+	var plan ImportPlan
+	seen := make(map[string]empty, len(domains))
+	for _, domain := range domains {
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+		seen[domain] = empty{}
+		known, _ := c.lookup(domain, true)
+		switch {
+		case ValidateDomain(domain) != nil:
+			plan.Invalid = append(plan.Invalid, domain)
+		case known:
+			plan.Known = append(plan.Known, domain)
+		default:
+			plan.Add = append(plan.Add, domain)
+		}
+	}
+	return plan
+}
+
+//ApplyImport adds the domains of the plan as local domains that expire after ttl, see Client.AddLocal
+//the api doesn't document how to submit domains, so imports can't be submitted upstream
+func (c *Client) ApplyImport(plan ImportPlan, ttl time.Duration) {
+	if len(plan.Add) > 0 {
+		c.AddLocal(ttl, plan.Add...)
+	}
+}
@@ -0,0 +1,45 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ImportFormat
+		data   string
+		want   []string
+	}{
+		{name: "text", format: ImportText, data: "# list\nBad.com\n\n*.evil.org.\nhttps://scam.net/login # url\n", want: []string{"bad.com", "evil.org", "scam.net"}},
+		{name: "csv", format: ImportCSV, data: "domain,category\nbad.com,phishing\n# comment\nevil.org\n", want: []string{"bad.com", "evil.org"}},
+		{name: "hosts", format: ImportHosts, data: "127.0.0.1 localhost\n::1 ip6-localhost ip6-loopback\n0.0.0.0 bad.com www.bad.com # ads\n", want: []string{"bad.com", "www.bad.com"}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			domains, err := ReadImport(strings.NewReader(data.data), data.format)
+			a.NoError(err)
+			a.Equal(data.want, domains)
+		})
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"known.com"}}, SourceFeed)
+	c.AddLocal(0, "local.com")
+
+	plan := c.PlanImport([]string{"new.com", "known.com", "local.com", "new.com", "not a domain"})
+	a.Equal([]string{"new.com"}, plan.Add)
+	a.Equal([]string{"known.com", "local.com"}, plan.Known)
+	a.Equal([]string{"not a domain"}, plan.Invalid)
+	a.False(c.Check("new.com"), "planning should not change Client")
+
+	c.ApplyImport(plan, 0)
+	a.True(c.Check("new.com"))
+}
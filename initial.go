@@ -0,0 +1,58 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+//InitialSync populates a Client's cache when auto syncing starts, see AutoSyncWith
+//strategies must be safe to retry, AutoSyncResilient calls them again after they fail
+type InitialSync func(ctx context.Context, c *Client) error
+
+//InitialFullSync always starts with a full sync, this is what AutoSync does
+func InitialFullSync(ctx context.Context, c *Client) error {
+	return c.FullSync()
+}
+
+//InitialFromStore loads the stored cache, and only fetches recent updates if it was updated within maxAge
+//caches that are missing, empty, or older than maxAge are followed by a full sync instead
+//the store is only loaded into an empty cache, so retrying doesn't discard updates applied since
+//this suits frequently restarted bots, which would otherwise do a full sync on every start
+func InitialFromStore(store Store, maxAge time.Duration) InitialSync {
+	return func(ctx context.Context, c *Client) error {
+		if c.Size() == 0 {
+			err := store.Load(c)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		return updateIfRecent(c, maxAge)
+	}
+}
+
+//InitialFromBootstrap loads the cache from a mirror with Bootstrap, and only fetches recent updates if it was updated within maxAge
+//the mirror is only used when the cache is empty, and falls back to a full sync if it fails or is older than maxAge
+func InitialFromBootstrap(client *http.Client, url string, format CacheFormat, maxAge time.Duration) InitialSync {
+	return func(ctx context.Context, c *Client) error {
+		if c.Size() == 0 {
+			if err := Bootstrap(ctx, c, client, url, format); err != nil {
+				return c.FullSync()
+			}
+		}
+		return updateIfRecent(c, maxAge)
+	}
+}
+
+//updateIfRecent fetches recent updates if the cache was updated within maxAge, or does a full sync otherwise
+func updateIfRecent(c *Client, maxAge time.Duration) error {
+	c.m.Lock()
+	lastUpdated, size := c.lastUpdated, len(c.domains)
+	c.m.Unlock()
+	if size > 0 && !lastUpdated.IsZero() && time.Since(lastUpdated) <= maxAge {
+		return c.Update()
+	}
+	return c.FullSync()
+}
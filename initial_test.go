@@ -0,0 +1,88 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitialFromStore(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, endpointRecent):
+			requests = append(requests, endpointRecent)
+			_, _ = w.Write([]byte(`[{"type":"add","domains":["recent.com"]}]`))
+		case r.URL.Path == endpointAll:
+			requests = append(requests, endpointAll)
+			_, _ = w.Write([]byte(`["full.com"]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		updated  time.Duration
+		stored   bool
+		requests []string
+		domains  []string
+	}{
+		{name: "recent", updated: time.Minute, stored: true, requests: []string{endpointRecent}, domains: []string{"recent.com", "stored.com"}},
+		{name: "stale", updated: time.Hour * 2, stored: true, requests: []string{endpointAll}, domains: []string{"full.com"}},
+		{name: "missing", requests: []string{endpointAll}, domains: []string{"full.com"}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			requests = nil
+			store := NewFileStore(filepath.Join(t.TempDir(), "cache.json"), CacheJSON)
+			if data.stored {
+				stored := New(srv.URL, "test", http.Client{})
+				stored.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"stored.com"}}, SourceFeed)
+				stored.lastUpdated = time.Now().Add(-data.updated)
+				a.NoError(store.Save(stored))
+			}
+
+			c := New(srv.URL, "test", http.Client{})
+			a.NoError(InitialFromStore(store, time.Hour)(context.Background(), c))
+			a.Equal(data.requests, requests)
+			a.ElementsMatch(data.domains, c.Domains())
+		})
+	}
+}
+
+func TestInitialFromBootstrap(t *testing.T) {
+	a := assert.New(t)
+	mirror := New("", "test", http.Client{})
+	mirror.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"mirrored.com"}}, SourceFeed)
+	mirror.lastUpdated = time.Now()
+	var recent int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cache.json":
+			a.NoError(WriteCacheInto(mirror, w))
+		case strings.HasPrefix(r.URL.Path, endpointRecent):
+			recent++
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			a.Fail("unexpected request", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "test", http.Client{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := AutoSyncWith(ctx, c, InitialFromBootstrap(srv.Client(), srv.URL+"/cache.json", CacheJSON, time.Hour), false, 0, 0)
+	a.NoError(err)
+	a.Equal(1, recent)
+	a.Equal([]string{"mirrored.com"}, c.Domains())
+}
@@ -0,0 +1,43 @@
+package sinkingyachts
+
+import "strings"
+
+//arenaChunk is the size of each arena chunk, domains longer than a quarter of it are not interned
+const arenaChunk = 64 << 10
+
+//arena interns domains into large append-only chunks, so the cache holds a few big allocations instead of one per domain
+//this cuts per-string overhead and the amount of objects the GC has to scan for caches with hundreds of thousands of domains
+//interned strings point into a chunk, so a chunk stays alive while any of its domains are referenced,
+//which keeps removed domains around until the cache is rebuilt by a full sync or a load
+//the zero value is ready to use, it's not safe for concurrent use
+type arena struct {
+	b strings.Builder
+}
+
+//intern returns a copy of s that is stored in the arena
+func (a *arena) intern(s string) string {
+	if len(s) == 0 || len(s) > arenaChunk/4 {
+		return s
+	}
+	if a.b.Cap()-a.b.Len() < len(s) {
+		//strings already returned keep pointing to the old chunk, since it's never written to again
+		a.b = strings.Builder{}
+		a.b.Grow(arenaChunk)
+	}
+	a.b.WriteString(s)
+	chunk := a.b.String()
+	return chunk[len(chunk)-len(s):]
+}
+
+//internDomains returns a set of domains that are interned into a new arena
+func internDomains(ds []string) (map[string]empty, *arena) {
+	a := &arena{}
+	dMap := make(map[string]empty, len(ds))
+	for _, d := range ds {
+		if _, found := dMap[d]; found {
+			continue
+		}
+		dMap[a.intern(d)] = empty{}
+	}
+	return dMap, a
+}
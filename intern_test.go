@@ -0,0 +1,46 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	a := assert.New(t)
+	ar := &arena{}
+
+	first := ar.intern("a.com")
+	a.Equal("a.com", first)
+	a.Equal("", ar.intern(""))
+	long := strings.Repeat("a", arenaChunk)
+	a.Equal(long, ar.intern(long))
+
+	//fill a few chunks, earlier strings must not change
+	var interned []string
+	for i := 0; i < arenaChunk/4; i++ {
+		interned = append(interned, ar.intern("b"+strings.Repeat("c", i%16)+".com"))
+	}
+	a.Equal("a.com", first)
+	for i, d := range interned {
+		a.Equal("b"+strings.Repeat("c", i%16)+".com", d)
+	}
+}
+
+func TestInternedCache(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	a.NoError(ReadCacheFrom(c, strings.NewReader(`{"domains":["a.com","b.com","a.com"]}`)))
+	a.NotNil(c.arena)
+	a.Equal(2, c.Size())
+
+	added := []string{"c.com", "a.com"}
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: added}, SourceFeed)
+	a.Equal([]string{"c.com", "a.com"}, added)
+	a.True(c.Check("c.com"))
+	a.Equal(3, c.Size())
+	c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	a.False(c.Check("a.com"))
+	a.ElementsMatch([]string{"b.com", "c.com"}, c.Domains())
+}
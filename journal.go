@@ -0,0 +1,142 @@
+package sinkingyachts
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//Journal is a Publisher that writes every update as a json line of time, source, type and domains
+//use it with PublishUpdates to keep an audit log of every change, for example
+//PublishUpdates(ctx, c, NewJournal(file), 64)
+type Journal struct {
+	w   io.Writer
+	key KeyFunc
+	m   sync.Mutex
+}
+
+//NewJournal creates a Journal that writes into w
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w}
+}
+
+//Publish writes the update as a single json line
+func (j *Journal) Publish(_ context.Context, update AppliedUpdate) error {
+	b, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	if j.key != nil {
+		sealed, err := Encrypt(j.key, b)
+		if err != nil {
+			return err
+		}
+		b = []byte(base64.StdEncoding.EncodeToString(sealed))
+	}
+	j.m.Lock()
+	defer j.m.Unlock()
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
+
+//RotatingFile is an append only file that rotates once it grows past a size limit
+//rotated files are renamed with a numbered suffix, "journal.jsonl.1" being the most recent
+//it is safe for concurrent use
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	keep    int
+	m       sync.Mutex
+	f       *os.File
+	size    int64
+}
+
+//OpenRotatingFile opens or creates the file at path for appending
+//maxSize is the size in bytes after which the file is rotated, keep is the amount of rotated files to keep
+func OpenRotatingFile(path string, maxSize int64, keep int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:    path,
+		maxSize: maxSize,
+		keep:    keep,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//Write appends p to the file, rotating beforehand if it would grow past the size limit
+//a single write is never split across files
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+//Close closes the file
+func (r *RotatingFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+//open opens the file at path for appending
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+//rotate shifts the rotated files by one, dropping the oldest, and starts a new file
+//should only be called when mutex is locked
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	if r.keep <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", r.path, r.keep))
+	for i := r.keep - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
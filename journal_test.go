@@ -0,0 +1,93 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	a := assert.New(t)
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	r, err := OpenRotatingFile(path, 8, 2)
+	a.NoError(err)
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err = r.Write([]byte(line))
+		a.NoError(err)
+	}
+	a.NoError(r.Close())
+
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		a.NoError(err)
+		return string(b)
+	}
+	a.Equal("five\n", read(path))
+	a.Equal("four\n", read(path+".1"))
+	a.Equal("three\n", read(path+".2"))
+	a.NoFileExists(path + ".3")
+}
+
+func TestJournalPublish(t *testing.T) {
+	a := assert.New(t)
+	var buf bytes.Buffer
+	j := NewJournal(&buf)
+	at := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	updates := []AppliedUpdate{
+		{Update: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, Time: at, Source: SourceFeed},
+		{Update: DomainUpdate{Add: false, Domains: []string{"a.com"}}, Time: at.Add(time.Second), Source: SourceRecent},
+	}
+	for _, au := range updates {
+		a.NoError(j.Publish(context.Background(), au))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if a.Len(lines, len(updates)) {
+		for i, line := range lines {
+			var au AppliedUpdate
+			a.NoError(json.Unmarshal([]byte(line), &au))
+			a.Equal(updates[i], au)
+		}
+	}
+
+	//concurrent publishes are written as whole lines
+	buf.Reset()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.NoError(j.Publish(context.Background(), updates[0]))
+		}()
+	}
+	wg.Wait()
+	var read []AppliedUpdate
+	a.NoError(Replay(context.Background(), &buf, 0, func(au AppliedUpdate) error {
+		read = append(read, au)
+		return nil
+	}))
+	a.Len(read, 8)
+}
+
+func TestJournalReplay(t *testing.T) {
+	a := assert.New(t)
+	var buf bytes.Buffer
+	j := NewJournal(&buf)
+	start := time.Now()
+	a.NoError(j.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, Time: start, Source: SourceFeed}))
+	a.NoError(j.Publish(context.Background(), AppliedUpdate{Update: DomainUpdate{Add: false, Domains: []string{"a.com"}}, Time: start.Add(time.Second), Source: SourceRecent}))
+
+	c := New("", "test", http.Client{})
+	began := time.Now()
+	a.NoError(ReplayInto(context.Background(), c, &buf, 20))
+	a.GreaterOrEqual(time.Since(began), time.Millisecond*50)
+	a.Equal([]string{"b.com"}, c.Domains())
+}
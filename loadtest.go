@@ -0,0 +1,197 @@
+package sinkingyachts
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//LoadTestConfig configures LoadTest
+type LoadTestConfig struct {
+	//Concurrency is the amount of goroutines checking concurrently, defaults to 1
+	Concurrency int
+	//Duration is how long to generate load for
+	Duration time.Duration
+	//Domains are the domains to check, picked at random, see LoadMix
+	Domains []string
+	//FuzzyRatio is the fraction of checks from 0 to 1 that use FuzzyCheck instead of Check
+	FuzzyRatio float64
+}
+
+//LoadReport is the result of LoadTest
+type LoadReport struct {
+	//Checks is the amount of checks done
+	Checks int
+	//Hits is the amount of checks that returned true
+	Hits int
+	//Elapsed is how long the test took
+	Elapsed time.Duration
+	//Throughput is checks per second
+	Throughput float64
+	//P50, P90, P99 and Max are the latency percentiles of a single check
+	P50, P90, P99, Max time.Duration
+}
+
+//String formats the report into a single line
+func (r LoadReport) String() string {
+	return fmt.Sprintf("%d checks (%d hits) in %s, %.0f/s, p50 %s p90 %s p99 %s max %s",
+		r.Checks, r.Hits, r.Elapsed, r.Throughput, r.P50, r.P90, r.P99, r.Max)
+}
+
+//LoadTest generates synthetic check load against Client and reports throughput and latency
+//it is meant for sizing hosts before deployment, and runs until the configured duration passes or ctx is cancelled
+func LoadTest(ctx context.Context, c *Client, cfg LoadTestConfig) (LoadReport, error) {
+	if len(cfg.Domains) == 0 {
+		return LoadReport{}, fmt.Errorf("no domains to check")
+	}
+	workers := cfg.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var m sync.Mutex
+	var latencies latencyHistogram
+	hits := 0
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			local := &latencyHistogram{}
+			localHits := 0
+			for ctx.Err() == nil {
+				domain := cfg.Domains[rng.Intn(len(cfg.Domains))]
+				fuzzy := rng.Float64() < cfg.FuzzyRatio
+				began := time.Now()
+				var found bool
+				if fuzzy {
+					found = c.FuzzyCheck(domain)
+				} else {
+					found = c.Check(domain)
+				}
+				local.record(time.Since(began))
+				if found {
+					localHits++
+				}
+			}
+			m.Lock()
+			latencies.merge(local)
+			hits += localHits
+			m.Unlock()
+		}(time.Now().UnixNano() + int64(i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := LoadReport{
+		Checks:     int(latencies.total),
+		Hits:       hits,
+		Elapsed:    elapsed,
+		Throughput: float64(latencies.total) / elapsed.Seconds(),
+	}
+	if latencies.total > 0 {
+		report.P50 = latencies.percentile(0.50)
+		report.P90 = latencies.percentile(0.90)
+		report.P99 = latencies.percentile(0.99)
+		report.Max = latencies.max
+	}
+	return report, nil
+}
+
+//LoadMix builds n domains for LoadTest, where hitRatio of them are subdomains of domains known by Client
+//and the rest are random domains that are unlikely to be known
+func LoadMix(c *Client, n int, hitRatio float64) []string {
+	known := c.Domains()
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	domains := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if len(known) > 0 && rng.Float64() < hitRatio {
+			domains = append(domains, fmt.Sprintf("www%d.%s", rng.Intn(10), known[rng.Intn(len(known))]))
+		} else {
+			domains = append(domains, fmt.Sprintf("cdn%d.example%d.com", rng.Intn(10), rng.Int63()))
+		}
+	}
+	return domains
+}
+
+//histogramSubBits is the log2 of the amount of buckets each power of two is split into by latencyHistogram
+const histogramSubBits = 4
+
+//histogramSubBuckets is the amount of buckets each power of two is split into by latencyHistogram
+const histogramSubBuckets = 1 << histogramSubBits
+
+//latencyHistogram counts latencies into logarithmic buckets, so percentiles of any amount of checks take a fixed amount of memory
+//percentiles are rounded up to the end of their bucket, which is accurate to within about 6%
+type latencyHistogram struct {
+	counts [(64 - histogramSubBits + 1) * histogramSubBuckets]uint64
+	total  uint64
+	max    time.Duration
+}
+
+//record counts a latency
+func (h *latencyHistogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[histogramBucket(d)]++
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+//merge adds the counts of other
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.total += other.total
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+//percentile returns the p-th percentile of the recorded latencies, there must be at least one
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	rank := uint64(float64(h.total-1) * p)
+	var seen uint64
+	for i, count := range h.counts {
+		seen += count
+		if seen > rank {
+			if end := histogramBucketEnd(i); end < h.max {
+				return end
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+//histogramBucket returns the bucket of a latency, durations below histogramSubBuckets nanoseconds get a bucket each
+func histogramBucket(d time.Duration) int {
+	v := uint64(d)
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	sub := (v >> (exp - histogramSubBits)) & (histogramSubBuckets - 1)
+	return (exp-histogramSubBits+1)*histogramSubBuckets + int(sub)
+}
+
+//histogramBucketEnd returns the largest latency in the bucket
+func histogramBucketEnd(i int) time.Duration {
+	if i < histogramSubBuckets {
+		return time.Duration(i)
+	}
+	exp := i/histogramSubBuckets + histogramSubBits - 1
+	sub := uint64(i % histogramSubBuckets)
+	start := (histogramSubBuckets + sub) << (exp - histogramSubBits)
+	return time.Duration(start + 1<<(exp-histogramSubBits) - 1)
+}
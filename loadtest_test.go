@@ -0,0 +1,70 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoadTest(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "evil.com"}}, SourceFeed)
+
+	_, err := LoadTest(context.Background(), c, LoadTestConfig{Duration: time.Millisecond})
+	a.Error(err, "no domains to check")
+
+	report, err := LoadTest(context.Background(), c, LoadTestConfig{
+		Concurrency: 2,
+		Duration:    time.Millisecond * 50,
+		Domains:     LoadMix(c, 100, 0.5),
+		FuzzyRatio:  0.5,
+	})
+	a.NoError(err)
+	a.Greater(report.Checks, 0)
+	a.Greater(report.Hits, 0)
+	a.LessOrEqual(report.Hits, report.Checks)
+	a.Greater(report.Throughput, 0.0)
+	a.LessOrEqual(report.P50, report.P90)
+	a.LessOrEqual(report.P90, report.P99)
+	a.LessOrEqual(report.P99, report.Max)
+	a.Greater(report.Max, time.Duration(0))
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	a := assert.New(t)
+	var h latencyHistogram
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	other := &latencyHistogram{}
+	other.record(time.Second)
+	h.merge(other)
+
+	a.Equal(uint64(1001), h.total)
+	a.Equal(time.Second, h.max)
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.50, time.Microsecond * 500},
+		{0.90, time.Microsecond * 900},
+		{0.99, time.Microsecond * 990},
+	}
+	for _, data := range tests {
+		got := h.percentile(data.p)
+		a.GreaterOrEqual(got, data.want, "p%g", data.p*100)
+		a.LessOrEqual(float64(got), float64(data.want)*1.07, "p%g", data.p*100)
+	}
+	a.Equal(time.Second, h.percentile(1))
+
+	for _, d := range []time.Duration{0, 15, 16, 31, 32, 1000, time.Hour} {
+		i := histogramBucket(d)
+		a.GreaterOrEqual(histogramBucketEnd(i), d)
+		if i > 0 {
+			a.Less(histogramBucketEnd(i-1), d)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package sinkingyachts
+
+import (
+	"sort"
+	"time"
+)
+
+//AddLocal adds domains that are only known locally, such as manual or heuristic blocks
+//local domains are checked like known phishing domains, and are persisted with the cache
+//they expire after ttl, a ttl of 0 never expires, adding an existing local domain replaces its expiry
+//local domains are not affected by syncing, and are not reported to OnUpdate
+//domains are normalized as configured by SetNormalization, so they match the checked domains they're written like
+func (c *Client) AddLocal(ttl time.Duration, domains ...string) {
+	now := time.Now()
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = now.Add(ttl)
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.local == nil {
+		c.local = map[string]time.Time{}
+	}
+	if c.localAdded == nil {
+		c.localAdded = map[string]time.Time{}
+	}
+	for _, domain := range domains {
+		domain = c.normalization.Normalize(domain)
+		c.local[domain] = expiry
+		c.localAdded[domain] = now
+		delete(c.localRemoved, domain)
+	}
+	c.bumpGeneration()
+	c.sendUpdate()
+}
+
+//RemoveLocal removes local domains
+//removals are remembered for 30 days, so they can be merged into other instances, see MergeLocal
+//domains are normalized like AddLocal
+func (c *Client) RemoveLocal(domains ...string) {
+	now := time.Now()
+	c.m.Lock()
+	defer c.m.Unlock()
+	removed := false
+	for _, domain := range domains {
+		domain = c.normalization.Normalize(domain)
+		if _, ok := c.local[domain]; ok {
+			if c.localRemoved == nil {
+				c.localRemoved = map[string]time.Time{}
+			}
+			c.localRemoved[domain] = now
+			delete(c.local, domain)
+			delete(c.localAdded, domain)
+			delete(c.reviews, domain)
+			c.forgetRule(RuleLocal, domain)
+			removed = true
+		}
+	}
+	if removed {
+		c.bumpGeneration()
+	}
+	c.sendUpdate()
+}
+
+//LocalDomains returns local domains mapped to their expiry, a zero expiry never expires
+//expired domains that haven't been swept yet are included
+func (c *Client) LocalDomains() map[string]time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	domains := make(map[string]time.Time, len(c.local))
+	for domain, expiry := range c.local {
+		domains[domain] = expiry
+	}
+	return domains
+}
+
+//SweepExpired removes expired local domains, and returns how many got removed
+//expired domains are never matched by checks, sweeping only frees them, see SweepLocal
+func (c *Client) SweepExpired() int {
+	now := time.Now()
+	c.m.Lock()
+	defer c.m.Unlock()
+	removed := 0
+	for domain, expiry := range c.local {
+		if !expiry.IsZero() && !now.Before(expiry) {
+			delete(c.local, domain)
+			delete(c.localAdded, domain)
+			delete(c.reviews, domain)
+			c.forgetRule(RuleLocal, domain)
+			removed++
+		}
+	}
+	c.sweepTombstones(now)
+	if removed > 0 {
+		c.bumpGeneration()
+		c.sendUpdate()
+	}
+	return removed
+}
+
+//checkLocal checks if domain is an unexpired local domain
+//should only be called when mutex is locked
+func (c *Client) checkLocal(domain string) bool {
+	expiry, ok := c.local[domain]
+	return ok && (expiry.IsZero() || time.Now().Before(expiry))
+}
+
+//LocalReview is a local domain whose review date has passed, see Client.SetLocalReview
+type LocalReview struct {
+	//Domain is the local domain
+	Domain string
+	//Review is when the domain was due for review
+	Review time.Time
+	//Expiry is when the domain expires, it is zero if it never expires
+	Expiry time.Time
+}
+
+//SetLocalReview sets when local domains should be reviewed, domains that are not local are ignored
+//review dates don't affect checks, they remind operators to review manual blocks, so they don't linger forever
+//a zero review clears the review date, review dates are persisted with the cache, see DueReviews
+//domains are normalized like AddLocal
+func (c *Client) SetLocalReview(review time.Time, domains ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, domain := range domains {
+		domain = c.normalization.Normalize(domain)
+		if _, ok := c.local[domain]; !ok {
+			continue
+		}
+		if review.IsZero() {
+			delete(c.reviews, domain)
+			continue
+		}
+		if c.reviews == nil {
+			c.reviews = map[string]time.Time{}
+		}
+		c.reviews[domain] = review
+	}
+	c.sendUpdate()
+}
+
+//OnReviewDue sets fn to be called with local domains once they pass their review date, nil removes it
+//passed review dates are found by DueReviews, and each is reported once, SweepLocal checks for them after every sweep
+func (c *Client) OnReviewDue(fn func(LocalReview)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.onReviewDue = fn
+}
+
+//DueReviews returns the local domains that passed their review date, sorted by review date
+//domains that haven't been reported yet are passed to the OnReviewDue hook
+func (c *Client) DueReviews() []LocalReview {
+	now := time.Now()
+	c.m.Lock()
+	var due, report []LocalReview
+	for domain, review := range c.reviews {
+		if now.Before(review) {
+			continue
+		}
+		lr := LocalReview{Domain: domain, Review: review, Expiry: c.local[domain]}
+		due = append(due, lr)
+		if reported, ok := c.reviewed[domain]; !ok || !reported.Equal(review) {
+			if c.reviewed == nil {
+				c.reviewed = map[string]time.Time{}
+			}
+			c.reviewed[domain] = review
+			report = append(report, lr)
+			c.publish(Event{Kind: EventReviewDue, Review: &lr})
+		}
+	}
+	onDue := c.onReviewDue
+	c.m.Unlock()
+
+	sortReviews(due)
+	sortReviews(report)
+	if onDue != nil {
+		for _, lr := range report {
+			onDue(lr)
+		}
+	}
+	return due
+}
+
+//sortReviews sorts reviews by review date, then by domain
+func sortReviews(reviews []LocalReview) {
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].Review.Equal(reviews[j].Review) {
+			return reviews[i].Review.Before(reviews[j].Review)
+		}
+		return reviews[i].Domain < reviews[j].Domain
+	})
+}
@@ -0,0 +1,112 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLocalDomains(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.AddLocal(0, "forever.com")
+	c.AddLocal(time.Hour, "hour.com")
+	c.AddLocal(time.Nanosecond, "expired.com")
+	time.Sleep(time.Millisecond)
+
+	a.True(c.Check("forever.com"))
+	a.True(c.FuzzyCheck("foo.hour.com"))
+	a.False(c.Check("expired.com"))
+	a.Equal(1, c.SweepExpired())
+	a.Len(c.LocalDomains(), 2)
+
+	var buf bytes.Buffer
+	a.NoError(WriteCacheInto(c, &buf))
+	loaded := New("", "test", http.Client{})
+	a.NoError(ReadCacheFrom(loaded, &buf))
+	a.True(loaded.Check("forever.com"))
+	a.True(loaded.Check("hour.com"))
+
+	c.RemoveLocal("forever.com")
+	a.False(c.Check("forever.com"))
+}
+
+func TestLocalDomainsNormalized(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.SetNormalization(NormalizeLenient)
+	c.AddLocal(0, "Bad.COM.", "xn--bcher-kva.com", "%62ad.net")
+	a.Equal(map[string]time.Time{"bad.com": {}, "xn--bcher-kva.com": {}, "bad.net": {}}, c.LocalDomains())
+	a.True(c.Check("bad.com"))
+	a.True(c.Check("BAD.com"))
+	a.True(c.Check("bücher.com"))
+	a.True(c.Check("bad.net"))
+
+	c.SetLocalReview(time.Now().Add(-time.Hour), "BAD.com")
+	if due := c.DueReviews(); a.Len(due, 1) {
+		a.Equal("bad.com", due[0].Domain)
+	}
+	c.RemoveLocal("Bad.Net.")
+	a.False(c.Check("bad.net"))
+	a.Len(c.LocalDomains(), 2)
+	a.Contains(c.LocalState().Removed, "bad.net")
+}
+
+func TestLocalReviews(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	var reported []string
+	c.OnReviewDue(func(lr LocalReview) {
+		reported = append(reported, lr.Domain)
+	})
+	c.AddLocal(0, "old.com", "older.com", "new.com")
+	past := time.Now().Add(-time.Hour)
+	c.SetLocalReview(past, "old.com", "missing.com")
+	c.SetLocalReview(past.Add(-time.Hour), "older.com")
+	c.SetLocalReview(time.Now().Add(time.Hour), "new.com")
+
+	due := c.DueReviews()
+	a.Len(due, 2)
+	a.Equal("older.com", due[0].Domain)
+	a.Equal("old.com", due[1].Domain)
+	a.Equal([]string{"older.com", "old.com"}, reported)
+	//each review date is only reported once
+	a.Len(c.DueReviews(), 2)
+	a.Len(reported, 2)
+
+	var buf bytes.Buffer
+	a.NoError(WriteCacheInto(c, &buf))
+	loaded := New("", "test", http.Client{})
+	a.NoError(ReadCacheFrom(loaded, &buf))
+	a.Len(loaded.DueReviews(), 2)
+
+	c.SetLocalReview(time.Time{}, "older.com")
+	c.RemoveLocal("old.com")
+	a.Empty(c.DueReviews())
+}
+
+func TestDryRun(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+	var hits []Match
+	c.SetDryRun(true, func(m Match) {
+		hits = append(hits, m)
+	})
+
+	a.False(c.Check("bad.com"))
+	a.False(c.FuzzyCheck("foo.bad.com"))
+	m := c.CheckDetailed("foo.bad.com")
+	a.False(m.Phishing())
+	a.True(m.DryRun)
+	a.Equal("bad.com", m.Matched)
+	a.False(c.Check("good.com"))
+	a.Len(hits, 3)
+	a.Equal(uint64(3), c.Stats().DryRunHits)
+
+	c.SetDryRun(false, nil)
+	a.True(c.Check("bad.com"))
+	a.True(c.CheckDetailed("foo.bad.com").Phishing())
+}
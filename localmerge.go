@@ -0,0 +1,149 @@
+package sinkingyachts
+
+import (
+	"sort"
+	"time"
+)
+
+//localTombstoneTTL is how long removals of local domains are remembered for merging
+//an instance that stays disconnected for longer may bring removed domains back when merged
+const localTombstoneTTL = time.Hour * 24 * 30
+
+//MergeStrategy decides which edit wins when a local domain got added and removed at the same time on different instances
+//edits at different times are always resolved by the last edit winning, the strategy only breaks ties
+type MergeStrategy int
+
+const (
+	//MergeAddWins keeps a domain that was added and removed at the same time, it's the safer default for blocklists
+	MergeAddWins MergeStrategy = iota
+	//MergeRemoveWins drops a domain that was added and removed at the same time
+	MergeRemoveWins
+)
+
+//LocalEntry is a local domain in LocalState
+type LocalEntry struct {
+	//Added is when the domain was last added
+	Added time.Time `json:"added"`
+	//Expiry is when the domain expires, it is zero if it never expires
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+//LocalState is the state of local domains and their removals, it can be sent to other instances to be merged with Client.MergeLocal
+type LocalState struct {
+	//Local are the local domains
+	Local map[string]LocalEntry `json:"local,omitempty"`
+	//Removed are local domains that got removed, mapped to when they got removed
+	Removed map[string]time.Time `json:"removed,omitempty"`
+}
+
+//LocalState returns the state of local domains and their recent removals, for merging into other instances
+func (c *Client) LocalState() LocalState {
+	c.m.Lock()
+	defer c.m.Unlock()
+	state := LocalState{
+		Local:   make(map[string]LocalEntry, len(c.local)),
+		Removed: make(map[string]time.Time, len(c.localRemoved)),
+	}
+	for domain, expiry := range c.local {
+		state.Local[domain] = LocalEntry{Added: c.localAdded[domain], Expiry: expiry}
+	}
+	for domain, removed := range c.localRemoved {
+		state.Removed[domain] = removed
+	}
+	return state
+}
+
+//MergeLocal merges the local domains of another instance into Client, such as after instances edited them while disconnected
+//every domain is resolved on its own, the latest of its additions and removals on either instance wins
+//ties between an addition and a removal are broken by strategy, ties between additions keep the latest expiry
+//merging is deterministic, instances that merge each other's states in any order end up with the same local domains
+//edits are timestamped by the clock of the instance that made them, so clocks should be kept in sync
+//the domains added to and removed from Client are returned as a Diff
+func (c *Client) MergeLocal(other LocalState, strategy MergeStrategy) Diff {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.local == nil {
+		c.local = map[string]time.Time{}
+	}
+	if c.localAdded == nil {
+		c.localAdded = map[string]time.Time{}
+	}
+	if c.localRemoved == nil {
+		c.localRemoved = map[string]time.Time{}
+	}
+
+	var diff Diff
+	for domain, removed := range other.Removed {
+		if removed.After(c.localRemoved[domain]) {
+			c.localRemoved[domain] = removed
+		}
+	}
+	for domain, entry := range other.Local {
+		expiry, local := c.local[domain]
+		if !local {
+			if removed, ok := c.localRemoved[domain]; ok && !addWins(entry.Added, removed, strategy) {
+				continue
+			}
+			c.local[domain] = entry.Expiry
+			c.localAdded[domain] = entry.Added
+			diff.Added = append(diff.Added, domain)
+			continue
+		}
+		added := c.localAdded[domain]
+		if entry.Added.After(added) || entry.Added.Equal(added) && laterExpiry(entry.Expiry, expiry) {
+			c.local[domain] = entry.Expiry
+			c.localAdded[domain] = entry.Added
+		}
+	}
+	for domain, removed := range c.localRemoved {
+		if _, local := c.local[domain]; local && !addWins(c.localAdded[domain], removed, strategy) {
+			delete(c.local, domain)
+			delete(c.localAdded, domain)
+			delete(c.reviews, domain)
+			c.forgetRule(RuleLocal, domain)
+			diff.Removed = append(diff.Removed, domain)
+		}
+	}
+	//a removal that lost to an addition is dropped, so it can't win against an older addition later
+	for domain := range c.local {
+		delete(c.localRemoved, domain)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	if !diff.Empty() {
+		c.bumpGeneration()
+	}
+	c.sendUpdate()
+	return diff
+}
+
+//addWins returns true if an addition wins against a removal
+func addWins(added, removed time.Time, strategy MergeStrategy) bool {
+	if added.Equal(removed) {
+		return strategy == MergeAddWins
+	}
+	return added.After(removed)
+}
+
+//laterExpiry returns true if expiry a is later than expiry b, a zero expiry never expires and is the latest
+func laterExpiry(a, b time.Time) bool {
+	switch {
+	case a.IsZero():
+		return !b.IsZero()
+	case b.IsZero():
+		return false
+	default:
+		return a.After(b)
+	}
+}
+
+//sweepTombstones forgets removals of local domains older than localTombstoneTTL
+//should only be called when mutex is locked
+func (c *Client) sweepTombstones(now time.Time) {
+	for domain, removed := range c.localRemoved {
+		if now.Sub(removed) > localTombstoneTTL {
+			delete(c.localRemoved, domain)
+		}
+	}
+}
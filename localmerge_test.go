@@ -0,0 +1,77 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMergeLocal(t *testing.T) {
+	a := assert.New(t)
+	t0 := time.Now().Add(-time.Hour)
+	at := func(minutes int) time.Time {
+		return t0.Add(time.Duration(minutes) * time.Minute)
+	}
+	first := LocalState{
+		Local: map[string]LocalEntry{
+			"both.com":    {Added: at(1)},
+			"expiry.com":  {Added: at(1), Expiry: at(120)},
+			"re-add.com":  {Added: at(5)},
+			"tie.com":     {Added: at(3)},
+			"only-a.com":  {Added: at(1)},
+			"removed.com": {Added: at(1)},
+		},
+	}
+	second := LocalState{
+		Local: map[string]LocalEntry{
+			"both.com":   {Added: at(2)},
+			"expiry.com": {Added: at(1)},
+		},
+		Removed: map[string]time.Time{
+			"removed.com": at(2),
+			"re-add.com":  at(4),
+			"tie.com":     at(3),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		expected []string
+	}{
+		{"Add wins", MergeAddWins, []string{"both.com", "expiry.com", "only-a.com", "re-add.com", "tie.com"}},
+		{"Remove wins", MergeRemoveWins, []string{"both.com", "expiry.com", "only-a.com", "re-add.com"}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			//merging in either order converges
+			for _, order := range [][]LocalState{{first, second}, {second, first}} {
+				c := New("", "test", http.Client{})
+				c.MergeLocal(order[0], data.strategy)
+				c.MergeLocal(order[1], data.strategy)
+				var domains []string
+				for domain := range c.LocalDomains() {
+					domains = append(domains, domain)
+				}
+				a.ElementsMatch(data.expected, domains)
+				state := c.LocalState()
+				a.True(state.Local["both.com"].Added.Equal(at(2)))
+				a.True(state.Local["expiry.com"].Expiry.IsZero())
+			}
+		})
+	}
+
+	c := New("", "test", http.Client{})
+	c.MergeLocal(first, MergeAddWins)
+	diff := c.MergeLocal(second, MergeAddWins)
+	a.Equal([]string{"removed.com"}, diff.Removed)
+	a.Empty(diff.Added)
+
+	c.RemoveLocal("only-a.com")
+	other := New("", "test", http.Client{})
+	other.MergeLocal(first, MergeAddWins)
+	diff = other.MergeLocal(c.LocalState(), MergeAddWins)
+	a.Equal([]string{"only-a.com", "removed.com"}, diff.Removed)
+}
@@ -0,0 +1,456 @@
+package sinkingyachts
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//shutdownTimeout bounds how long Manager waits for the feed to stop before the final save
+const shutdownTimeout = time.Second * 10
+
+//Manager runs a Client wired up as described by a Config
+//it keeps the cache synced, persisted, and served, see Manager.Run
+type Manager struct {
+	cfg          Config
+	client       *Client
+	store        Store
+	publisher    *SnapshotPublisher
+	bootstrapKey ed25519.PublicKey
+	m            sync.Mutex
+	reloaded     chan struct{}
+	lastSync     time.Time
+	onError      func(error)
+}
+
+//NewManager creates a Manager from a Config, nothing is started until Manager.Run
+func NewManager(cfg Config) (*Manager, error) {
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		cfg:      cfg,
+		client:   client,
+		reloaded: make(chan struct{}),
+	}
+	if cfg.Store.Path != "" {
+		format, _ := cfg.Store.format()
+		fs := NewFileStore(cfg.Store.Path, format)
+		fs.EnableBackups(BackupPolicy{
+			Keep:     cfg.Store.Backups,
+			Interval: time.Duration(cfg.Store.BackupInterval),
+			Compress: cfg.Store.CompressBackups,
+		})
+		if cfg.Store.KeyEnv != "" {
+			fs.EnableEncryption(EnvKey(cfg.Store.KeyEnv))
+		}
+		m.store = fs
+	}
+	webClient := NewHTTPClient(cfg.timeout())
+	m.publisher, err = cfg.Publish.publisher(&webClient)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Bootstrap.Key != "" {
+		m.bootstrapKey, err = LoadVerifyKey(cfg.Bootstrap.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+//Client returns the managed Client
+func (m *Manager) Client() *Client {
+	return m.client
+}
+
+//Store returns the configured Store, or nil if the cache isn't persisted
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+//OnError registers fn to be called with errors that Run recovers from, replacing the previous fn
+//such as a failed bootstrap, which falls back to a full sync, or a feed disconnect, which is reported as a SyncError and reconnected
+func (m *Manager) OnError(fn func(error)) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.onError = fn
+}
+
+//reportError passes err to the fn registered with OnError, if any
+func (m *Manager) reportError(err error) {
+	m.m.Lock()
+	fn := m.onError
+	m.m.Unlock()
+	if fn != nil {
+		fn(err)
+	}
+}
+
+//Run loads the stored cache, or bootstraps an empty cache from the mirror, and syncs it, then syncs, persists, serves and sweeps the cache as configured
+//this function blocks and returns only when cancelled by ctx, or when any of them fails
+//the feed is reconnected with a growing delay instead, and only stops Run on a fatal error, see AutoSyncResilient
+//the cache is saved into the store one last time before returning
+func (m *Manager) Run(ctx context.Context) error {
+	if m.store != nil {
+		err := m.store.Load(m.client)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	var l net.Listener
+	if m.cfg.Query.Address != "" {
+		network := m.cfg.Query.Network
+		if network == "" {
+			network = "unix"
+		}
+		var err error
+		l, err = net.Listen(network, m.cfg.Query.Address)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch {
+	case m.cfg.Sync.Delta:
+		err = m.client.DeltaSync()
+	case m.bootstrap(ctx):
+		err = m.client.Update()
+	default:
+		err = m.client.FullSync()
+	}
+	if err != nil {
+		if l != nil {
+			_ = l.Close()
+		}
+		return err
+	}
+	m.synced()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				errs <- err
+				cancel()
+			}
+		}()
+	}
+
+	if m.cfg.Sync.Realtime {
+		//the feed is reconnected like in AutoSyncResilient, only a fatal error stops Run
+		run(func() error {
+			return listenResilient(ctx, m.client, ResilientSync{OnError: func(err SyncError) {
+				m.reportError(err)
+			}})
+		})
+	}
+	run(func() error {
+		return m.syncLoop(ctx)
+	})
+	if m.store != nil {
+		run(func() error {
+			return m.saveOnChange(ctx)
+		})
+	}
+	if l != nil {
+		run(func() error {
+			return ServeQueries(ctx, l, m.client)
+		})
+	}
+	if m.publisher != nil {
+		interval := time.Duration(m.cfg.Publish.Interval)
+		if interval <= 0 {
+			interval = time.Minute * 10
+		}
+		run(func() error {
+			return PublishSnapshots(ctx, m.client, m.publisher, interval)
+		})
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+	select {
+	case err = <-errs:
+	default:
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if errShutdown := m.client.Shutdown(shutdownCtx, m.store); err == nil {
+		err = errShutdown
+	}
+	return err
+}
+
+//bootstrap loads an empty cache from the configured mirror
+//returns true if the mirrored cache is recent enough to only fetch recent updates instead of a full sync
+//a failed bootstrap is reported to OnError, and returns false to fall back to a full sync
+func (m *Manager) bootstrap(ctx context.Context) bool {
+	cfg := m.cfg.Bootstrap
+	if cfg.URL == "" || m.client.Size() > 0 {
+		return false
+	}
+	client := NewHTTPClient(m.cfg.timeout())
+	format, _ := cfg.format()
+	var err error
+	if m.bootstrapKey != nil {
+		err = bootstrapVerified(ctx, m.client, &client, cfg.URL, format, m.bootstrapKey, cfg.maxSize())
+	} else {
+		err = bootstrap(ctx, m.client, &client, cfg.URL, format, cfg.maxSize())
+	}
+	if err != nil {
+		m.reportError(fmt.Errorf("bootstrap from %s failed, falling back to a full sync: %w", cfg.URL, err))
+		return false
+	}
+	maxAge := time.Duration(cfg.MaxAge)
+	if maxAge <= 0 {
+		maxAge = time.Hour
+	}
+	m.client.m.Lock()
+	defer m.client.m.Unlock()
+	return !m.client.lastUpdated.IsZero() && time.Since(m.client.lastUpdated) <= maxAge
+}
+
+//saveOnChange saves the cache into the store whenever it changes
+func (m *Manager) saveOnChange(ctx context.Context) error {
+	changed := make(chan struct{}, 1)
+	remove := m.client.OnUpdate(func(AppliedUpdate) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer remove()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+			err := m.store.Save(m.client)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//syncLoop periodically updates, full syncs and sweeps the cache at the configured intervals and schedule
+//the intervals are picked up again whenever the Manager is reloaded
+//failed syncs stop the loop once more than the configured max failures failed in a row
+func (m *Manager) syncLoop(ctx context.Context) error {
+	for {
+		cfg, reloaded := m.current()
+		tolerate := func(err error) error {
+			m.client.m.Lock()
+			failures := m.client.syncFailures
+			m.client.m.Unlock()
+			if failures > cfg.Sync.MaxFailures {
+				return err
+			}
+			return nil
+		}
+		recentTick, stopRecent := tick(time.Duration(cfg.Sync.RecentInterval))
+		fullSyncTick, stopFullSync := tick(time.Duration(cfg.Sync.FullSyncInterval))
+		schedule, _ := cfg.Sync.schedule()
+		scheduledTick, stopScheduled := scheduleTick(schedule)
+		sweepTick, stopSweep := tick(time.Duration(cfg.SweepInterval))
+		fullSync := func() error {
+			if m.client.Paused() {
+				return nil
+			}
+			if err := m.client.FullSync(); err != nil {
+				return tolerate(err)
+			}
+			m.synced()
+			return nil
+		}
+		err := func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-reloaded:
+					return nil
+				case <-recentTick:
+					if m.client.Paused() {
+						continue
+					}
+					update := m.client.Update
+					if cfg.Sync.Delta {
+						update = m.client.DeltaSync
+					}
+					if err := update(); err != nil {
+						if err = tolerate(err); err != nil {
+							return err
+						}
+						continue
+					}
+					m.synced()
+				case <-fullSyncTick:
+					if err := fullSync(); err != nil {
+						return err
+					}
+				case <-scheduledTick:
+					if err := fullSync(); err != nil {
+						return err
+					}
+				case <-sweepTick:
+					m.client.SweepExpired()
+					m.client.DueReviews()
+				}
+			}
+		}()
+		stopRecent()
+		stopFullSync()
+		stopScheduled()
+		stopSweep()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+//synced records a successful sync with the api
+func (m *Manager) synced() {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.lastSync = time.Now()
+}
+
+//Config returns the Config the Manager currently runs with, including reloaded settings
+func (m *Manager) Config() Config {
+	cfg, _ := m.current()
+	return cfg
+}
+
+//current returns the current Config, and a channel that is closed when it gets reloaded
+func (m *Manager) current() (Config, <-chan struct{}) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.cfg, m.reloaded
+}
+
+//Reload applies the reloadable settings of cfg without dropping the cache or the feed connection
+//categories, metadata, regex rules, the anomaly guard, sync intervals, the sync schedule, the sweep interval and the log level can be reloaded
+//an error is returned without applying anything if cfg changes other settings, as those need a restart
+func (m *Manager) Reload(cfg Config) error {
+	err := cfg.validate()
+	if err != nil {
+		return err
+	}
+	m.m.Lock()
+	defer m.m.Unlock()
+	if field := fixedChange(m.cfg, cfg); field != "" {
+		return fmt.Errorf("config: %s can't be reloaded, restart instead", field)
+	}
+	if err = m.client.SetRegexRules(cfg.RegexRules...); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	m.client.FilterCategories(cfg.Categories...)
+	m.client.SetAnomalyGuard(cfg.Anomaly)
+	if cfg.Metadata {
+		m.client.EnableMetadata()
+	}
+	m.cfg = cfg
+	close(m.reloaded)
+	m.reloaded = make(chan struct{})
+	return nil
+}
+
+//fixedChange returns the json name of the first setting that differs and can't be reloaded, or empty if none
+func fixedChange(old, new Config) string {
+	switch {
+	case old.Endpoint != new.Endpoint:
+		return "endpoint"
+	case old.Identity != new.Identity:
+		return "identity"
+	case old.Timeout != new.Timeout:
+		return "timeout"
+	case old.FeedTimeout != new.FeedTimeout:
+		return "feed_timeout"
+	case old.FeedMessageTimeout != new.FeedMessageTimeout:
+		return "feed_message_timeout"
+	case old.FeedPing != new.FeedPing || old.FeedPingTimeout != new.FeedPingTimeout:
+		return "feed_ping"
+	case old.RecentWindow != new.RecentWindow:
+		return "recent_window"
+	case old.FeedBuffer != new.FeedBuffer:
+		return "feed_buffer"
+	case old.FeedBackpressure != new.FeedBackpressure || old.FeedConsumerTimeout != new.FeedConsumerTimeout:
+		return "feed_backpressure"
+	case old.FeedProtocol != new.FeedProtocol:
+		return "feed_protocol"
+	case !reflect.DeepEqual(old.Headers, new.Headers):
+		return "headers"
+	case old.StrictValidation != new.StrictValidation:
+		return "strict_validation"
+	case old.Metadata && !new.Metadata:
+		return "metadata"
+	case old.MaxEntries != new.MaxEntries:
+		return "max_entries"
+	case old.Privacy != new.Privacy:
+		return "privacy"
+	case old.Normalization != new.Normalization:
+		return "normalization"
+	case !reflect.DeepEqual(old.Alerts, new.Alerts):
+		return "alerts"
+	case old.Transport != new.Transport:
+		return "transport"
+	case old.Sync.Realtime != new.Sync.Realtime:
+		return "sync.realtime"
+	case old.Store != new.Store:
+		return "store"
+	case old.Query != new.Query:
+		return "query"
+	case old.Publish != new.Publish:
+		return "publish"
+	case old.Bootstrap != new.Bootstrap:
+		//the cache is only bootstrapped once Run starts
+		return "bootstrap"
+	}
+	return ""
+}
+
+//ReloadOnSignal reloads the Manager from the config file at path whenever the process receives SIGHUP
+//errors from loading or applying the config are passed to onError, which may be nil, and the previous config is kept
+//this function blocks and returns only when cancelled by ctx
+func (m *Manager) ReloadOnSignal(ctx context.Context, path string, onError func(error)) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			cfg, err := LoadConfig(path)
+			if err == nil {
+				err = m.Reload(cfg)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+//Match is the detailed result of checking a domain
+type Match struct {
+	//Domain is the checked domain
+	Domain string
+	//Matched is the known phishing domain that matched, which may be a parent of Domain
+	//it is empty if Domain is not phishing
+	Matched string
+	//Rule is the regex rule that matched, it is empty if a known or local domain matched
+	Rule string
+	//Category is the kind of threat Matched is, it is DefaultCategory unless metadata says otherwise
+	//it is empty if Domain is not phishing
+	Category string
+	//Metadata is the metadata of Matched, it is only set if metadata is enabled and known
+	Metadata *Metadata
+	//DNS is the resolved records of Matched, it is only set by EnrichDNS
+	DNS *DNSRecords
+	//DryRun is true if the domain matched while Client is in dry run mode, so it should not be acted upon
+	DryRun bool
+}
+
+//Phishing returns true if the checked domain is phishing and should be acted upon
+//matches in dry run mode are not considered phishing
+func (m Match) Phishing() bool {
+	return m.Matched != "" && !m.DryRun
+}
+
+//CheckOpts overrides how Lookup checks a domain, the zero value checks exactly like Check
+type CheckOpts struct {
+	//Fuzzy also checks the parent domains, like FuzzyCheck
+	Fuzzy bool
+	//PublicSuffix stops fuzzy checks at the public suffix of the domain instead of the top level domain, see VariantPublicSuffix
+	//nil checks every parent domain but the top level domain
+	PublicSuffix func(domain string) (string, bool)
+	//SkipLocal only matches domains known to the api, ignoring local domains and regex rules
+	SkipLocal bool
+}
+
+//CheckDetailed fuzzy checks a domain like FuzzyCheck, and returns which domain matched
+//Domain of the Match is the domain as it was given, before normalization
+func (c *Client) CheckDetailed(domain string) Match {
+	return c.Lookup(domain, CheckOpts{Fuzzy: true})
+}
+
+//Lookup checks a domain as configured by opts, and returns which domain matched
+//it's what Check, FuzzyCheck and CheckDetailed are built on, so any combination of their behaviors can be chosen per call
+//Domain of the Match is the domain as it was given, before normalization
+func (c *Client) Lookup(domain string, opts CheckOpts) Match {
+	m := Match{Domain: domain}
+	variants := []string{c.normalize(domain)}
+	if opts.Fuzzy {
+		var vo []VariantOption
+		if opts.PublicSuffix != nil {
+			vo = append(vo, VariantPublicSuffix(opts.PublicSuffix))
+		}
+		variants = GenerateVariants(variants[0], vo...)
+	}
+	for _, part := range variants {
+		if found, local := c.lookup(part, !opts.SkipLocal); found {
+			if local {
+				c.countRuleHit(RuleLocal, part)
+			}
+			m.Matched = part
+			m.Category = DefaultCategory
+			if md, ok := c.Metadata(part); ok {
+				m.Metadata = &md
+				if md.Category != "" {
+					m.Category = md.Category
+				}
+			}
+			observed := m
+			observed.DryRun = true
+			m.DryRun = c.observeDryRun(observed)
+			return m
+		}
+	}
+	if opts.SkipLocal {
+		return m
+	}
+	//regex rules are only evaluated once no known or local domain matched, as they are the most expensive
+	for _, part := range variants {
+		if rule := c.matchRegex(part); rule != "" {
+			m.Matched = part
+			m.Rule = rule
+			m.Category = DefaultCategory
+			observed := m
+			observed.DryRun = true
+			m.DryRun = c.observeDryRun(observed)
+			return m
+		}
+	}
+	return m
+}
+
+//DNSRecords are the resolved records of a domain
+type DNSRecords struct {
+	//A is the IPv4 addresses of the domain
+	A []net.IP
+	//AAAA is the IPv6 addresses of the domain
+	AAAA []net.IP
+	//NS is the name servers of the domain
+	NS []string
+}
+
+//Live returns true if the domain resolves to any address
+//domains that don't resolve are likely parked or taken down
+func (r DNSRecords) Live() bool {
+	return len(r.A) > 0 || len(r.AAAA) > 0
+}
+
+//EnrichDNS resolves the matched domain of m, and sets the result into m.DNS
+//it is a no op if m is not phishing, resolver may be nil to use net.DefaultResolver
+//domains that don't exist are not an error, and result in empty records
+func EnrichDNS(ctx context.Context, resolver *net.Resolver, m *Match) error {
+	if !m.Phishing() {
+		return nil
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	var records DNSRecords
+	addrs, err := resolver.LookupIPAddr(ctx, m.Matched)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	for _, addr := range addrs {
+		if ip4 := addr.IP.To4(); ip4 != nil {
+			records.A = append(records.A, ip4)
+		} else {
+			records.AAAA = append(records.AAAA, addr.IP)
+		}
+	}
+	ns, err := resolver.LookupNS(ctx, m.Matched)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	for _, n := range ns {
+		records.NS = append(records.NS, n.Host)
+	}
+	m.DNS = &records
+	return nil
+}
+
+//isNotFound checks if err is a dns error of a missing domain or record
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
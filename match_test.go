@@ -0,0 +1,163 @@
+package sinkingyachts
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "evil.co.uk"}}, SourceFeed)
+	c.AddLocal(0, "local.com")
+	assert.NoError(t, c.SetRegexRules(`rule-[0-9]+\.com`))
+	suffix := func(domain string) (string, bool) {
+		if strings.HasSuffix(domain, ".co.uk") {
+			return "co.uk", true
+		}
+		return domain[strings.LastIndex(domain, ".")+1:], true
+	}
+
+	tests := []struct {
+		name    string
+		domain  string
+		opts    CheckOpts
+		matched string
+		rule    bool
+	}{
+		{name: "exact", domain: "bad.com", matched: "bad.com"},
+		{name: "unknown", domain: "good.com", opts: CheckOpts{Fuzzy: true}},
+		{name: "parent not fuzzy", domain: "foo.bad.com"},
+		{name: "parent fuzzy", domain: "foo.bad.com", opts: CheckOpts{Fuzzy: true}, matched: "bad.com"},
+		{name: "local", domain: "local.com", matched: "local.com"},
+		{name: "local skipped", domain: "local.com", opts: CheckOpts{SkipLocal: true}},
+		{name: "rule", domain: "foo.rule-1.com", opts: CheckOpts{Fuzzy: true}, matched: "rule-1.com", rule: true},
+		{name: "rule skipped", domain: "foo.rule-1.com", opts: CheckOpts{Fuzzy: true, SkipLocal: true}},
+		{name: "known not skipped", domain: "foo.bad.com", opts: CheckOpts{Fuzzy: true, SkipLocal: true}, matched: "bad.com"},
+		{name: "public suffix", domain: "foo.evil.co.uk", opts: CheckOpts{Fuzzy: true, PublicSuffix: suffix}, matched: "evil.co.uk"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			m := c.Lookup(data.domain, data.opts)
+			a.Equal(data.domain, m.Domain)
+			a.Equal(data.matched, m.Matched)
+			a.Equal(data.rule, m.Rule != "")
+			a.Equal(data.matched != "", m.Phishing())
+		})
+	}
+}
+
+func TestLookupPublicSuffix(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"co.uk"}}, SourceFeed)
+	a.True(c.FuzzyCheck("foo.co.uk"))
+	a.False(c.Lookup("foo.co.uk", CheckOpts{Fuzzy: true, PublicSuffix: func(domain string) (string, bool) {
+		return "co.uk", true
+	}}).Phishing())
+}
+
+//stubResolver returns a resolver answering from records, keyed by the encoded dns name and the record type
+//names without records are answered with NXDOMAIN, and "\x06broken\x04test\x00" with SERVFAIL
+func stubResolver(records map[string]map[uint16][][]byte) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go func() {
+				defer server.Close()
+				for {
+					var size uint16
+					if binary.Read(server, binary.BigEndian, &size) != nil {
+						return
+					}
+					msg := make([]byte, size)
+					if _, err := io.ReadFull(server, msg); err != nil {
+						return
+					}
+					end, _ := skipDNSName(msg, 12)
+					name := string(msg[12:end])
+					qtype := binary.BigEndian.Uint16(msg[end:])
+					//the header and question are echoed back, leaving out any additional records of the query
+					resp := append([]byte(nil), msg[:end+4]...)
+					resp[2] |= 0x80
+					resp[3] = 0x80
+					binary.BigEndian.PutUint16(resp[6:], 0)
+					binary.BigEndian.PutUint16(resp[8:], 0)
+					binary.BigEndian.PutUint16(resp[10:], 0)
+					switch {
+					case name == "\x06broken\x04test\x00":
+						resp[3] |= 2
+					case records[name] == nil:
+						resp[3] |= 3
+					default:
+						for _, rdata := range records[name][qtype] {
+							resp = append(resp, 0xc0, 12)
+							resp = append(resp, byte(qtype>>8), byte(qtype), 0, 1, 0, 0, 0, 60, byte(len(rdata)>>8), byte(len(rdata)))
+							resp = append(resp, rdata...)
+							resp[7]++
+						}
+					}
+					if _, err := server.Write(append([]byte{byte(len(resp) >> 8), byte(len(resp))}, resp...)); err != nil {
+						return
+					}
+				}
+			}()
+			return client, nil
+		},
+	}
+}
+
+func TestEnrichDNS(t *testing.T) {
+	const dnsTypeNS = 2
+	resolver := stubResolver(map[string]map[uint16][][]byte{
+		"\x04evil\x04test\x00": {
+			dnsTypeA:    {{192, 0, 2, 1}},
+			dnsTypeAAAA: {net.ParseIP("2001:db8::1")},
+			dnsTypeNS:   {[]byte("\x03ns1\x04evil\x04test\x00")},
+		},
+	})
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"evil.test", "gone.test", "broken.test"}}, SourceFeed)
+
+	tests := []struct {
+		name    string
+		domain  string
+		matched string
+		dns     *DNSRecords
+		err     bool
+	}{
+		{name: "live", domain: "www.evil.test", matched: "evil.test", dns: &DNSRecords{
+			A:    []net.IP{net.IPv4(192, 0, 2, 1).To4()},
+			AAAA: []net.IP{net.ParseIP("2001:db8::1")},
+			NS:   []string{"ns1.evil.test."},
+		}},
+		{name: "not found", domain: "gone.test", matched: "gone.test", dns: &DNSRecords{}},
+		{name: "not phishing", domain: "good.test"},
+		{name: "server failure", domain: "broken.test", matched: "broken.test", err: true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			m := c.CheckDetailed(data.domain)
+			a.Equal(data.matched, m.Matched)
+			err := EnrichDNS(context.Background(), resolver, &m)
+			if data.err {
+				a.Error(err)
+				a.Nil(m.DNS)
+				return
+			}
+			a.NoError(err)
+			a.Equal(data.dns, m.DNS)
+			if m.DNS != nil {
+				a.Equal(len(data.dns.A) > 0, m.DNS.Live())
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package sinkingyachts
+
+import "time"
+
+//Metadata is additional information about a known domain
+type Metadata struct {
+	//AddedAt is when the domain was first seen by Client
+	AddedAt time.Time `json:"added_at"`
+	//Source is where the domain was first seen from
+	Source UpdateSource `json:"source,omitempty"`
+	//Category is the kind of threat, such as "phishing", if known
+	Category string `json:"category,omitempty"`
+}
+
+//EnableMetadata starts tracking Metadata of domains as they get added
+//metadata is opt-in as it costs additional memory per domain, domains that are already known have no metadata
+//metadata is persisted with the cache, and loading a cache with metadata enables it
+func (c *Client) EnableMetadata() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.meta == nil {
+		c.meta = map[string]Metadata{}
+	}
+}
+
+//Metadata returns the Metadata of a known domain
+//false is returned if the domain is unknown, has no metadata, or metadata is not enabled
+func (c *Client) Metadata(domain string) (Metadata, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	md, ok := c.meta[domain]
+	return md, ok
+}
+
+//trackMeta records metadata of an applied update if metadata is enabled
+//should only be called when mutex is locked
+func (c *Client) trackMeta(mod DomainUpdate, source UpdateSource) {
+	if c.meta == nil {
+		return
+	}
+	now := time.Now()
+	for _, domain := range mod.Domains {
+		if !mod.Add {
+			delete(c.meta, domain)
+			continue
+		}
+		if _, ok := c.meta[domain]; !ok {
+			c.meta[domain] = Metadata{
+				AddedAt:  now,
+				Source:   source,
+				Category: mod.category(),
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"old.com"}}, SourceFeed)
+	c.EnableMetadata()
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"new.com"}}, SourceWebhook)
+
+	_, ok := c.Metadata("old.com")
+	a.False(ok)
+	md, ok := c.Metadata("new.com")
+	a.True(ok)
+	a.Equal(SourceWebhook, md.Source)
+	a.False(md.AddedAt.IsZero())
+
+	m := c.CheckDetailed("foo.new.com")
+	a.Equal("new.com", m.Matched)
+	a.Equal(&md, m.Metadata)
+
+	for _, format := range []CacheFormat{CacheJSON, CacheMsgpack} {
+		var buf bytes.Buffer
+		a.NoError(WriteCacheFormat(c, &buf, format))
+		loaded := New("", "test", http.Client{})
+		a.NoError(ReadCacheFormat(loaded, &buf, format))
+		loadedMd, ok := loaded.Metadata("new.com")
+		a.True(ok)
+		a.Equal(md.Source, loadedMd.Source)
+		a.True(md.AddedAt.Equal(loadedMd.AddedAt))
+	}
+
+	c.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"new.com"}}, SourceFeed)
+	_, ok = c.Metadata("new.com")
+	a.False(ok)
+}
+
+func TestFilterCategories(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.EnableMetadata()
+	c.FilterCategories("malware")
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"phish.com"}}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"scam.com"}, Category: "scam"}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"malware.com"}, Category: "malware"}, SourceFeed)
+
+	a.False(c.Check("phish.com"), "updates without a category are of the default category")
+	a.False(c.Check("scam.com"))
+	a.True(c.Check("malware.com"))
+	a.Equal("malware", c.CheckDetailed("malware.com").Category)
+	a.Equal("malware", c.CheckDetailed("malware.com").Metadata.Category)
+
+	c.FilterCategories()
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"phish.com"}}, SourceFeed)
+	a.Equal(DefaultCategory, c.CheckDetailed("phish.com").Category)
+	a.Empty(c.CheckDetailed("good.com").Category)
+
+	c.FilterCategories(DefaultCategory)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"other.com"}}, SourceFeed)
+	a.True(c.Check("other.com"))
+}
+
+func TestFilterCategoriesFullSync(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["a.com","b.com"]`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL, "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "old.com"}}, SourceFeed)
+	c.FilterCategories("malware")
+
+	//the full list is of the default category, so only known domains are kept and removed ones are dropped
+	a.NoError(c.FullSync())
+	a.Equal(1, c.Size())
+	a.True(c.Check("a.com"))
+	a.False(c.Check("b.com"))
+	a.False(c.Check("old.com"))
+}
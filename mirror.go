@@ -0,0 +1,181 @@
+package sinkingyachts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//mirrorHistory is the amount of updates NewMirror keeps for recent updates
+const mirrorHistory = 10000
+
+//mirrorFeedBuffer is the amount of updates queued per feed subscriber before it's disconnected
+const mirrorFeedBuffer = 256
+
+//Mirror serves Client's cache over the same http endpoints as the api, so a fleet can sync from a single instance
+//point other instances at it with New("http://mirror:8080", identity, client)
+//the following endpoints are served
+//  GET /v2/all/ returns every known domain
+//  GET /v2/recent/<seconds> returns the updates of the last seconds, from the Client's history
+//    410 Gone is returned if the history doesn't reach back that far, followers should do a FullSync instead
+//  GET /v2/check/<domain> returns if the domain is phishing, including local domains
+//  GET /v2/dbsize/ returns the amount of known domains
+//  GET /delta?instance=<instance>&since=<generation> returns the changes since a generation as Delta, see Client.DeltaSync
+//  GET /hash returns the canonical hash of the cache, see Client.Hash
+//  GET /repair returns the hashes of the repair buckets, or with ?buckets=1,2 the domains of the buckets, see Client.Repair
+//  GET /export/<name> returns the domains in the format of the named exporter
+//  GET /feed streams every applied update over websocket in the format of the api's feed, or a negotiated Codec
+//    a subscriber that falls behind by more than 256 updates is disconnected, without slowing down the others
+//the Mirror itself doesn't authenticate, wrap it with BearerAuth and a RateLimiter to expose it beyond localhost
+//full lists carry an ETag and Last-Modified derived from the generation of the cache, and honor conditional requests
+type Mirror struct {
+	//Exporters are the exporters served by name under /export/, NewMirror adds the built-in exporters
+	Exporters map[string]Exporter
+
+	c        *Client
+	instance string
+	mux      *http.ServeMux
+	feed     *Replicator
+}
+
+//NewMirror creates a Mirror of Client
+//it enables a history of 10000 updates for recent updates, unless history is already enabled
+func NewMirror(c *Client) *Mirror {
+	if !c.historyEnabled() {
+		c.EnableHistory(mirrorHistory)
+	}
+	id := make([]byte, 4)
+	_, _ = rand.Read(id)
+	m := &Mirror{
+		Exporters: map[string]Exporter{
+			"text":    ExportText,
+			"csv":     ExportCSV,
+			"stix":    STIXExporter{},
+			"misp":    MISPExporter{},
+			"squid":   ExportSquid,
+			"envoy":   ExportEnvoy,
+			"unbound": ExportUnbound,
+			"knot":    ExportKnot,
+		},
+		c:        c,
+		instance: hex.EncodeToString(id),
+		mux:      http.NewServeMux(),
+		feed:     newReplicator(c, "", mirrorFeedBuffer, false),
+	}
+	m.mux.HandleFunc(endpointAll, adminMethod(http.MethodGet, m.serveAll))
+	m.mux.HandleFunc(endpointRecent, adminMethod(http.MethodGet, m.serveRecent))
+	m.mux.HandleFunc(endpointCheck, adminMethod(http.MethodGet, m.serveCheck))
+	m.mux.HandleFunc(endpointSize, adminMethod(http.MethodGet, m.serveSize))
+	m.mux.HandleFunc(endpointDelta, adminMethod(http.MethodGet, m.serveDelta))
+	m.mux.HandleFunc(endpointHash, adminMethod(http.MethodGet, m.serveHash))
+	m.mux.HandleFunc(endpointRepair, adminMethod(http.MethodGet, m.serveRepair))
+	m.mux.HandleFunc("/export/", adminMethod(http.MethodGet, m.serveExport))
+	m.mux.Handle(endpointFeed, m.feed)
+	return m
+}
+
+//Close disconnects all feed subscribers, and stops broadcasting updates to them
+func (m *Mirror) Close() error {
+	return m.feed.Close()
+}
+
+//SetFeedCodecs sets the codecs feed subscribers can negotiate, see Replicator.SetCodecs
+func (m *Mirror) SetFeedCodecs(codecs ...Codec) {
+	m.feed.SetCodecs(codecs...)
+}
+
+//ServeHTTP serves the mirror endpoints
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+func (m *Mirror) serveAll(w http.ResponseWriter, r *http.Request) error {
+	if m.notModified(w, r) {
+		return nil
+	}
+	domains := m.c.Domains()
+	sort.Strings(domains)
+	return writeJSON(w, domains)
+}
+
+func (m *Mirror) serveRecent(w http.ResponseWriter, r *http.Request) error {
+	seconds, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, endpointRecent))
+	if err != nil || seconds < 0 {
+		return badRequest{errors.New("invalid seconds")}
+	}
+	applied, err := m.c.updatesAfter(time.Now().Add(-time.Duration(seconds) * time.Second))
+	if errors.Is(err, ErrHistoryTruncated) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	updates := make([]DomainUpdate, 0, len(applied))
+	for _, au := range applied {
+		updates = append(updates, au.Update)
+	}
+	return writeJSON(w, updates)
+}
+
+func (m *Mirror) serveCheck(w http.ResponseWriter, r *http.Request) error {
+	domain := strings.TrimPrefix(r.URL.Path, endpointCheck)
+	if domain == "" {
+		return badRequest{errors.New("missing domain")}
+	}
+	//matched like Client.Check, so regex rules and dry run apply to the mirror's checks as well
+	phishing := m.c.Lookup(domain, CheckOpts{}).Phishing()
+	logCheck(r, m.c, domain, phishing)
+	_, err := w.Write([]byte(strconv.FormatBool(phishing)))
+	return err
+}
+
+func (m *Mirror) serveSize(w http.ResponseWriter, r *http.Request) error {
+	_, err := w.Write([]byte(strconv.Itoa(m.c.Size())))
+	return err
+}
+
+func (m *Mirror) serveExport(w http.ResponseWriter, r *http.Request) error {
+	e, ok := m.Exporters[strings.TrimPrefix(r.URL.Path, "/export/")]
+	if !ok {
+		http.NotFound(w, r)
+		return nil
+	}
+	if m.notModified(w, r) {
+		return nil
+	}
+	return WriteExport(m.c, w, e)
+}
+
+//notModified sets the ETag and Last-Modified of the cache, and responds with 304 if the request's copy is current
+//the generation is read before the cache, so a change in between at worst causes one extra transfer
+func (m *Mirror) notModified(w http.ResponseWriter, r *http.Request) bool {
+	generation, modified := m.c.version()
+	etag := `"` + m.instance + "-" + strconv.FormatUint(generation, 10) + `"`
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				w.WriteHeader(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err == nil && !modified.IsZero() && !modified.Truncate(time.Second).After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	srv := httptest.NewServer(NewMirror(primary))
+	defer srv.Close()
+	r := NewRawClient(srv.URL, "test", *srv.Client())
+	_, err := r.Recent(60)
+	a.Error(err)
+	primary.history.since = time.Now().Add(-time.Hour)
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com", "a.com"}}, SourceFeed)
+	primary.AddLocal(0, "local.com")
+
+	all, err := r.All()
+	a.NoError(err)
+	a.Equal([]string{"a.com", "b.com"}, all)
+	size, err := r.Size()
+	a.NoError(err)
+	a.Equal(2, size)
+	for domain, phishing := range map[string]bool{"a.com": true, "local.com": true, "c.com": false} {
+		found, err := r.Check(domain)
+		a.NoError(err)
+		a.Equal(phishing, found, domain)
+	}
+	primary.SetDryRun(true, nil)
+	found, err := r.Check("a.com")
+	a.NoError(err)
+	a.False(found, "dry run hits aren't reported as phishing")
+	a.Equal(uint64(1), primary.Stats().DryRunHits)
+	primary.SetDryRun(false, nil)
+
+	recent, err := r.Recent(60)
+	a.NoError(err)
+	a.Equal([]DomainUpdate{{Add: true, Domains: []string{"b.com", "a.com"}}}, recent)
+
+	follower := New(srv.URL, "test", *srv.Client())
+	a.NoError(follower.FullSync())
+	a.Equal(2, follower.Size())
+
+	get := func(path string, header http.Header) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		a.NoError(err)
+		req.Header = header
+		resp, err := srv.Client().Do(req)
+		a.NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		a.NoError(err)
+		return resp, string(body)
+	}
+	resp, body := get("/export/text", nil)
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.Equal("a.com\nb.com\n", body)
+	etag := resp.Header.Get("ETag")
+	a.NotEmpty(etag)
+	lastModified := resp.Header.Get("Last-Modified")
+	a.NotEmpty(lastModified)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{"etag", http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"weak etag list", http.Header{"If-None-Match": {`"other", W/` + etag}}, http.StatusNotModified},
+		{"other etag", http.Header{"If-None-Match": {`"other"`}}, http.StatusOK},
+		{"modified since", http.Header{"If-Modified-Since": {lastModified}}, http.StatusNotModified},
+		{"old copy", http.Header{"If-Modified-Since": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}}, http.StatusOK},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			resp, _ := get(endpointAll, data.header)
+			assert.Equal(t, data.status, resp.StatusCode)
+		})
+	}
+
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}}, SourceFeed)
+	resp, _ = get(endpointAll, http.Header{"If-None-Match": {etag}})
+	a.Equal(http.StatusOK, resp.StatusCode)
+	a.NotEqual(etag, resp.Header.Get("ETag"))
+
+	resp, _ = get("/export/missing", nil)
+	a.Equal(http.StatusNotFound, resp.StatusCode)
+	resp, _ = get(endpointRecent+"abc", nil)
+	a.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDeltaSync(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	srv := httptest.NewServer(NewMirror(primary))
+	defer srv.Close()
+
+	follower := New(srv.URL, "test", *srv.Client())
+	var sources []UpdateSource
+	follower.OnUpdate(func(au AppliedUpdate) {
+		sources = append(sources, au.Source)
+	})
+	a.NoError(follower.DeltaSync())
+	a.ElementsMatch([]string{"a.com", "b.com"}, follower.Domains())
+
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com", "d.com"}}, SourceFeed)
+	primary.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com", "d.com"}}, SourceFeed)
+	d, err := follower.Raw().Delta(follower.delta.instance, follower.delta.generation)
+	a.NoError(err)
+	a.False(d.Full)
+	a.Equal([]string{"c.com"}, d.Added)
+	a.Equal([]string{"a.com"}, d.Removed)
+	a.Equal(primary.Generation(), d.Generation)
+
+	a.NoError(follower.DeltaSync())
+	a.ElementsMatch([]string{"b.com", "c.com"}, follower.Domains())
+	a.NoError(follower.DeltaSync())
+	a.ElementsMatch([]string{"b.com", "c.com"}, follower.Domains())
+	a.Equal([]UpdateSource{SourceDelta, SourceDelta, SourceDelta}, sources)
+
+	d, err = follower.Raw().Delta("other", follower.delta.generation)
+	a.NoError(err)
+	a.True(d.Full)
+	a.Equal([]string{"b.com", "c.com"}, d.Added)
+}
+
+func TestMirrorFeed(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+	mirror := NewMirror(primary)
+	defer mirror.Close()
+	srv := httptest.NewServer(mirror)
+	defer srv.Close()
+	subscribers := func() int {
+		mirror.feed.m.Lock()
+		defer mirror.feed.m.Unlock()
+		return len(mirror.feed.followers)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var followers []*Client
+	for i := 0; i < 2; i++ {
+		follower := New(srv.URL, "test", http.Client{})
+		go func() {
+			_ = follower.ListenForUpdates(ctx)
+		}()
+		followers = append(followers, follower)
+	}
+	a.Eventually(func() bool { return subscribers() == 2 }, time.Second, time.Millisecond*10)
+
+	//a subscriber that doesn't read is dropped once its buffer is full, without blocking the others
+	slow, ok := mirror.feed.register()
+	a.True(ok)
+	for i := 0; i < mirrorFeedBuffer+1; i++ {
+		primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{strconv.Itoa(i) + ".com"}}, SourceFeed)
+	}
+	for range slow {
+	}
+	for _, follower := range followers {
+		follower := follower
+		a.Eventually(func() bool { return follower.Size() == mirrorFeedBuffer+1 }, time.Second*5, time.Millisecond*10)
+		a.False(follower.Check("a.com"))
+	}
+	a.Equal(2, subscribers())
+}
@@ -0,0 +1,134 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//mispNamespace is the namespace of the deterministic uuids of MISP events and attributes
+var mispNamespace = [16]byte{0x3d, 0x8e, 0x71, 0xc4, 0x5a, 0x02, 0x4b, 0x6f, 0xa1, 0x53, 0x0e, 0x97, 0xd4, 0x28, 0xbc, 0x61}
+
+//MISPExporter exports domains as a MISP event, see WriteMISPFeed for serving it as a MISP feed
+//the event uuid is derived from Org and Info, and attribute uuids from the event and the domains, so subscribers update the same event on every export
+type MISPExporter struct {
+	//Org is the name of the organisation that created the event
+	Org string
+	//Info is the title of the event, defaults to "Sinking Yachts phishing domains"
+	Info string
+}
+
+type mispOrg struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid"`
+}
+
+type mispAttribute struct {
+	UUID      string `json:"uuid"`
+	Type      string `json:"type"`
+	Category  string `json:"category"`
+	Value     string `json:"value"`
+	ToIDS     bool   `json:"to_ids"`
+	Timestamp string `json:"timestamp"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+type mispEvent struct {
+	UUID          string          `json:"uuid"`
+	Info          string          `json:"info"`
+	Date          string          `json:"date"`
+	Timestamp     string          `json:"timestamp"`
+	Analysis      string          `json:"analysis"`
+	ThreatLevelID string          `json:"threat_level_id"`
+	Published     bool            `json:"published"`
+	Orgc          mispOrg         `json:"Orgc"`
+	Attribute     []mispAttribute `json:"Attribute,omitempty"`
+}
+
+//mispManifestEntry is the summary of an event in the manifest of a feed
+type mispManifestEntry struct {
+	Info          string  `json:"info"`
+	Date          string  `json:"date"`
+	Timestamp     string  `json:"timestamp"`
+	Analysis      string  `json:"analysis"`
+	ThreatLevelID string  `json:"threat_level_id"`
+	Orgc          mispOrg `json:"Orgc"`
+}
+
+//Export writes the domains as a single MISP event with a domain attribute per domain
+func (m MISPExporter) Export(w io.Writer, set ExportSet) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Event mispEvent `json:"Event"`
+	}{m.event(set)})
+}
+
+func (m MISPExporter) event(set ExportSet) mispEvent {
+	info := m.Info
+	if info == "" {
+		info = "Sinking Yachts phishing domains"
+	}
+	updated := set.LastUpdated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	ev := mispEvent{
+		UUID:          uuidV5(mispNamespace, "event:"+m.Org+":"+info),
+		Info:          info,
+		Date:          updated.UTC().Format("2006-01-02"),
+		Timestamp:     strconv.FormatInt(updated.Unix(), 10),
+		Analysis:      "2",
+		ThreatLevelID: "2",
+		Published:     true,
+		Orgc:          mispOrg{Name: m.Org, UUID: uuidV5(mispNamespace, "org:"+m.Org)},
+		Attribute:     make([]mispAttribute, 0, len(set.Entries)),
+	}
+	for _, entry := range set.Entries {
+		attr := mispAttribute{
+			UUID:      uuidV5(mispNamespace, "attribute:"+ev.UUID+":"+entry.Domain),
+			Type:      "domain",
+			Category:  "Network activity",
+			Value:     entry.Domain,
+			ToIDS:     true,
+			Timestamp: ev.Timestamp,
+		}
+		if md := entry.Metadata; md != nil {
+			if !md.AddedAt.IsZero() {
+				attr.Timestamp = strconv.FormatInt(md.AddedAt.Unix(), 10)
+			}
+			attr.Comment = md.Category
+		}
+		ev.Attribute = append(ev.Attribute, attr)
+	}
+	return ev
+}
+
+//WriteMISPFeed writes the Client's domains into dir as a MISP feed of a single event
+//the feed consists of manifest.json and the event as <uuid>.json, which MISP instances can subscribe to when dir is served over http
+//files are replaced atomically, so the feed can be rewritten while being served
+func WriteMISPFeed(c *Client, dir string, m MISPExporter) error {
+	set := c.exportSet()
+	ev := m.event(set)
+	err := writeFileAtomic(filepath.Join(dir, ev.UUID+".json"), func(w io.Writer) error {
+		return m.Export(w, set)
+	})
+	if err != nil {
+		return err
+	}
+	manifest := map[string]mispManifestEntry{
+		ev.UUID: {
+			Info:          ev.Info,
+			Date:          ev.Date,
+			Timestamp:     ev.Timestamp,
+			Analysis:      ev.Analysis,
+			ThreatLevelID: ev.ThreatLevelID,
+			Orgc:          ev.Orgc,
+		},
+	}
+	return writeFileAtomic(filepath.Join(dir, "manifest.json"), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(manifest)
+	})
+}
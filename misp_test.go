@@ -0,0 +1,59 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteMISPFeed(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "evil.org"}}, SourceFeed)
+	c.lastUpdated = time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	a.NoError(WriteMISPFeed(c, dir, MISPExporter{Org: "Example Org"}))
+
+	b, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	a.NoError(err)
+	var manifest map[string]mispManifestEntry
+	a.NoError(json.Unmarshal(b, &manifest))
+	a.Len(manifest, 1)
+	for id, entry := range manifest {
+		a.Equal("2022-04-01", entry.Date)
+		a.Equal("1648814400", entry.Timestamp)
+		a.Equal("Example Org", entry.Orgc.Name)
+
+		b, err = os.ReadFile(filepath.Join(dir, id+".json"))
+		a.NoError(err)
+		var ev struct {
+			Event mispEvent
+		}
+		a.NoError(json.Unmarshal(b, &ev))
+		a.Equal(id, ev.Event.UUID)
+		a.Len(ev.Event.Attribute, 2)
+		a.Equal("domain", ev.Event.Attribute[0].Type)
+		a.Equal("bad.com", ev.Event.Attribute[0].Value)
+	}
+
+	files, err := os.ReadDir(dir)
+	a.NoError(err)
+	a.Len(files, 2, "temporary files should be cleaned up")
+}
+
+func TestMISPAttributeUUIDs(t *testing.T) {
+	a := assert.New(t)
+	set := ExportSet{Entries: []ExportEntry{{Domain: "bad.com"}}}
+	first := MISPExporter{Org: "Example Org"}.event(set)
+	again := MISPExporter{Org: "Example Org"}.event(set)
+	other := MISPExporter{Org: "Example Org", Info: "Other event"}.event(set)
+
+	a.Equal(first.UUID, again.UUID)
+	a.Equal(first.Attribute[0].UUID, again.Attribute[0].UUID, "attribute uuids should be stable")
+	a.NotEqual(first.UUID, other.UUID)
+	a.NotEqual(first.Attribute[0].UUID, other.Attribute[0].UUID, "the same domain in another event needs its own uuid")
+}
@@ -0,0 +1,536 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//msgpack is a minimal MessagePack encoder and decoder, supporting just enough to serialize the cache
+//structs are encoded as maps using their json tags, time.Time uses the timestamp extension
+//supported kinds are bool, integers, floats, strings, slices, string keyed maps, pointers and structs
+
+var timeType = reflect.TypeOf(time.Time{})
+
+//errMsgpackShort is returned when the input ends unexpectedly
+var errMsgpackShort = errors.New("msgpack: unexpected end of input")
+
+//msgpackMarshal encodes v into MessagePack
+func msgpackMarshal(v interface{}) ([]byte, error) {
+	e := msgpackEncoder{}
+	err := e.encode(reflect.ValueOf(v))
+	return e.buf, err
+}
+
+//msgpackUnmarshal decodes MessagePack data into v, v must be a non nil pointer
+func msgpackUnmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("msgpack: unmarshal target must be a non nil pointer")
+	}
+	d := msgpackDecoder{buf: data}
+	err := d.decode(rv.Elem(), 0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.buf) {
+		return errors.New("msgpack: trailing data after value")
+	}
+	return nil
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = appendUint(e.buf, 64, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint(e.buf, 64, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = appendUint(e.buf, 64, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		e.encodeHeader(v.Len(), 0x90, 15, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("msgpack: unsupported map key %s", v.Type().Key())
+		}
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		e.encodeHeader(v.Len(), 0x80, 15, 0xde, 0xdf)
+		iter := v.MapRange()
+		for iter.Next() {
+			e.encodeString(iter.Key().String())
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := msgpackFields(v.Type())
+		present := make([]msgpackField, 0, len(fields))
+		for _, f := range fields {
+			if f.omitEmpty && v.Field(f.index).IsZero() {
+				continue
+			}
+			present = append(present, f)
+		}
+		e.encodeHeader(len(present), 0x80, 15, 0xde, 0xdf)
+		for _, f := range present {
+			e.encodeString(f.name)
+			if err := e.encode(v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+//encodeHeader writes a length header for strings, arrays and maps
+func (e *msgpackEncoder) encodeHeader(n int, fix byte, fixMax int, b16, b32 byte) {
+	switch {
+	case n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, b16)
+		e.buf = appendUint(e.buf, 16, uint64(n))
+	default:
+		e.buf = append(e.buf, b32)
+		e.buf = appendUint(e.buf, 32, uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	if len(s) > 31 && len(s) <= math.MaxUint8 {
+		e.buf = append(e.buf, 0xd9, byte(len(s)))
+	} else {
+		e.encodeHeader(len(s), 0xa0, 31, 0xda, 0xdb)
+	}
+	e.buf = append(e.buf, s...)
+}
+
+//encodeTime writes t using the 96 bit timestamp extension
+func (e *msgpackEncoder) encodeTime(t time.Time) {
+	e.buf = append(e.buf, 0xc7, 12, 0xff)
+	e.buf = appendUint(e.buf, 32, uint64(t.Nanosecond()))
+	e.buf = appendUint(e.buf, 64, uint64(t.Unix()))
+}
+
+//appendUint appends the lowest bits of u to buf in big endian
+func appendUint(buf []byte, bits int, u uint64) []byte {
+	for shift := bits - 8; shift >= 0; shift -= 8 {
+		buf = append(buf, byte(u>>uint(shift)))
+	}
+	return buf
+}
+
+//msgpackMaxDepth bounds nesting to protect against maliciously deep inputs
+const msgpackMaxDepth = 64
+
+type msgpackDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.buf)-d.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+//decode decodes the next value into v
+func (d *msgpackDecoder) decode(v reflect.Value, depth int) error {
+	if depth > msgpackMaxDepth {
+		return errors.New("msgpack: maximum nesting depth exceeded")
+	}
+	b, err := d.read(1)
+	if err != nil {
+		return err
+	}
+	c := b[0]
+	if c == 0xc0 {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.pos--
+		return d.decode(v.Elem(), depth+1)
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		d.pos--
+		return d.decodeInterface(v, depth)
+	}
+
+	switch {
+	case c == 0xc2 || c == 0xc3:
+		if v.Kind() != reflect.Bool {
+			return d.mismatch("bool", v)
+		}
+		v.SetBool(c == 0xc3)
+	case c <= 0x7f || c >= 0xe0 || (c >= 0xcc && c <= 0xd3):
+		d.pos--
+		return d.decodeNumber(v)
+	case c == 0xca || c == 0xcb:
+		d.pos--
+		return d.decodeNumber(v)
+	case (c >= 0xa0 && c <= 0xbf) || c == 0xd9 || c == 0xda || c == 0xdb:
+		n, err := d.length(c, 0xa0, 0x1f, 0xd9, 0xda, 0xdb)
+		if err != nil {
+			return err
+		}
+		s, err := d.read(n)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.String {
+			return d.mismatch("string", v)
+		}
+		v.SetString(string(s))
+	case (c >= 0x90 && c <= 0x9f) || c == 0xdc || c == 0xdd:
+		n, err := d.length(c, 0x90, 0x0f, 0, 0xdc, 0xdd)
+		if err != nil {
+			return err
+		}
+		return d.decodeArray(v, n, depth)
+	case (c >= 0x80 && c <= 0x8f) || c == 0xde || c == 0xdf:
+		n, err := d.length(c, 0x80, 0x0f, 0, 0xde, 0xdf)
+		if err != nil {
+			return err
+		}
+		return d.decodeMap(v, n, depth)
+	case c == 0xd6 || c == 0xd7 || c == 0xc7:
+		t, err := d.decodeTime(c)
+		if err != nil {
+			return err
+		}
+		if v.Type() != timeType {
+			return d.mismatch("timestamp", v)
+		}
+		v.Set(reflect.ValueOf(t))
+	default:
+		return fmt.Errorf("msgpack: unsupported type byte 0x%02x", c)
+	}
+	return nil
+}
+
+//length reads the length of a string, array or map given its type byte
+func (d *msgpackDecoder) length(c, fix, fixMask, b8, b16, b32 byte) (int, error) {
+	var n uint64
+	var err error
+	switch {
+	case c&^fixMask == fix:
+		n = uint64(c & fixMask)
+	case b8 != 0 && c == b8:
+		n, err = d.readUint(1)
+	case c == b16:
+		n, err = d.readUint(2)
+	case c == b32:
+		n, err = d.readUint(4)
+	}
+	if err != nil {
+		return 0, err
+	}
+	//every element takes at least a byte, reject lengths that can't possibly fit
+	if n > uint64(len(d.buf)-d.pos) {
+		return 0, errMsgpackShort
+	}
+	return int(n), nil
+}
+
+func (d *msgpackDecoder) decodeNumber(v reflect.Value) error {
+	b, _ := d.read(1)
+	c := b[0]
+	var i int64
+	var u uint64
+	var f float64
+	var err error
+	kind := 'i'
+	switch {
+	case c <= 0x7f:
+		i = int64(c)
+	case c >= 0xe0:
+		i = int64(int8(c))
+	case c >= 0xcc && c <= 0xcf:
+		u, err = d.readUint(1 << (c - 0xcc))
+		kind = 'u'
+	case c >= 0xd0 && c <= 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err = d.readUint(size)
+		shift := 64 - uint(size*8)
+		i = int64(u<<shift) >> shift
+	case c == 0xca:
+		u, err = d.readUint(4)
+		f = float64(math.Float32frombits(uint32(u)))
+		kind = 'f'
+	case c == 0xcb:
+		u, err = d.readUint(8)
+		f = math.Float64frombits(u)
+		kind = 'f'
+	}
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case 'i':
+		f, u = float64(i), uint64(i)
+	case 'u':
+		f, i = float64(u), int64(u)
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if kind == 'f' || v.OverflowInt(i) || (kind == 'u' && i < 0) {
+			return d.mismatch("number", v)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if kind == 'f' || (kind == 'i' && i < 0) || v.OverflowUint(u) {
+			return d.mismatch("number", v)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(f)
+	default:
+		return d.mismatch("number", v)
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decodeArray(v reflect.Value, n int, depth int) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decode(s.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		if n != v.Len() {
+			return d.mismatch("array", v)
+		}
+		for i := 0; i < n; i++ {
+			if err := d.decode(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return d.mismatch("array", v)
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decodeMap(v reflect.Value, n int, depth int) error {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return d.mismatch("map", v)
+		}
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key, depth+1); err != nil {
+				return err
+			}
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(val, depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		fields := msgpackFields(v.Type())
+		for i := 0; i < n; i++ {
+			var key string
+			if err := d.decode(reflect.ValueOf(&key).Elem(), depth+1); err != nil {
+				return err
+			}
+			found := false
+			for _, f := range fields {
+				if f.name == key {
+					found = true
+					if err := d.decode(v.Field(f.index), depth+1); err != nil {
+						return err
+					}
+					break
+				}
+			}
+			if !found {
+				var skip interface{}
+				if err := d.decode(reflect.ValueOf(&skip).Elem(), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return d.mismatch("map", v)
+	}
+	return nil
+}
+
+//decodeInterface decodes the next value into its natural go type
+func (d *msgpackDecoder) decodeInterface(v reflect.Value, depth int) error {
+	c := d.buf[d.pos]
+	var target reflect.Value
+	switch {
+	case c == 0xc2 || c == 0xc3:
+		target = reflect.New(reflect.TypeOf(false)).Elem()
+	case c <= 0x7f || c >= 0xe0 || (c >= 0xcc && c <= 0xd3):
+		target = reflect.New(reflect.TypeOf(int64(0))).Elem()
+		if c >= 0xcc && c <= 0xcf {
+			target = reflect.New(reflect.TypeOf(uint64(0))).Elem()
+		}
+	case c == 0xca || c == 0xcb:
+		target = reflect.New(reflect.TypeOf(float64(0))).Elem()
+	case (c >= 0xa0 && c <= 0xbf) || c == 0xd9 || c == 0xda || c == 0xdb:
+		target = reflect.New(reflect.TypeOf("")).Elem()
+	case (c >= 0x90 && c <= 0x9f) || c == 0xdc || c == 0xdd:
+		target = reflect.New(reflect.TypeOf([]interface{}{})).Elem()
+	case (c >= 0x80 && c <= 0x8f) || c == 0xde || c == 0xdf:
+		target = reflect.New(reflect.TypeOf(map[string]interface{}{})).Elem()
+	case c == 0xd6 || c == 0xd7 || c == 0xc7:
+		target = reflect.New(timeType).Elem()
+	default:
+		return fmt.Errorf("msgpack: unsupported type byte 0x%02x", c)
+	}
+	if err := d.decode(target, depth+1); err != nil {
+		return err
+	}
+	v.Set(target)
+	return nil
+}
+
+//decodeTime decodes a timestamp extension, the type byte has already been read
+func (d *msgpackDecoder) decodeTime(c byte) (time.Time, error) {
+	size := 4
+	switch c {
+	case 0xd7:
+		size = 8
+	case 0xc7:
+		n, err := d.readUint(1)
+		if err != nil {
+			return time.Time{}, err
+		}
+		size = int(n)
+	}
+	ext, err := d.read(1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if int8(ext[0]) != -1 {
+		return time.Time{}, fmt.Errorf("msgpack: unsupported extension type %d", int8(ext[0]))
+	}
+	switch size {
+	case 4:
+		sec, err := d.readUint(4)
+		return time.Unix(int64(sec), 0), err
+	case 8:
+		u, err := d.readUint(8)
+		return time.Unix(int64(u&0x3ffffffff), int64(u>>34)), err
+	case 12:
+		nsec, err := d.readUint(4)
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec, err := d.readUint(8)
+		return time.Unix(int64(sec), int64(nsec)), err
+	default:
+		return time.Time{}, fmt.Errorf("msgpack: invalid timestamp length %d", size)
+	}
+}
+
+func (d *msgpackDecoder) mismatch(got string, v reflect.Value) error {
+	return fmt.Errorf("msgpack: cannot decode %s into %s", got, v.Type())
+}
+
+type msgpackField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+//msgpackFields returns the encoded fields of a struct, named by their json tags
+func msgpackFields(t reflect.Type) []msgpackField {
+	var fields []msgpackField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, msgpackField{
+			name:      name,
+			index:     i,
+			omitEmpty: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
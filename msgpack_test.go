@@ -0,0 +1,44 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheFormats(t *testing.T) {
+	domains := []string{"a.com", "b.com", strings.Repeat("long", 20) + ".com"}
+	for i := 0; i < 70000; i++ {
+		domains = append(domains, strings.Repeat("x", i%300)+".com")
+	}
+	for _, format := range []CacheFormat{CacheJSON, CacheMsgpack} {
+		a := assert.New(t)
+		c := New("", "test", http.Client{})
+		c.applyLiveUpdates(DomainUpdate{Add: true, Domains: domains}, SourceFeed)
+		c.lastUpdated = time.Unix(1650000000, 123456789)
+
+		var buf bytes.Buffer
+		a.NoError(WriteCacheFormat(c, &buf, format))
+		loaded := New("", "test", http.Client{})
+		a.NoError(ReadCacheFormat(loaded, &buf, format))
+
+		expected, actual := c.Domains(), loaded.Domains()
+		sort.Strings(expected)
+		sort.Strings(actual)
+		a.Equal(expected, actual)
+		a.True(c.lastUpdated.Equal(loaded.lastUpdated))
+	}
+}
+
+func TestMsgpackInvalid(t *testing.T) {
+	var sf save
+	a := assert.New(t)
+	a.Error(msgpackUnmarshal([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, &sf))
+	a.Error(msgpackUnmarshal([]byte{0x81, 0xa1, 'x', 0x91, 0x91, 0x91}, &sf))
+	a.Error(msgpackUnmarshal(bytes.Repeat([]byte{0x91}, 1000), &sf))
+	a.NoError(msgpackUnmarshal([]byte{0x81, 0xa1, 'x', 0x92, 0x01, 0xa1, 'y'}, &sf))
+}
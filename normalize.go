@@ -0,0 +1,190 @@
+package sinkingyachts
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+//Normalization controls how checked domains are normalized before they are looked up, see Client.SetNormalization
+//the zero value is NormalizeStrict, which looks domains up exactly as they are given
+type Normalization struct {
+	//FoldCase lower cases domains, "Bad.COM" becomes "bad.com"
+	FoldCase bool
+	//StripTrailingDot removes the trailing dot of fully qualified domains, "bad.com." becomes "bad.com"
+	StripTrailingDot bool
+	//IDN maps internationalized domains to their ascii form, "bücher.com" becomes "xn--bcher-kva.com"
+	//ideographic full stops are mapped to dots, and labels are lower cased before being encoded
+	//this is a subset of the IDNA mapping, it doesn't apply unicode normalization
+	IDN bool
+	//PercentDecode decodes percent encoded hosts, "bad%2Ecom" becomes "bad.com"
+	//hosts that are not validly encoded are left as they are
+	PercentDecode bool
+}
+
+var (
+	//NormalizeStrict doesn't normalize domains, it's the default
+	//it suits callers that already hold valid, normalized domains, such as ones taken from a parsed url
+	NormalizeStrict = Normalization{}
+	//NormalizeLenient applies every normalization
+	//it suits domains scraped from chat messages, where they may be written in any form
+	NormalizeLenient = Normalization{
+		FoldCase:         true,
+		StripTrailingDot: true,
+		IDN:              true,
+		PercentDecode:    true,
+	}
+)
+
+//SetNormalization sets how Client normalizes domains before checking them
+//this affects Check, FuzzyCheck and CheckDetailed, and local domains added afterwards, the domains received from the api are never normalized
+func (c *Client) SetNormalization(n Normalization) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.normalization = n
+}
+
+//normalize normalizes a checked domain as configured by SetNormalization
+func (c *Client) normalize(domain string) string {
+	c.m.Lock()
+	n := c.normalization
+	c.m.Unlock()
+	return n.Normalize(domain)
+}
+
+//Normalize returns the domain normalized as configured
+func (n Normalization) Normalize(domain string) string {
+	if n.PercentDecode && strings.Contains(domain, "%") {
+		if decoded, err := url.PathUnescape(domain); err == nil {
+			domain = decoded
+		}
+	}
+	if n.IDN {
+		domain = toASCII(domain)
+	}
+	if n.FoldCase {
+		domain = strings.ToLower(domain)
+	}
+	if n.StripTrailingDot {
+		domain = strings.TrimSuffix(domain, ".")
+	}
+	return domain
+}
+
+//fullStops are the characters the IDNA mapping treats as dots
+var fullStops = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+//toASCII encodes the non ascii labels of domain with punycode
+func toASCII(domain string) string {
+	if isASCII(domain) {
+		return domain
+	}
+	labels := strings.Split(fullStops.Replace(domain), ".")
+	for i, label := range labels {
+		if !isASCII(label) && utf8.ValidString(label) {
+			labels[i] = "xn--" + punycode(strings.ToLower(label))
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+//parameters of punycode, see RFC 3492
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+//punycode encodes a label with punycode, without the "xn--" prefix
+func punycode(label string) string {
+	runes := []rune(label)
+	out := make([]byte, 0, len(label)+8)
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		next := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < next {
+				next = r
+			}
+		}
+		delta += int(next-n) * (handled + 1)
+		n = next
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+//punyAdapt is the bias adaptation function of punycode
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+//punyDigit returns the character of a punycode digit
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,46 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        Normalization
+		input    string
+		expected string
+	}{
+		{"Strict", NormalizeStrict, "Bad.COM.", "Bad.COM."},
+		{"Fold case", Normalization{FoldCase: true}, "Bad.COM", "bad.com"},
+		{"Trailing dot", Normalization{StripTrailingDot: true}, "bad.com.", "bad.com"},
+		{"Percent", Normalization{PercentDecode: true}, "bad%2Ecom", "bad.com"},
+		{"Invalid percent", Normalization{PercentDecode: true}, "bad%zz.com", "bad%zz.com"},
+		{"IDN", Normalization{IDN: true}, "bücher.com", "xn--bcher-kva.com"},
+		{"IDN upper case", Normalization{IDN: true}, "MÜNCHEN.de", "xn--mnchen-3ya.de"},
+		{"IDN only non ascii", Normalization{IDN: true}, "日本語。jp", "xn--wgv71a119e.jp"},
+		{"Lenient", NormalizeLenient, "WWW.Bücher%2Ecom.", "www.xn--bcher-kva.com"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(data.expected, data.n.Normalize(data.input))
+		})
+	}
+}
+
+func TestSetNormalization(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "xn--bcher-kva.com"}}, SourceFeed)
+	a.False(c.Check("BAD.com."))
+	a.False(c.FuzzyCheck("foo.bücher.com"))
+
+	c.SetNormalization(NormalizeLenient)
+	a.True(c.Check("BAD.com."))
+	m := c.CheckDetailed("foo.bücher.com")
+	a.Equal("foo.bücher.com", m.Domain)
+	a.Equal("xn--bcher-kva.com", m.Matched)
+}
@@ -1,6 +1,7 @@
 package sinkingyachts
 
 import (
+	"context"
 	"net/http"
 	"time"
 )
@@ -28,9 +29,79 @@ func WithoutHeader(header string) Option {
 	}
 }
 
+//WithTimeout sets the timeout of requests made by RawClient's http.Client, see http.Client.Timeout
+func WithTimeout(duration time.Duration) Option {
+	return func(client *RawClient) {
+		client.webClient.Timeout = duration
+	}
+}
+
 //WithFeedTimeout sets a custom feed timeout for dialing to the websocket update feed
 func WithFeedTimeout(duration time.Duration) Option {
 	return func(client *RawClient) {
 		client.feedTimeout = duration
 	}
 }
+
+//WithStrictValidation validates every domain received from the api with ValidateDomain
+//invalid domains are dropped before reaching the cache, onInvalid is called for each dropped domain if not nil
+//this protects the cache from a compromised or buggy upstream
+func WithStrictValidation(onInvalid func(domain string, err error)) Option {
+	return func(client *RawClient) {
+		client.strict = true
+		client.onInvalid = onInvalid
+	}
+}
+
+//WithBaseContext sets the context that requests made by RawClient derive from
+//this lets requests carry the application's values such as tracing, and get cancelled with it
+//feeds and bulk checks given their own context also carry its values, and are cancelled once the base context is done
+//a nil context keeps the default of context.Background
+func WithBaseContext(ctx context.Context) Option {
+	return func(client *RawClient) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		client.baseCtx = ctx
+	}
+}
+
+//WithFeedReadLimit sets the maximum size in bytes of a single feed message, larger messages close the feed with an error
+//the default is 32768 bytes, followers of a Replicator with large batches may need a higher limit
+func WithFeedReadLimit(bytes int64) Option {
+	return func(client *RawClient) {
+		client.readLimit = bytes
+	}
+}
+
+//WithFeedMessageTimeout sets the maximum time to wait for each feed message, after which the feed closes with an error
+//it should be well above the usual gap between updates, as the feed stays silent when nothing changes
+//the default of 0 waits forever
+func WithFeedMessageTimeout(duration time.Duration) Option {
+	return func(client *RawClient) {
+		client.msgTimeout = duration
+	}
+}
+
+//WithFeedPing pings the feed every interval, and closes it with an error if a pong doesn't arrive within timeout
+//this bounds how long a hung tcp connection can stall the feed, without closing a healthy feed that is just quiet
+//a timeout of 0 or less uses the interval as the timeout, and an interval of 0 or less disables pings
+func WithFeedPing(interval, timeout time.Duration) Option {
+	return func(client *RawClient) {
+		if timeout <= 0 {
+			timeout = interval
+		}
+		client.pingInterval = interval
+		client.pingTimeout = timeout
+	}
+}
+
+//WithBulkLimits sets how CheckMany spreads its requests
+//workers is the maximum amount of concurrent requests, defaults to 4
+//interval is the minimum time between starting two requests, defaults to 50ms, 0 disables rate limiting
+func WithBulkLimits(workers int, interval time.Duration) Option {
+	return func(client *RawClient) {
+		client.bulkWorkers = workers
+		client.bulkRate = interval
+	}
+}
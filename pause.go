@@ -0,0 +1,57 @@
+package sinkingyachts
+
+import "errors"
+
+//ErrPaused is returned by syncs while changes to the cache are paused, see Client.Pause
+var ErrPaused = errors.New("cache changes are paused")
+
+//Pause freezes the synced domains, checks keep being served from the frozen state until Resume
+//FullSync, Update, DeltaSync and Repair return ErrPaused, and updates from the feed, webhooks and replays are dropped
+//local domains can still be edited, so operators can block domains by hand while paused
+//dropped updates are not queued, a sync is needed after Resume to catch up, Manager.Resume does this
+func (c *Client) Pause() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.paused = true
+}
+
+//Resume lets syncs and updates change the cache again after Pause
+func (c *Client) Resume() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.paused = false
+}
+
+//Paused returns true while changes to the cache are paused
+func (c *Client) Paused() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.paused
+}
+
+//Pause freezes the cache with Client.Pause, such as during incident forensics or a suspected bad upstream push
+//scheduled syncs are skipped while paused, and Ready doesn't report the cache as stale
+func (m *Manager) Pause() {
+	m.client.Pause()
+}
+
+//Resume lets the cache change again, and catches up on what was missed with a FullSync, or a DeltaSync if configured
+//the Client is resumed even if the sync fails, the next scheduled sync retries it
+func (m *Manager) Resume() error {
+	m.client.Resume()
+	cfg, _ := m.current()
+	sync := m.client.FullSync
+	if cfg.Sync.Delta {
+		sync = m.client.DeltaSync
+	}
+	if err := sync(); err != nil {
+		return err
+	}
+	m.synced()
+	return nil
+}
+
+//Paused returns true while the cache is paused
+func (m *Manager) Paused() bool {
+	return m.client.Paused()
+}
@@ -0,0 +1,41 @@
+package sinkingyachts
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+//NewHTTPClient returns a http.Client with its own transport, tuned for talking to a few api hosts
+//http.Client is copied by value into RawClient, but the copies keep pointing at the same transport
+//so passing the returned client to any number of RawClient or Client makes them share one connection pool
+//timeout bounds each request, a timeout of 0 means no timeout which is not recommended
+func NewHTTPClient(timeout time.Duration) http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   time.Second * 10,
+			KeepAlive: time.Second * 30,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          32,
+		MaxIdleConnsPerHost:   8,
+		IdleConnTimeout:       time.Second * 90,
+		TLSHandshakeTimeout:   time.Second * 10,
+		ExpectContinueTimeout: time.Second,
+	}
+	return http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+//NewRawClients creates a RawClient for each endpoint, all sharing webClient's transport and the same options
+//this is useful for aggregating several mirrors or feeds, see NewRawClient for the parameters
+func NewRawClients(endpoints []string, identity string, webClient http.Client, options ...Option) []RawClient {
+	clients := make([]RawClient, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		clients = append(clients, NewRawClient(endpoint, identity, webClient, options...))
+	}
+	return clients
+}
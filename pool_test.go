@@ -0,0 +1,59 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	a := assert.New(t)
+	var conns int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["a.com"]`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	webClient := NewHTTPClient(time.Second * 5)
+	a.Equal(time.Second*5, webClient.Timeout)
+	clients := NewRawClients([]string{srv.URL, srv.URL, srv.URL}, "test", webClient)
+	a.Len(clients, 3)
+
+	//connections are released back into the shared pool, and acquired again by every client
+	for _, c := range clients {
+		ds, err := c.All()
+		a.NoError(err)
+		a.Equal([]string{"a.com"}, ds)
+	}
+	a.Equal(int64(1), atomic.LoadInt64(&conns), "clients share one connection pool")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 24; i++ {
+		wg.Add(1)
+		go func(c RawClient) {
+			defer wg.Done()
+			_, err := c.All()
+			a.NoError(err)
+		}(clients[i%len(clients)])
+	}
+	wg.Wait()
+	opened := atomic.LoadInt64(&conns)
+	a.LessOrEqual(opened, int64(24))
+
+	for _, c := range clients {
+		_, err := c.All()
+		a.NoError(err)
+	}
+	a.Equal(opened, atomic.LoadInt64(&conns), "idle connections are reused after concurrent use")
+}
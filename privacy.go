@@ -0,0 +1,66 @@
+package sinkingyachts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+//PrivacyMode controls how checked domains appear in logs and hooks, see Client.SetPrivacy
+type PrivacyMode int
+
+const (
+	//PrivacyOff reports checked domains as they are
+	PrivacyOff PrivacyMode = iota
+	//PrivacyHash reports checked domains as "hmac-sha256:<hex>", so repeated checks can still be correlated
+	PrivacyHash
+	//PrivacyOmit leaves checked domains out, only reporting if they were a hit or a miss
+	PrivacyOmit
+)
+
+//SetPrivacy sets how checked domains appear in the hooks of Client, such as SetDryRun, and in logs, such as AccessLog
+//this is meant for deployments with privacy requirements around the links users post
+//key is the key hashes are made with, a nil key uses a random key, so hashes are only comparable within the process
+func (c *Client) SetPrivacy(mode PrivacyMode, key []byte) {
+	if mode == PrivacyHash && key == nil {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.privacy = mode
+	c.privacyKey = key
+}
+
+//redact returns the checked domain as it should be reported in the privacy mode
+func (c *Client) redact(domain string) string {
+	c.m.Lock()
+	mode, key := c.privacy, c.privacyKey
+	c.m.Unlock()
+	return redactDomain(domain, mode, key)
+}
+
+//redactDomain returns the domain as it should be reported in the privacy mode
+func redactDomain(domain string, mode PrivacyMode, key []byte) string {
+	switch {
+	case domain == "" || mode == PrivacyOff:
+		return domain
+	case mode == PrivacyHash:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(domain))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	default:
+		return ""
+	}
+}
+
+//redactMatch returns the match with its domains redacted in the privacy mode
+func (c *Client) redactMatch(m Match) Match {
+	c.m.Lock()
+	mode, key := c.privacy, c.privacyKey
+	c.m.Unlock()
+	m.Domain = redactDomain(m.Domain, mode, key)
+	m.Matched = redactDomain(m.Matched, mode, key)
+	return m
+}
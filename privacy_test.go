@@ -0,0 +1,62 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrivacy(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  PrivacyMode
+		check func(a *assert.Assertions, domain string)
+	}{
+		{"off", PrivacyOff, func(a *assert.Assertions, domain string) {
+			a.Equal("sub.bad.com", domain)
+		}},
+		{"hash", PrivacyHash, func(a *assert.Assertions, domain string) {
+			a.True(strings.HasPrefix(domain, "hmac-sha256:"))
+			a.NotContains(domain, "bad.com")
+		}},
+		{"omit", PrivacyOmit, func(a *assert.Assertions, domain string) {
+			a.Empty(domain)
+		}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			c := New("", "test", http.Client{})
+			c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+			c.SetPrivacy(data.mode, nil)
+
+			var hit Match
+			c.SetDryRun(true, func(m Match) {
+				hit = m
+			})
+			a.False(c.FuzzyCheck("sub.bad.com"))
+			data.check(a, hit.Domain)
+			c.SetDryRun(false, nil)
+
+			var entry AccessEntry
+			handler := AccessLog(NewMirror(c), AccessSinkFunc(func(e AccessEntry) {
+				entry = e
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, endpointCheck+"sub.bad.com", nil))
+			a.Equal("false", rec.Body.String())
+			a.Equal("miss", entry.Result)
+			data.check(a, strings.TrimPrefix(entry.Endpoint, endpointCheck))
+			if data.mode != PrivacyOff {
+				a.Equal(endpointCheck+entry.Domain, entry.Endpoint)
+			}
+		})
+	}
+
+	key := []byte("key")
+	a := assert.New(t)
+	a.Equal(redactDomain("bad.com", PrivacyHash, key), redactDomain("bad.com", PrivacyHash, key))
+	a.NotEqual(redactDomain("bad.com", PrivacyHash, key), redactDomain("bad.com", PrivacyHash, []byte("other")))
+}
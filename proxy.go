@@ -0,0 +1,85 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+//ExportSquid writes a squid dstdomain acl file, for use as acl phishing dstdomain "/etc/squid/phishing.acl"
+//domains are written with a leading dot to match their subdomains, so subdomains of listed domains are left out as squid rejects overlapping entries
+var ExportSquid Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("# sinking yachts phishing domains\n")
+	for _, domain := range topDomains(set) {
+		_ = bw.WriteByte('.')
+		_, _ = bw.WriteString(domain)
+		_ = bw.WriteByte('\n')
+	}
+	return bw.Flush()
+})
+
+//ExportEnvoy writes an envoy http rbac filter denying requests to the domains and their subdomains
+//the output is json, which can also be embedded into yaml configs as is
+var ExportEnvoy Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	type stringMatch struct {
+		Exact      string `json:"exact,omitempty"`
+		Suffix     string `json:"suffix,omitempty"`
+		IgnoreCase bool   `json:"ignore_case"`
+	}
+	type header struct {
+		Name        string      `json:"name"`
+		StringMatch stringMatch `json:"string_match"`
+	}
+	type rule struct {
+		Header header `json:"header"`
+	}
+	domains := topDomains(set)
+	rules := make([]rule, 0, len(domains)*2)
+	for _, domain := range domains {
+		rules = append(rules,
+			rule{Header: header{Name: ":authority", StringMatch: stringMatch{Exact: domain, IgnoreCase: true}}},
+			rule{Header: header{Name: ":authority", StringMatch: stringMatch{Suffix: "." + domain, IgnoreCase: true}}},
+		)
+	}
+	filter := map[string]interface{}{
+		"name": "envoy.filters.http.rbac",
+		"typed_config": map[string]interface{}{
+			"@type": "type.googleapis.com/envoy.extensions.filters.http.rbac.v3.RBAC",
+			"rules": map[string]interface{}{
+				"action": "DENY",
+				"policies": map[string]interface{}{
+					"sinkingyachts": map[string]interface{}{
+						"permissions": []interface{}{map[string]interface{}{"or_rules": map[string]interface{}{"rules": rules}}},
+						"principals":  []interface{}{map[string]interface{}{"any": true}},
+					},
+				},
+			},
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(filter)
+})
+
+//topDomains returns the domains of set that aren't a subdomain of another domain in set
+func topDomains(set ExportSet) []string {
+	known := make(map[string]empty, len(set.Entries))
+	for _, entry := range set.Entries {
+		known[entry.Domain] = empty{}
+	}
+	domains := make([]string, 0, len(set.Entries))
+	for _, entry := range set.Entries {
+		covered := false
+		for _, parent := range GenerateVariants(entry.Domain) {
+			if _, ok := known[parent]; ok && parent != entry.Domain {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			domains = append(domains, entry.Domain)
+		}
+	}
+	return domains
+}
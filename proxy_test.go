@@ -0,0 +1,29 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProxyExporters(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "login.bad.com", "evil.org", "localhost"}}, SourceFeed)
+
+	var buf bytes.Buffer
+	a.NoError(WriteExport(c, &buf, ExportSquid))
+	a.Equal("# sinking yachts phishing domains\n.bad.com\n.evil.org\n.localhost\n", buf.String())
+
+	buf.Reset()
+	a.NoError(WriteExport(c, &buf, ExportEnvoy))
+	var filter map[string]interface{}
+	a.NoError(json.Unmarshal(buf.Bytes(), &filter))
+	a.Equal("envoy.filters.http.rbac", filter["name"])
+	a.Equal(6, strings.Count(buf.String(), `"name": ":authority"`))
+	a.Contains(buf.String(), `"suffix": ".evil.org"`)
+	a.NotContains(buf.String(), "login.bad.com")
+}
@@ -0,0 +1,59 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+)
+
+//ErrPublisherBehind is returned by PublishUpdates when the Publisher can't keep up with applied updates
+var ErrPublisherBehind = errors.New("publisher fell behind, updates were dropped")
+
+//Publisher publishes applied updates into external systems, such as a NATS subject or a Kafka topic
+//AppliedUpdate.MarshalJSON can be used as the message payload
+type Publisher interface {
+	Publish(ctx context.Context, update AppliedUpdate) error
+}
+
+//PublisherFunc is a function that implements Publisher
+type PublisherFunc func(ctx context.Context, update AppliedUpdate) error
+
+//Publish calls f(ctx, update)
+func (f PublisherFunc) Publish(ctx context.Context, update AppliedUpdate) error {
+	return f(ctx, update)
+}
+
+//PublishUpdates publishes every update applied to Client into Publisher in order
+//buffer is the amount of updates that may be queued while Publisher is busy
+//this function blocks and returns only when cancelled by ctx, when Publisher errors,
+//or with ErrPublisherBehind when more than buffer updates are queued
+func PublishUpdates(ctx context.Context, c *Client, p Publisher, buffer int) error {
+	queue := make(chan AppliedUpdate, buffer)
+	overflow := make(chan struct{})
+	overflowed := false
+	remove := c.OnUpdate(func(au AppliedUpdate) {
+		if overflowed {
+			return
+		}
+		select {
+		case queue <- au:
+		default:
+			overflowed = true
+			close(overflow)
+		}
+	})
+	defer remove()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-overflow:
+			return ErrPublisherBehind
+		case au := <-queue:
+			err := p.Publish(ctx, au)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
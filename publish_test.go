@@ -0,0 +1,40 @@
+package sinkingyachts
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPublishUpdates(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	received := make(chan []byte, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- PublishUpdates(ctx, c, PublisherFunc(func(ctx context.Context, update AppliedUpdate) error {
+			b, err := json.Marshal(update)
+			received <- b
+			return err
+		}), 4)
+	}()
+	a.Eventually(func() bool {
+		c.m.Lock()
+		defer c.m.Unlock()
+		return len(c.listeners) == 1
+	}, time.Second, time.Millisecond)
+
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+	var au AppliedUpdate
+	a.NoError(json.Unmarshal(<-received, &au))
+	a.Equal(DomainUpdate{Add: true, Domains: []string{"a.com"}}, au.Update)
+	a.Equal(SourceFeed, au.Source)
+	a.False(au.Time.IsZero())
+
+	cancel()
+	a.NoError(<-done)
+}
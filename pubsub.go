@@ -0,0 +1,54 @@
+package sinkingyachts
+
+import (
+	"context"
+	"encoding/json"
+)
+
+//PubSub is a minimal publish/subscribe transport, such as redis pub/sub
+//with go-redis, Publish maps to Client.Publish and Subscribe to Client.Subscribe(...).Channel()
+type PubSub interface {
+	//Publish sends payload to all subscribers of channel
+	Publish(ctx context.Context, channel string, payload []byte) error
+	//Subscribe returns a receive channel of payloads sent to channel
+	//the returned channel should be closed when ctx is cancelled or the subscription ends
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+//NewPubSubPublisher creates a Publisher that publishes updates as json into channel of PubSub
+//used together with PublishUpdates on the syncing instance, and SubscribeUpdates on the others
+func NewPubSubPublisher(ps PubSub, channel string) Publisher {
+	return PublisherFunc(func(ctx context.Context, update AppliedUpdate) error {
+		b, err := json.Marshal(update)
+		if err != nil {
+			return err
+		}
+		return ps.Publish(ctx, channel, b)
+	})
+}
+
+//SubscribeUpdates subscribes to channel of PubSub and applies received updates to Client
+//updates are applied as if they came from the websocket feed, with SourcePubSub as their source
+//this function blocks and returns only when cancelled by ctx, the subscription ends, or a payload is invalid
+func SubscribeUpdates(ctx context.Context, c *Client, ps PubSub, channel string) error {
+	ch, err := ps.Subscribe(ctx, channel)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case payload, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var au AppliedUpdate
+			err = json.Unmarshal(payload, &au)
+			if err != nil {
+				return err
+			}
+			c.applyLiveUpdates(au.Update, SourcePubSub)
+		}
+	}
+}
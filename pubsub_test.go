@@ -0,0 +1,126 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+//memPubSub is an in memory PubSub
+type memPubSub struct {
+	m    sync.Mutex
+	subs map[string][]chan []byte
+}
+
+func (ps *memPubSub) Publish(ctx context.Context, channel string, payload []byte) error {
+	ps.m.Lock()
+	defer ps.m.Unlock()
+	for _, ch := range ps.subs[channel] {
+		ch <- payload
+	}
+	return nil
+}
+
+func (ps *memPubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	ps.m.Lock()
+	defer ps.m.Unlock()
+	if ps.subs == nil {
+		ps.subs = map[string][]chan []byte{}
+	}
+	ch := make(chan []byte, 16)
+	ps.subs[channel] = append(ps.subs[channel], ch)
+	go func() {
+		<-ctx.Done()
+		ps.m.Lock()
+		defer ps.m.Unlock()
+		subs := ps.subs[channel][:0]
+		for _, sub := range ps.subs[channel] {
+			if sub != ch {
+				subs = append(subs, sub)
+			}
+		}
+		ps.subs[channel] = subs
+		close(ch)
+	}()
+	return ch, nil
+}
+
+//subscribers returns the amount of subscribers of channel
+func (ps *memPubSub) subscribers(channel string) int {
+	ps.m.Lock()
+	defer ps.m.Unlock()
+	return len(ps.subs[channel])
+}
+
+func TestPubSub(t *testing.T) {
+	a := assert.New(t)
+	ps := &memPubSub{}
+	src := New("", "test", http.Client{})
+	dst := New("", "test", http.Client{})
+	other := New("", "test", http.Client{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	published := make(chan error)
+	go func() {
+		published <- PublishUpdates(ctx, src, NewPubSubPublisher(ps, "updates"), 4)
+	}()
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	subscribed := make(chan error)
+	go func() {
+		subscribed <- SubscribeUpdates(subCtx, dst, ps, "updates")
+	}()
+	otherCtx, cancelOther := context.WithCancel(context.Background())
+	defer cancelOther()
+	go func() {
+		_ = SubscribeUpdates(otherCtx, other, ps, "other")
+	}()
+	a.Eventually(func() bool {
+		src.m.Lock()
+		defer src.m.Unlock()
+		return len(src.listeners) == 1 && ps.subscribers("updates") == 1 && ps.subscribers("other") == 1
+	}, time.Second, time.Millisecond)
+
+	var sources []UpdateSource
+	var sm sync.Mutex
+	dst.OnUpdate(func(au AppliedUpdate) {
+		sm.Lock()
+		defer sm.Unlock()
+		sources = append(sources, au.Source)
+	})
+	src.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	a.Eventually(func() bool { return dst.Check("a.com") && dst.Check("b.com") }, time.Second, time.Millisecond)
+	src.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	a.Eventually(func() bool { return !dst.Check("a.com") }, time.Second, time.Millisecond)
+	sm.Lock()
+	a.Equal([]UpdateSource{SourcePubSub, SourcePubSub}, sources)
+	sm.Unlock()
+	a.Zero(other.Size(), "updates are only delivered to subscribers of the channel")
+
+	unsubscribe()
+	a.NoError(<-subscribed)
+	a.Eventually(func() bool { return ps.subscribers("updates") == 0 }, time.Second, time.Millisecond)
+	src.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}}, SourceFeed)
+	time.Sleep(time.Millisecond * 20)
+	a.False(dst.Check("c.com"), "updates aren't applied after unsubscribing")
+
+	cancel()
+	a.NoError(<-published)
+}
+
+func TestSubscribeUpdatesInvalid(t *testing.T) {
+	a := assert.New(t)
+	ps := &memPubSub{}
+	c := New("", "test", http.Client{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- SubscribeUpdates(ctx, c, ps, "updates")
+	}()
+	a.Eventually(func() bool { return ps.subscribers("updates") == 1 }, time.Second, time.Millisecond)
+	a.NoError(ps.Publish(ctx, "updates", []byte("not json")))
+	a.Error(<-done)
+}
@@ -0,0 +1,174 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//the query protocol is line based, each request is a single line of a command and its argument
+//"CHECK <domain>", "FUZZY <domain>" and "SIZE" are supported
+//responses are a single line of "OK <result>" or "ERR <message>"
+const (
+	queryCheck = "CHECK"
+	queryFuzzy = "FUZZY"
+	querySize  = "SIZE"
+	queryOK    = "OK"
+	queryErr   = "ERR"
+)
+
+//ServeQueries serves Client's cache over a line protocol on the listener, usually a unix socket
+//this lets short-lived processes query a warm cache with QueryClient, instead of each doing a FullSync
+//this function blocks and returns only when cancelled by ctx, or when accepting connections fails
+//the listener and all open connections are closed upon return
+func ServeQueries(ctx context.Context, l net.Listener, c *Client) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveQueryConn(ctx, conn, c)
+		}()
+	}
+}
+
+//serveQueryConn answers queries on a single connection until it's closed or ctx is cancelled
+func serveQueryConn(ctx context.Context, conn net.Conn, c *Client) {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		_, _ = w.WriteString(answerQuery(c, scanner.Text()))
+		_ = w.WriteByte('\n')
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+//answerQuery returns the response line to a request line
+func answerQuery(c *Client, line string) string {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch strings.ToUpper(cmd) {
+	case queryCheck:
+		if arg == "" {
+			return queryErr + " missing domain"
+		}
+		return queryOK + " " + strconv.FormatBool(c.Check(arg))
+	case queryFuzzy:
+		if arg == "" {
+			return queryErr + " missing domain"
+		}
+		return queryOK + " " + strconv.FormatBool(c.FuzzyCheck(arg))
+	case querySize:
+		return queryOK + " " + strconv.Itoa(c.Size())
+	default:
+		return fmt.Sprintf("%s unknown command %q", queryErr, cmd)
+	}
+}
+
+//QueryClient is a thin client for querying a cache served by ServeQueries
+//it is safe for concurrent use, queries are sent one at a time over a single connection
+type QueryClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	m    sync.Mutex
+}
+
+//DialQuery connects to a cache served by ServeQueries
+//for example DialQuery("unix", "/run/sinkingyachts.sock")
+func DialQuery(network, address string) (*QueryClient, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryClient{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}, nil
+}
+
+//Check if a domain is phishing, see Client.Check
+func (q *QueryClient) Check(domain string) (bool, error) {
+	res, err := q.query(queryCheck, domain)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(res)
+}
+
+//FuzzyCheck if a domain or its parent domains are phishing, see Client.FuzzyCheck
+func (q *QueryClient) FuzzyCheck(domain string) (bool, error) {
+	res, err := q.query(queryFuzzy, domain)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(res)
+}
+
+//Size return the amount of known phishing domains, see Client.Size
+func (q *QueryClient) Size() (int, error) {
+	res, err := q.query(querySize, "")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(res)
+}
+
+//Close closes the connection
+func (q *QueryClient) Close() error {
+	return q.conn.Close()
+}
+
+//query sends a request line and returns the result of the response
+func (q *QueryClient) query(cmd, arg string) (string, error) {
+	if strings.ContainsAny(arg, " \t\r\n") {
+		return "", fmt.Errorf("invalid domain %q", arg)
+	}
+	line := cmd
+	if arg != "" {
+		line += " " + arg
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+	_, err := q.conn.Write([]byte(line + "\n"))
+	if err != nil {
+		return "", err
+	}
+	res, err := q.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	status, result, _ := strings.Cut(strings.TrimRight(res, "\r\n"), " ")
+	if status != queryOK {
+		return "", errors.New(result)
+	}
+	return result, nil
+}
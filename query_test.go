@@ -0,0 +1,48 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeQueries(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+
+	sock := filepath.Join(t.TempDir(), "query.sock")
+	l, err := net.Listen("unix", sock)
+	a.NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ServeQueries(ctx, l, c)
+	}()
+
+	q, err := DialQuery("unix", sock)
+	a.NoError(err)
+	found, err := q.Check("bad.com")
+	a.NoError(err)
+	a.True(found)
+	found, err = q.Check("foo.bad.com")
+	a.NoError(err)
+	a.False(found)
+	found, err = q.FuzzyCheck("foo.bad.com")
+	a.NoError(err)
+	a.True(found)
+	size, err := q.Size()
+	a.NoError(err)
+	a.Equal(1, size)
+	_, err = q.Check("bad .com")
+	a.Error(err)
+	_, err = q.query("NOPE", "")
+	a.EqualError(err, `unknown command "NOPE"`)
+
+	cancel()
+	a.NoError(<-done)
+	a.NoError(q.Close())
+}
@@ -0,0 +1,123 @@
+package sinkingyachts
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//authTokenKey is the context key of the token a request got authorized with by BearerAuth
+type authTokenKey struct{}
+
+//BearerAuth wraps h to require "Authorization: Bearer <token>" with any of the tokens
+//requests without a valid token are rejected with 401, no tokens rejects every request
+func BearerAuth(h http.Handler, tokens ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, token := range tokens {
+			if token != "" && bearerAuthorized(r, token) {
+				h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authTokenKey{}, token)))
+				return
+			}
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+//RateLimiter limits the requests of each client with a token bucket
+//clients are told apart by the token they got authorized with if wrapped by BearerAuth, or else by their ip address
+//wrapping BearerAuth instead, such as limiter.Wrap(BearerAuth(mirror, tokens...)), also limits guessing tokens, by ip address
+type RateLimiter struct {
+	rate    float64
+	burst   float64
+	m       sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+//bucket is the token bucket of a client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+//NewRateLimiter creates a RateLimiter allowing rate requests per second per client, with bursts of up to burst requests
+//a rate of 0 or less doesn't limit requests
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+	}
+}
+
+//Wrap wraps h to reject requests beyond the limit with 429, along with a Retry-After of when to try again
+func (l *RateLimiter) Wrap(h http.Handler) http.Handler {
+	if l.rate <= 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.allow(rateKey(r), time.Now())
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+//allow takes a token from the client's bucket, or returns how long until the next token if it's empty
+func (l *RateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+//sweep forgets clients whose bucket has refilled, at most once a minute
+//should only be called when mutex is locked
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+//rateKey identifies the client of a request
+func rateKey(r *http.Request) string {
+	if token, ok := r.Context().Value(authTokenKey{}).(string); ok {
+		return "token:" + token
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,102 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	a := assert.New(t)
+	l := NewRateLimiter(2, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("a", now)
+		a.True(ok)
+	}
+	ok, wait := l.allow("a", now)
+	a.False(ok)
+	a.Equal(time.Millisecond*500, wait)
+	ok, _ = l.allow("b", now)
+	a.True(ok)
+	ok, _ = l.allow("a", now.Add(time.Millisecond*500))
+	a.True(ok)
+
+	l.allow("b", now.Add(time.Hour))
+	a.Len(l.buckets, 1)
+}
+
+func TestBearerAuthRateLimit(t *testing.T) {
+	handler := BearerAuth(NewRateLimiter(1, 1).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})), "one", "two")
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"invalid", "three", http.StatusUnauthorized},
+		{"first", "one", http.StatusNoContent},
+		{"first limited", "one", http.StatusTooManyRequests},
+		{"second", "two", http.StatusNoContent},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			req := httptest.NewRequest(http.MethodGet, "/v2/all/", nil)
+			if data.token != "" {
+				req.Header.Set("Authorization", "Bearer "+data.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			a.Equal(data.status, rec.Code)
+			if data.status == http.StatusTooManyRequests {
+				a.Equal("1", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestRateLimiterLimitsAuth(t *testing.T) {
+	handler := NewRateLimiter(1, 2).Wrap(BearerAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), "one"))
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"guess", "two", http.StatusUnauthorized},
+		{"valid", "one", http.StatusNoContent},
+		{"guess limited", "three", http.StatusTooManyRequests},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			req := httptest.NewRequest(http.MethodGet, "/v2/all/", nil)
+			req.Header.Set("Authorization", "Bearer "+data.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			a.Equal(data.status, rec.Code)
+		})
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	a := assert.New(t)
+	for _, rate := range []float64{0, -1} {
+		handler := NewRateLimiter(rate, 1).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		for i := 0; i < 3; i++ {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/all/", nil))
+			a.Equal(http.StatusNoContent, rec.Code)
+		}
+	}
+}
@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"math"
 	"net/http"
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 	"strconv"
 	"time"
 )
@@ -17,11 +17,28 @@ import (
 //it does not cache and all responses are blocking
 //it is safe for concurrent use
 type RawClient struct {
-	domain      string
-	identity    string
-	webClient   http.Client
-	header      http.Header
-	feedTimeout time.Duration
+	domain          string
+	identity        string
+	webClient       http.Client
+	header          http.Header
+	feedTimeout     time.Duration
+	strict          bool
+	onInvalid       func(domain string, err error)
+	baseCtx         context.Context
+	readLimit       int64
+	msgTimeout      time.Duration
+	pingInterval    time.Duration
+	pingTimeout     time.Duration
+	recentWindow    time.Duration
+	backpressure    Backpressure
+	consumerTimeout time.Duration
+	feedBuffer      int
+	feedProtocol    FeedProtocol
+	feedCodecs      []Codec
+	bulkWorkers     int
+	bulkRate        time.Duration
+	transfer        *transferStats
+	transport       transportConfig
 }
 
 //NewRawClient creates a new RawClient
@@ -36,37 +53,103 @@ func NewRawClient(domain, identity string, webClient http.Client, options ...Opt
 	h.Set("User-Agent", "sinkingyachts/0.1 (https://github.com/Thunder33345/sinkingyachts)")
 
 	client := RawClient{
-		domain:      domain,
-		identity:    identity,
-		webClient:   webClient,
-		header:      h,
-		feedTimeout: time.Second * 5,
+		domain:       domain,
+		identity:     identity,
+		webClient:    webClient,
+		header:       h,
+		feedTimeout:  time.Second * 5,
+		baseCtx:      context.Background(),
+		bulkWorkers:  4,
+		bulkRate:     time.Millisecond * 50,
+		transfer:     newTransferStats(),
+		recentWindow: defaultRecentWindow,
 	}
 	for _, option := range options {
 		option(&client)
 	}
 	client.header = fixHeaders(client.header, client.identity)
+	client.applyTransport()
 	return client
 }
 
+//As returns a copy of RawClient that identifies as identity
+//this is useful for relays making requests on behalf of different downstream applications
+//the copy shares the same http.Client and otherwise behaves the same, for example c.As("Foo Bot (foo@example.com)").Check(domain)
+func (c RawClient) As(identity string) RawClient {
+	c.identity = identity
+	c.header = fixHeaders(c.header, identity)
+	return c
+}
+
+//With returns a copy of RawClient with options applied on top of its current configuration
+//the copy shares the same transport, so one connection pool can serve several configurations
+//unless transport options are given, which make the copy use its own transport
+//X-Identity cannot be overwritten with options, use As instead
+func (c RawClient) With(options ...Option) RawClient {
+	c.header = c.header.Clone()
+	for _, option := range options {
+		option(&c)
+	}
+	c.header = fixHeaders(c.header, c.identity)
+	c.applyTransport()
+	return c
+}
+
+//feedUpdate is an update from the feed, along with when it was received
+type feedUpdate struct {
+	mod      DomainUpdate
+	received time.Time
+}
+
+//delay returns how long the update took to propagate from being made upstream to being received from the feed
+//it is 0 unless the source provides DomainUpdate.Time, and depends on the clocks of both ends being in sync
+func (u feedUpdate) delay() time.Duration {
+	if u.mod.Time.IsZero() || u.received.IsZero() {
+		return 0
+	}
+	return u.received.Sub(u.mod.Time)
+}
+
 //Feed connects into the wss endpoint to get live updates
 //Feed will block forever, and only returns if ctx cancels it, or there's an error
 //to cancel use context.WithCancel as ctx
 //error will be nil when process exited cleanly
+//what happens when modFeed isn't received from in time is set by WithFeedBackpressure
 func (c RawClient) Feed(ctx context.Context, modFeed chan DomainUpdate) error {
+	return c.feed(ctx, func(ctx context.Context, mod DomainUpdate, _ time.Time) (bool, error) {
+		return sendFeed(ctx, c, modFeed, mod)
+	})
+}
+
+//feedReceived is Feed, passing along when each update was received
+func (c RawClient) feedReceived(ctx context.Context, modFeed chan feedUpdate) error {
+	return c.feed(ctx, func(ctx context.Context, mod DomainUpdate, received time.Time) (bool, error) {
+		return sendFeed(ctx, c, modFeed, feedUpdate{mod: mod, received: received})
+	})
+}
+
+//feed reads the feed, passing every update to send along with when it was received
+//send returns false to stop reading, along with the error to stop with
+func (c RawClient) feed(ctx context.Context, send func(ctx context.Context, mod DomainUpdate, received time.Time) (bool, error)) error {
+	ctx, cancelBase := c.withBase(ctx)
+	defer cancelBase()
 	var cn *websocket.Conn
 
 	var err error
 	opCtx, cancel := context.WithTimeout(ctx, c.feedTimeout)
 	cn, _, err = websocket.Dial(opCtx, c.domain+endpointFeed, &websocket.DialOptions{
-		HTTPClient: &c.webClient,
-		HTTPHeader: c.header,
+		HTTPClient:   &c.webClient,
+		HTTPHeader:   c.header,
+		Subprotocols: subprotocols(c.offeredCodecs()),
 	})
 	cancel()
 
 	if err != nil {
 		return err
 	}
+	if c.readLimit > 0 {
+		cn.SetReadLimit(c.readLimit)
+	}
 
 	defer func() {
 		if err == nil || errors.Is(err, ctx.Err()) {
@@ -78,23 +161,102 @@ func (c RawClient) Feed(ctx context.Context, modFeed chan DomainUpdate) error {
 		}
 	}()
 
+	codec := c.negotiatedCodec(cn.Subprotocol())
+	readCtx, stopPing := c.pingFeed(ctx, cn)
+	defer stopPing()
 	for {
-		var mod DomainUpdate
-		err = wsjson.Read(ctx, cn, &mod)
+		var mods []DomainUpdate
+		mods, err = c.readFeed(readCtx, cn, codec)
 		if err != nil {
 			if errors.Is(err, ctx.Err()) {
 				return nil
 			}
+			if errPing := stopPing(); errPing != nil {
+				err = errPing
+			}
 			return err
 		}
-		modFeed <- mod
+		received := time.Now()
+		for _, mod := range mods {
+			mod.Domains = c.filterDomains(mod.Domains)
+			var sent bool
+			sent, err = send(ctx, mod, received)
+			if !sent {
+				return err
+			}
+		}
+	}
+}
+
+//pingFeed pings the feed while ctx is active if pings are enabled
+//it returns the context reads should use, which is cancelled when a ping fails
+//and a function that stops pinging and returns the ping's error, it must be called once reading stops
+func (c RawClient) pingFeed(ctx context.Context, cn *websocket.Conn) (context.Context, func() error) {
+	if c.pingInterval <= 0 {
+		return ctx, func() error { return nil }
+	}
+	readCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var pingErr error
+	go func() {
+		defer close(done)
+		defer cancel()
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-readCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			pingCtx, cancelPing := context.WithTimeout(readCtx, c.pingTimeout)
+			err := cn.Ping(pingCtx)
+			timedOut := errors.Is(pingCtx.Err(), context.DeadlineExceeded)
+			cancelPing()
+			if err != nil {
+				//pings stopped by reading stopping are not failures
+				if timedOut {
+					pingErr = fmt.Errorf("no feed pong received within %s: %w", c.pingTimeout, err)
+				}
+				return
+			}
+		}
+	}()
+	return readCtx, func() error {
+		cancel()
+		<-done
+		return pingErr
+	}
+}
+
+//readFeed reads a single message from the feed and decodes its updates with the codec, bounded by the message timeout if set
+func (c RawClient) readFeed(ctx context.Context, cn *websocket.Conn, codec Codec) ([]DomainUpdate, error) {
+	readCtx := ctx
+	if c.msgTimeout > 0 {
+		var cancel context.CancelFunc
+		readCtx, cancel = context.WithTimeout(ctx, c.msgTimeout)
+		defer cancel()
+	}
+	_, data, err := cn.Read(readCtx)
+	if err != nil {
+		if c.msgTimeout > 0 && ctx.Err() == nil && errors.Is(readCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no feed message received within %s: %w", c.msgTimeout, err)
+		}
+		return nil, err
 	}
+	c.transfer.feedReceived(len(data))
+	return codec.Decode(data)
 }
 
 //Check will check if a domain is a phishing domain
 //true if it's flagged as phishing, false otherwise
 func (c RawClient) Check(domain string) (bool, error) {
-	resp, err := c.doReq(endpointCheck + domain)
+	return c.check(c.baseCtx, domain)
+}
+
+//check is Check bounded by ctx
+func (c RawClient) check(ctx context.Context, domain string) (bool, error) {
+	resp, err := c.doReqContext(ctx, endpointCheck+domain)
 	if err != nil {
 		return false, err
 	}
@@ -137,7 +299,7 @@ func (c RawClient) All() ([]string, error) {
 	dec := json.NewDecoder(resp.Body)
 	var domains []string
 	err = dec.Decode(&domains)
-	return domains, err
+	return c.filterDomains(domains), err
 }
 
 //After will return a slice of changes that are after said time
@@ -149,7 +311,12 @@ func (c RawClient) After(after time.Time) ([]DomainUpdate, error) {
 //Recent returns changes that are recently done in given seconds
 //Changes will be represented as DomainUpdate
 func (c RawClient) Recent(seconds int) ([]DomainUpdate, error) {
-	resp, err := c.doReq(endpointRecent + strconv.Itoa(seconds))
+	return c.recent(c.baseCtx, seconds)
+}
+
+//recent is Recent bounded by ctx
+func (c RawClient) recent(ctx context.Context, seconds int) ([]DomainUpdate, error) {
+	resp, err := c.doReqContext(ctx, endpointRecent+strconv.Itoa(seconds))
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +331,9 @@ func (c RawClient) Recent(seconds int) ([]DomainUpdate, error) {
 	dec := json.NewDecoder(resp.Body)
 	var mods []DomainUpdate
 	err = dec.Decode(&mods)
+	for i := range mods {
+		mods[i].Domains = c.filterDomains(mods[i].Domains)
+	}
 	return mods, err
 }
 
@@ -189,12 +359,51 @@ func (c RawClient) Size() (int, error) {
 }
 
 func (c RawClient) doReq(endpoint string) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, c.domain+endpoint, nil)
+	return c.doReqContext(c.baseCtx, endpoint)
+}
+
+func (c RawClient) doReqContext(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.domain+endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header = c.header
-	return c.webClient.Do(req)
+	resp, err := c.webClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.transfer.countResponse(endpoint, resp)
+	return resp, nil
+}
+
+//withBase returns a context of ctx that carries the base context's values, and is also cancelled when the base context is done
+//values of ctx take precedence over the base context's values
+func (c RawClient) withBase(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(baseContext{Context: ctx, base: c.baseCtx})
+	if c.baseCtx.Done() == nil {
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-c.baseCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+//baseContext is a context that falls back to the values of base
+type baseContext struct {
+	context.Context
+	base context.Context
+}
+
+func (b baseContext) Value(key interface{}) interface{} {
+	if v := b.Context.Value(key); v != nil {
+		return v
+	}
+	return b.base.Value(key)
 }
 
 //fixHeaders is an internal function that returns a cloned header if given header not nil
@@ -0,0 +1,227 @@
+package sinkingyachts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"nhooyr.io/websocket"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRawClientAs(t *testing.T) {
+	a := assert.New(t)
+	var identities []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identities = append(identities, r.Header.Get("X-Identity"))
+		_, _ = w.Write([]byte("true"))
+	}))
+	defer srv.Close()
+
+	c := NewRawClient(srv.URL, "relay", http.Client{})
+	_, err := c.As("tenant").Check("a.com")
+	a.NoError(err)
+	_, err = c.Check("a.com")
+	a.NoError(err)
+	a.Equal([]string{"tenant", "relay"}, identities)
+}
+
+func TestRawClientWith(t *testing.T) {
+	a := assert.New(t)
+	c := NewRawClient("", "identity", http.Client{}, WithHeader("X-Foo", "foo"))
+	with := c.With(WithHeader("X-Foo", "bar"), WithHeader("X-Identity", "other"), WithTimeout(time.Second))
+	a.Equal("foo", c.header.Get("X-Foo"))
+	a.Equal("bar", with.header.Get("X-Foo"))
+	a.Equal("identity", with.header.Get("X-Identity"))
+	a.Equal(time.Duration(0), c.webClient.Timeout)
+	a.Equal(time.Second, with.webClient.Timeout)
+}
+
+func TestCheckMany(t *testing.T) {
+	a := assert.New(t)
+	var m sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		requests++
+		m.Unlock()
+		_, _ = w.Write([]byte(strconv.FormatBool(strings.HasPrefix(r.URL.Path, endpointCheck+"bad"))))
+	}))
+	defer srv.Close()
+
+	c := NewRawClient(srv.URL, "test", http.Client{}, WithBulkLimits(2, time.Millisecond))
+	results, err := c.CheckMany(context.Background(), []string{"bad.com", "good.com", "bad.net", "bad.com"})
+	a.NoError(err)
+	a.Equal(map[string]bool{"bad.com": true, "good.com": false, "bad.net": true}, results)
+	a.Equal(3, requests)
+}
+
+//baseKey is the context key of values set on the base context
+type baseKey struct{}
+
+//baseTransport records the base value of the context of every request
+type baseTransport struct {
+	m    sync.Mutex
+	seen []interface{}
+}
+
+func (t *baseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.m.Lock()
+	t.seen = append(t.seen, req.Context().Value(baseKey{}))
+	t.m.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+//values returns the recorded values and clears them
+func (t *baseTransport) values() []interface{} {
+	t.m.Lock()
+	defer t.m.Unlock()
+	seen := t.seen
+	t.seen = nil
+	return seen
+}
+
+func TestWithBaseContext(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == endpointFeed {
+			cn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer cn.Close(websocket.StatusNormalClosure, "")
+			_ = cn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"add","domains":["a.com"]}`))
+			<-cn.CloseRead(r.Context()).Done()
+			return
+		}
+		_, _ = w.Write([]byte("true"))
+	}))
+	defer srv.Close()
+
+	transport := &baseTransport{}
+	base, cancelBase := context.WithCancel(context.WithValue(context.Background(), baseKey{}, "base"))
+	defer cancelBase()
+	c := NewRawClient(srv.URL, "test", http.Client{Transport: transport}, WithBaseContext(base))
+
+	_, err := c.Check("a.com")
+	a.NoError(err)
+	a.Equal([]interface{}{"base"}, transport.values(), "Check carries the base value")
+
+	_, err = c.CheckMany(context.Background(), []string{"a.com", "b.com"})
+	a.NoError(err)
+	a.Equal([]interface{}{"base", "base"}, transport.values(), "CheckMany carries the base value")
+
+	_, err = c.CheckMany(context.WithValue(context.Background(), baseKey{}, "own"), []string{"a.com"})
+	a.NoError(err)
+	a.Equal([]interface{}{"own"}, transport.values(), "values of the given context take precedence")
+
+	feedDone := make(chan error)
+	modFeed := make(chan DomainUpdate, 1)
+	go func() {
+		feedDone <- c.Feed(context.Background(), modFeed)
+	}()
+	<-modFeed
+	a.Equal([]interface{}{"base"}, transport.values(), "Feed carries the base value")
+
+	cancelBase()
+	select {
+	case err = <-feedDone:
+		a.NoError(err)
+	case <-time.After(time.Second):
+		a.Fail("Feed isn't cancelled with the base context")
+	}
+	_, err = c.Check("a.com")
+	a.ErrorIs(err, context.Canceled)
+	_, err = c.CheckMany(context.Background(), []string{"a.com"})
+	a.ErrorIs(err, context.Canceled)
+
+	c = NewRawClient(srv.URL, "test", http.Client{}, WithBaseContext(nil))
+	_, err = c.Check("a.com")
+	a.NoError(err, "a nil base context is ignored")
+}
+
+func TestTransportOptions(t *testing.T) {
+	a := assert.New(t)
+	base := NewHTTPClient(0)
+	c := NewRawClient("", "identity", base, WithHTTPVersion(HTTP1), WithMaxIdleConns(4, 2), WithIdleConnTimeout(time.Second), WithTCPKeepAlive(time.Minute))
+	tr, ok := c.webClient.Transport.(*http.Transport)
+	a.True(ok)
+	a.NotSame(base.Transport, tr)
+	a.False(tr.ForceAttemptHTTP2)
+	a.NotNil(tr.TLSNextProto)
+	a.Equal(4, tr.MaxIdleConns)
+	a.Equal(2, tr.MaxIdleConnsPerHost)
+	a.Equal(time.Second, tr.IdleConnTimeout)
+	a.Equal(32, base.Transport.(*http.Transport).MaxIdleConns, "the given transport is left untouched")
+
+	a.Same(tr, c.With(WithHeader("X-Foo", "foo")).webClient.Transport, "copies without transport options share the transport")
+	with := c.With(WithMaxIdleConns(8, 0))
+	a.NotSame(tr, with.webClient.Transport)
+	a.Equal(8, with.webClient.Transport.(*http.Transport).MaxIdleConns)
+	a.Equal(2, with.webClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+
+	a.NotNil(NewRawClient("", "identity", http.Client{}, WithHTTP2()).webClient.Transport)
+	rec := NewRecorder(nil)
+	a.Same(rec, NewRawClient("", "identity", http.Client{Transport: rec}, WithHTTP2()).webClient.Transport)
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	a := assert.New(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a.NoError(err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "follower"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	a.NoError(err)
+	leaf, err := x509.ParseCertificate(der)
+	a.NoError(err)
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+	mirror := NewMirror(primary)
+	defer mirror.Close()
+	srv := httptest.NewUnstartedServer(mirror)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	_, err = New(srv.URL, "test", NewHTTPClient(0), WithRootCAs(roots)).Raw().All()
+	a.Error(err, "the server requires a client certificate")
+
+	c := New(srv.URL, "test", NewHTTPClient(0), WithRootCAs(roots), WithClientCertificate(cert))
+	a.NoError(c.FullSync())
+	a.True(c.Check("a.com"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = c.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool {
+		mirror.feed.m.Lock()
+		defer mirror.feed.m.Unlock()
+		return len(mirror.feed.followers) == 1
+	}, time.Second, time.Millisecond*10)
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com"}}, SourceFeed)
+	a.Eventually(func() bool { return c.Check("b.com") }, time.Second, time.Millisecond*10)
+}
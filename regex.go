@@ -0,0 +1,111 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+//limits of regex rules, they keep checks cheap no matter what rules are configured
+//go regexps run in linear time, so only the size of the rules and the amount of them needs to be bounded
+const (
+	//MaxRegexRules is the most regex rules a Client can have
+	MaxRegexRules = 256
+	//maxRegexLength is the longest pattern of a regex rule
+	maxRegexLength = 256
+	//maxRegexInst is the most instructions a compiled regex rule may have
+	maxRegexInst = 1024
+)
+
+//ErrRegexTooComplex is returned by SetRegexRules when a rule exceeds the safety limits
+var ErrRegexTooComplex = errors.New("regex rule is too complex")
+
+//regexRule is a compiled regex rule
+type regexRule struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+//SetRegexRules replaces the regex rules of Client, the rules are evaluated after a domain didn't match any known or local domain
+//rules must match the whole domain, "discord-nitro-[a-z0-9]{5}\.com" matches "discord-nitro-ab12c.com" but not "foo.discord-nitro-ab12c.com"
+//FuzzyCheck also matches rules against the parent domains, so it matches both
+//this is meant for algorithmically generated campaign domains, which can't be listed ahead of time
+//rules are not part of the cache, so they are neither persisted nor exported
+//no rules are replaced if any of them is invalid, too long or too complex, or if there are more than MaxRegexRules
+func (c *Client) SetRegexRules(patterns ...string) error {
+	rules, err := compileRegexRules(patterns)
+	if err != nil {
+		return err
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, old := range c.regexRules {
+		if !containsString(patterns, old.pattern) {
+			c.forgetRule(RuleRegex, old.pattern)
+		}
+	}
+	c.regexRules = rules
+	return nil
+}
+
+//RegexRules returns the patterns of the regex rules
+func (c *Client) RegexRules() []string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	patterns := make([]string, 0, len(c.regexRules))
+	for _, rule := range c.regexRules {
+		patterns = append(patterns, rule.pattern)
+	}
+	return patterns
+}
+
+//compileRegexRules compiles patterns into regex rules, enforcing the limit on the amount of rules
+func compileRegexRules(patterns []string) ([]regexRule, error) {
+	if len(patterns) > MaxRegexRules {
+		return nil, fmt.Errorf("%w: %d rules exceeds the limit of %d", ErrRegexTooComplex, len(patterns), MaxRegexRules)
+	}
+	rules := make([]regexRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileRegexRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, regexRule{pattern: pattern, re: re})
+	}
+	return rules, nil
+}
+
+//compileRegexRule compiles a pattern into a regexp anchored to the whole domain, enforcing the safety limits
+func compileRegexRule(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxRegexLength {
+		return nil, fmt.Errorf("%w: %q is longer than %d", ErrRegexTooComplex, pattern, maxRegexLength)
+	}
+	anchored := `^(?:` + pattern + `)$`
+	parsed, err := syntax.Parse(anchored, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex rule %q: %w", pattern, err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex rule %q: %w", pattern, err)
+	}
+	if len(prog.Inst) > maxRegexInst {
+		return nil, fmt.Errorf("%w: %q compiles to %d instructions", ErrRegexTooComplex, pattern, len(prog.Inst))
+	}
+	return regexp.Compile(anchored)
+}
+
+//matchRegex returns the pattern of the first regex rule that matches domain, or empty if none
+func (c *Client) matchRegex(domain string) string {
+	c.m.Lock()
+	rules := c.regexRules
+	c.m.Unlock()
+	for _, rule := range rules {
+		if rule.re.MatchString(domain) {
+			c.countRuleHit(RuleRegex, rule.pattern)
+			return rule.pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegexRules(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"discord-nitro-known.com"}}, SourceFeed)
+	a.NoError(c.SetRegexRules(`discord-nitro-[a-z0-9]{5}\.com`))
+	a.Equal([]string{`discord-nitro-[a-z0-9]{5}\.com`}, c.RegexRules())
+
+	a.True(c.Check("discord-nitro-ab12c.com"))
+	a.False(c.Check("discord-nitro-ab12c.com.evil"))
+	a.False(c.Check("foo.discord-nitro-ab12c.com"))
+	a.True(c.FuzzyCheck("foo.discord-nitro-ab12c.com"))
+
+	m := c.CheckDetailed("foo.discord-nitro-ab12c.com")
+	a.Equal("discord-nitro-ab12c.com", m.Matched)
+	a.Equal(`discord-nitro-[a-z0-9]{5}\.com`, m.Rule)
+	//known domains take priority over rules
+	m = c.CheckDetailed("discord-nitro-known.com")
+	a.Equal("discord-nitro-known.com", m.Matched)
+	a.Empty(m.Rule)
+
+	a.NoError(c.SetRegexRules())
+	a.False(c.Check("discord-nitro-ab12c.com"))
+}
+
+func TestRegexRulesLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		complex  bool
+	}{
+		{name: "Invalid", patterns: []string{"("}},
+		{name: "Too long", patterns: []string{strings.Repeat("a", maxRegexLength+1)}, complex: true},
+		{name: "Too complex", patterns: []string{`[a-z]{1000}[0-9]{1000}`}, complex: true},
+		{name: "Too many", patterns: make([]string, MaxRegexRules+1), complex: true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			c := New("", "test", http.Client{})
+			a.NoError(c.SetRegexRules("a.com"))
+			err := c.SetRegexRules(data.patterns...)
+			a.Error(err)
+			a.Equal(data.complex, errors.Is(err, ErrRegexTooComplex))
+			a.Equal([]string{"a.com"}, c.RegexRules())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package sinkingyachts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//endpointRepair is the endpoint of repair buckets served by Mirror
+const endpointRepair = "/repair"
+
+var (
+	//errInvalidBucket is returned by Mirror for repair buckets that don't exist
+	errInvalidBucket = errors.New("invalid bucket")
+	//errBucketCount is returned by RawClient.RepairHashes when a Mirror doesn't return a hash for every bucket
+	errBucketCount = errors.New("unexpected amount of repair buckets")
+)
+
+//repairBuckets is the amount of buckets domains are split into for repairs
+const repairBuckets = 256
+
+//repairBucket returns the bucket of a domain
+func repairBucket(domain string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(domain))
+	return int(h.Sum32() % repairBuckets)
+}
+
+//bucketDomains splits domains into their repair buckets, the domains of each bucket are sorted
+func bucketDomains(domains []string) [repairBuckets][]string {
+	var buckets [repairBuckets][]string
+	for _, domain := range domains {
+		b := repairBucket(domain)
+		buckets[b] = append(buckets[b], domain)
+	}
+	for _, bucket := range buckets {
+		sort.Strings(bucket)
+	}
+	return buckets
+}
+
+//bucketHashes returns the hash of every bucket, as lower case hex of a sha256 over the sorted domains of the bucket
+//categories are not included, as repairs only transfer domains
+func bucketHashes(buckets [repairBuckets][]string) []string {
+	hashes := make([]string, repairBuckets)
+	for i, bucket := range buckets {
+		h := sha256.New()
+		for _, domain := range bucket {
+			writeHashEntry(h, hashEntry{domain: domain})
+		}
+		hashes[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes
+}
+
+//RepairHashes returns the hashes of a Mirror's repair buckets, see Client.Repair
+func (c RawClient) RepairHashes() ([]string, error) {
+	var hashes []string
+	err := c.getJSON(endpointRepair, &hashes)
+	if err == nil && len(hashes) != repairBuckets {
+		err = errBucketCount
+	}
+	return hashes, err
+}
+
+//RepairDomains returns the domains of a Mirror's repair buckets, mapped by bucket, see Client.Repair
+func (c RawClient) RepairDomains(buckets []int) (map[int][]string, error) {
+	ids := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		ids = append(ids, strconv.Itoa(b))
+	}
+	query := url.Values{}
+	query.Set("buckets", strings.Join(ids, ","))
+	var domains map[int][]string
+	err := c.getJSON(endpointRepair+"?"+query.Encode(), &domains)
+	for b, ds := range domains {
+		domains[b] = c.filterDomains(ds)
+	}
+	return domains, err
+}
+
+//getJSON requests endpoint and decodes the json response into v
+func (c RawClient) getJSON(endpoint string, v interface{}) error {
+	resp, err := c.doReq(endpoint)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != 200 {
+		return unexpectedStatusError{
+			endpoint: c.domain + endpoint,
+			status:   resp.StatusCode,
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+//Repair compares the cache against a Mirror, and fixes only the parts that differ instead of doing a FullSync
+//domains are split into 256 buckets by their hash, and only the domains of buckets whose hashes differ are transferred
+//this is meant for followers whose hash doesn't match the Mirror's, such as after missing updates during an incident
+//the changes are applied as updates from SourceRepair, and returned as a Diff
+//the Client must be pointed at a Mirror, the api itself doesn't serve repairs
+func (c *Client) Repair() (Diff, error) {
+	if c.Paused() {
+		return Diff{}, ErrPaused
+	}
+	remote, err := c.r.RepairHashes()
+	if err != nil {
+		return Diff{}, err
+	}
+	local := bucketDomains(c.Domains())
+	localHashes := bucketHashes(local)
+	var differ []int
+	for i, h := range localHashes {
+		if remote[i] != h {
+			differ = append(differ, i)
+		}
+	}
+	if len(differ) == 0 {
+		return Diff{}, nil
+	}
+	domains, err := c.r.RepairDomains(differ)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	var diff Diff
+	for _, b := range differ {
+		bucketDiff := DiffDomains(local[b], domains[b])
+		diff.Added = append(diff.Added, bucketDiff.Added...)
+		diff.Removed = append(diff.Removed, bucketDiff.Removed...)
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	c.ApplyUpdates(diff.Updates(), SourceRepair)
+	return diff, nil
+}
+
+//serveRepair responds with the hashes of every bucket, or the domains of the buckets listed in the buckets query
+func (m *Mirror) serveRepair(w http.ResponseWriter, r *http.Request) error {
+	buckets := bucketDomains(m.c.Domains())
+	query := r.URL.Query().Get("buckets")
+	if query == "" {
+		return writeJSON(w, bucketHashes(buckets))
+	}
+	domains := map[int][]string{}
+	for _, id := range strings.Split(query, ",") {
+		b, err := strconv.Atoi(id)
+		if err != nil || b < 0 || b >= repairBuckets {
+			return badRequest{errInvalidBucket}
+		}
+		domains[b] = buckets[b]
+	}
+	return writeJSON(w, domains)
+}
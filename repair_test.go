@@ -0,0 +1,60 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com", "c.com", "d.com"}}, SourceFeed)
+	var requested []string
+	mirror := NewMirror(primary)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RawQuery)
+		mirror.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	follower := New(srv.URL, "test", http.Client{})
+	follower.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com", "stale.com"}}, SourceFeed)
+	var sources []UpdateSource
+	follower.OnUpdate(func(au AppliedUpdate) {
+		sources = append(sources, au.Source)
+	})
+	diff, err := follower.Repair()
+	a.NoError(err)
+	a.Equal([]string{"c.com", "d.com"}, diff.Added)
+	a.Equal([]string{"stale.com"}, diff.Removed)
+	a.Equal(primary.Hash(), follower.Hash())
+	a.Equal([]UpdateSource{SourceRepair, SourceRepair}, sources)
+	//only the buckets that differ are transferred
+	a.Len(requested, 2)
+	query, err := url.ParseQuery(requested[1])
+	a.NoError(err)
+	expected := map[string]bool{}
+	for _, domain := range []string{"c.com", "d.com", "stale.com"} {
+		expected[strconv.Itoa(repairBucket(domain))] = true
+	}
+	buckets := map[string]bool{}
+	for _, b := range strings.Split(query.Get("buckets"), ",") {
+		buckets[b] = true
+	}
+	a.Equal(expected, buckets)
+
+	diff, err = follower.Repair()
+	a.NoError(err)
+	a.True(diff.Empty())
+	a.Len(requested, 3)
+
+	resp, err := http.Get(srv.URL + endpointRepair + "?buckets=256")
+	a.NoError(err)
+	resp.Body.Close()
+	a.Equal(http.StatusBadRequest, resp.StatusCode)
+}
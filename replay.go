@@ -0,0 +1,84 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+//Replay reads updates recorded by Journal, and passes them to fn with their original timing scaled by speed
+//a speed of 2 replays twice as fast, a speed of 0 or less replays as fast as possible
+//this function blocks until all updates are replayed, ctx is cancelled, or fn returns an error
+func Replay(ctx context.Context, r io.Reader, speed float64, fn func(AppliedUpdate) error) error {
+	br := bufio.NewReader(r)
+	var prev time.Time
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		eof := errors.Is(err, io.EOF)
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var au AppliedUpdate
+			if err := json.Unmarshal(line, &au); err != nil {
+				return err
+			}
+			if speed > 0 && !prev.IsZero() && au.Time.After(prev) {
+				wait := time.Duration(float64(au.Time.Sub(prev)) / speed)
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+			prev = au.Time
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(au); err != nil {
+				return err
+			}
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+//replayBatch is the most updates applied at once when replaying as fast as possible
+const replayBatch = 1024
+
+//ReplayInto replays updates recorded by Journal into Client, as if they were received live from their original source
+//see Replay for the timing, when replaying as fast as possible consecutive updates of the same source are applied in batches
+func ReplayInto(ctx context.Context, c *Client, r io.Reader, speed float64) error {
+	if speed > 0 {
+		return Replay(ctx, r, speed, func(au AppliedUpdate) error {
+			c.applyLiveUpdates(au.Update, au.Source)
+			return nil
+		})
+	}
+
+	var batch []DomainUpdate
+	var source UpdateSource
+	flush := func() {
+		c.ApplyUpdates(batch, source)
+		batch = batch[:0]
+	}
+	err := Replay(ctx, r, speed, func(au AppliedUpdate) error {
+		if au.Source != source || len(batch) >= replayBatch {
+			flush()
+			source = au.Source
+		}
+		batch = append(batch, au.Update)
+		return nil
+	})
+	flush()
+	return err
+}
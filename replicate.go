@@ -0,0 +1,267 @@
+package sinkingyachts
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"nhooyr.io/websocket"
+	"sync"
+	"time"
+)
+
+//replicateBatch is the amount of domains sent per message of the initial snapshot
+//it keeps messages well below the feed's default read limit
+const replicateBatch = 100
+
+//replicateWriteTimeout bounds how long a single message may take to be written to a follower
+//followers that don't read within it are disconnected, rather than holding up their connection forever
+const replicateWriteTimeout = time.Second * 10
+
+//origins marking the frames of the snapshot sent to followers upon connecting
+//the snapshot begins with an empty removal, and ends with an empty addition, so it carries over every codec
+const (
+	replicateSnapshotBegin = "sinkingyachts.snapshot.begin"
+	replicateSnapshot      = "sinkingyachts.snapshot"
+	replicateSnapshotEnd   = "sinkingyachts.snapshot.end"
+)
+
+//Replicator pushes updates applied to a primary Client to follower instances over websocket
+//it speaks the same protocol as the api's feed, so followers are regular Client pointed at the primary
+//followers may negotiate another Codec, see SetCodecs
+//for example New("ws://primary:8080", identity, client, WithHeader("Authorization", "Bearer "+token))
+//upon connecting, followers receive a snapshot of all known domains in batches of 100, followed by live updates
+//once the snapshot is complete it replaces the follower's cache, so domains removed while it was disconnected are dropped
+//followers should only use ListenForUpdates against the primary
+type Replicator struct {
+	c         *Client
+	token     string
+	buffer    int
+	snapshot  bool
+	m         sync.Mutex
+	followers map[chan DomainUpdate]empty
+	closed    bool
+	remove    func()
+	codecs    []Codec
+}
+
+//NewReplicator creates a Replicator that replicates c to followers
+//token is required from followers as "Authorization: Bearer <token>", an empty token disables authentication
+func NewReplicator(c *Client, token string) *Replicator {
+	return newReplicator(c, token, 64, true)
+}
+
+//newReplicator creates a Replicator buffering up to buffer updates per follower
+//followers only receive live updates if snapshot is false
+func newReplicator(c *Client, token string, buffer int, snapshot bool) *Replicator {
+	r := &Replicator{
+		c:         c,
+		token:     token,
+		buffer:    buffer,
+		snapshot:  snapshot,
+		followers: map[chan DomainUpdate]empty{},
+		codecs:    []Codec{JSONCodec, EnvelopeCodec, MsgpackCodec},
+	}
+	r.remove = c.OnUpdate(r.broadcast)
+	return r
+}
+
+//ServeHTTP upgrades the request into a websocket and streams updates to the follower
+//it blocks until the follower disconnects, falls too far behind, or the Replicator is closed
+func (r *Replicator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(req) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	ch, ok := r.register()
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	defer r.unregister(ch)
+
+	r.m.Lock()
+	codecs := r.codecs
+	r.m.Unlock()
+	cn, err := websocket.Accept(w, req, &websocket.AcceptOptions{Subprotocols: subprotocols(codecs)})
+	if err != nil {
+		return
+	}
+	ctx := cn.CloseRead(req.Context())
+	codec := JSONCodec
+	for _, c := range codecs {
+		if c.Subprotocol() == cn.Subprotocol() {
+			codec = c
+			break
+		}
+	}
+
+	if r.snapshot {
+		err = writeSnapshot(ctx, cn, codec, r.c.Domains())
+		if err != nil {
+			_ = cn.Close(websocket.StatusInternalError, "internal error")
+			return
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			_ = cn.Close(websocket.StatusNormalClosure, "")
+			return
+		case mod, ok := <-ch:
+			if !ok && r.isClosed() {
+				_ = cn.Close(websocket.StatusGoingAway, "replication stopped")
+				return
+			}
+			if !ok {
+				_ = cn.Close(websocket.StatusTryAgainLater, "fell behind")
+				return
+			}
+			err = writeFrame(ctx, cn, codec, mod)
+			if err != nil {
+				_ = cn.Close(websocket.StatusInternalError, "internal error")
+				return
+			}
+		}
+	}
+}
+
+//Close stops replicating and disconnects all followers
+func (r *Replicator) Close() error {
+	r.remove()
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.closed = true
+	for ch := range r.followers {
+		close(ch)
+		delete(r.followers, ch)
+	}
+	return nil
+}
+
+//isClosed checks if Close has been called
+func (r *Replicator) isClosed() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.closed
+}
+
+//writeSnapshot writes domains as a snapshot in batches of replicateBatch, between the begin and end markers
+func writeSnapshot(ctx context.Context, cn *websocket.Conn, codec Codec, domains []string) error {
+	err := writeFrame(ctx, cn, codec, DomainUpdate{Domains: []string{}, Origin: replicateSnapshotBegin})
+	if err != nil {
+		return err
+	}
+	for len(domains) > 0 {
+		n := replicateBatch
+		if n > len(domains) {
+			n = len(domains)
+		}
+		err = writeFrame(ctx, cn, codec, DomainUpdate{Add: true, Domains: domains[:n], Origin: replicateSnapshot})
+		if err != nil {
+			return err
+		}
+		domains = domains[n:]
+	}
+	return writeFrame(ctx, cn, codec, DomainUpdate{Add: true, Domains: []string{}, Origin: replicateSnapshotEnd})
+}
+
+//applySnapshot collects the frames of a snapshot from the Replicator, and replaces the cache with it once complete
+//it returns false if mod isn't part of a snapshot, and should be applied as usual
+//should only be called when mutex is locked
+func (c *Client) applySnapshot(mod DomainUpdate) bool {
+	switch mod.Origin {
+	case replicateSnapshotBegin:
+		c.snapshot = []string{}
+	case replicateSnapshot:
+		if c.snapshot == nil {
+			//the beginning was missed, so the snapshot can't replace the cache, apply it as adds instead
+			return false
+		}
+		c.snapshot = append(c.snapshot, mod.Domains...)
+	case replicateSnapshotEnd:
+		if c.snapshot == nil {
+			return true
+		}
+		dMap, a := internDomains(c.snapshot)
+		c.snapshot = nil
+		c.replaceDomains(dMap, a, SourceFeed)
+	default:
+		return false
+	}
+	return true
+}
+
+//writeFrame encodes an update with the codec, and writes it to the connection within replicateWriteTimeout
+func writeFrame(ctx context.Context, cn *websocket.Conn, codec Codec, mod DomainUpdate) error {
+	frame, err := codec.Encode([]DomainUpdate{mod})
+	if err != nil {
+		return err
+	}
+	typ := websocket.MessageText
+	if codec.Binary() {
+		typ = websocket.MessageBinary
+	}
+	ctx, cancel := context.WithTimeout(ctx, replicateWriteTimeout)
+	defer cancel()
+	return cn.Write(ctx, typ, frame)
+}
+
+//SetCodecs sets the codecs followers can negotiate, in order of preference, followers that don't negotiate any get JSONCodec
+//by default JSONCodec, EnvelopeCodec and MsgpackCodec are supported, it only affects followers that connect afterwards
+func (r *Replicator) SetCodecs(codecs ...Codec) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.codecs = codecs
+}
+
+//authorized checks if the request carries the expected token
+func (r *Replicator) authorized(req *http.Request) bool {
+	if r.token == "" {
+		return true
+	}
+	return bearerAuthorized(req, r.token)
+}
+
+//bearerAuthorized checks if the request carries "Authorization: Bearer <token>"
+func bearerAuthorized(req *http.Request, token string) bool {
+	expected := "Bearer " + token
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(expected)) == 1
+}
+
+//register adds a new follower channel, returns false if Replicator is closed
+func (r *Replicator) register() (chan DomainUpdate, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.closed {
+		return nil, false
+	}
+	ch := make(chan DomainUpdate, r.buffer)
+	r.followers[ch] = empty{}
+	return ch, true
+}
+
+//unregister removes a follower channel if it is still registered
+func (r *Replicator) unregister(ch chan DomainUpdate) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if _, ok := r.followers[ch]; ok {
+		delete(r.followers, ch)
+		close(ch)
+	}
+}
+
+//broadcast sends an applied update to all followers
+//followers that can't keep up are disconnected, so they can reconnect and receive a fresh snapshot
+//a slow follower never delays the others or the Client, as sending to it never blocks
+func (r *Replicator) broadcast(au AppliedUpdate) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for ch := range r.followers {
+		select {
+		case ch <- au.Update:
+		default:
+			delete(r.followers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"nhooyr.io/websocket"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplicator(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+
+	r := NewReplicator(primary, "secret")
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+	endpoint := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	resp, err := http.Get(srv.URL + endpointFeed)
+	a.NoError(err)
+	a.Equal(http.StatusUnauthorized, resp.StatusCode)
+	closeBody(resp)
+
+	follower := New(endpoint, "test", http.Client{}, WithHeader("Authorization", "Bearer secret"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = follower.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return follower.Size() == 2 }, time.Second, time.Millisecond*10)
+
+	primary.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	a.Eventually(func() bool { return !follower.Check("a.com") && follower.Check("b.com") }, time.Second, time.Millisecond*10)
+
+	stats := follower.Stats()
+	a.True(stats.FeedConnected)
+	a.Equal(uint64(4), stats.FeedMessages, "the snapshot is framed by a begin and end marker")
+	a.Equal(uint64(2), stats.FeedAdded)
+	a.Equal(uint64(1), stats.FeedRemoved)
+	a.False(stats.FeedLastMessage.IsZero())
+	a.NoError(r.Close())
+}
+
+func TestReplicatorReconnect(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	r := NewReplicator(primary, "")
+	defer r.Close()
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+
+	follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{})
+	var removed []string
+	follower.OnUpdate(func(au AppliedUpdate) {
+		if !au.Update.Add {
+			removed = append(removed, au.Update.Domains...)
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- follower.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return follower.Size() == 2 }, time.Second, time.Millisecond*10)
+	cancel()
+	a.NoError(<-done)
+
+	//removed while the follower is disconnected, so it never receives the removal
+	primary.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}}, SourceFeed)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		done <- follower.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return follower.Check("c.com") }, time.Second, time.Millisecond*10)
+	a.False(follower.Check("a.com"), "the snapshot replaces the follower's cache")
+	a.True(follower.Check("b.com"))
+	a.Equal(2, follower.Size())
+	follower.m.Lock()
+	a.Equal([]string{"a.com"}, removed)
+	follower.m.Unlock()
+}
+
+func TestStartListening(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+	r := NewReplicator(primary, "")
+	defer r.Close()
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+
+	follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{})
+	a.NoError(follower.StartListening(context.Background()))
+	a.NoError(follower.StartListening(context.Background()))
+	a.True(follower.Listening())
+	a.Eventually(func() bool { return follower.Check("a.com") }, time.Second, time.Millisecond*10)
+
+	a.NoError(follower.StopListening())
+	a.False(follower.Listening())
+	a.NoError(follower.ListenErr())
+}
+
+func TestFeedMessageTimeout(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	r := NewReplicator(primary, "")
+	defer r.Close()
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+
+	follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{}, WithFeedMessageTimeout(time.Millisecond*50))
+	err := follower.ListenForUpdates(context.Background())
+	a.Error(err)
+	a.Contains(err.Error(), "no feed message received within 50ms")
+}
+
+func TestFeedPing(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	r := NewReplicator(primary, "")
+	defer r.Close()
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+
+	follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{}, WithFeedPing(time.Millisecond*20, time.Millisecond*50))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	a.NoError(follower.ListenForUpdates(ctx), "a quiet feed that answers pings should stay open")
+
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer cn.Close(websocket.StatusNormalClosure, "")
+		//never reading means pings are never answered, like a hung connection
+		<-r.Context().Done()
+	}))
+	defer hung.Close()
+
+	follower = New("ws"+strings.TrimPrefix(hung.URL, "http"), "test", http.Client{}, WithFeedPing(time.Millisecond*20, time.Millisecond*50))
+	err := follower.ListenForUpdates(context.Background())
+	a.Error(err)
+	a.Contains(err.Error(), "no feed pong received within 50ms")
+}
@@ -0,0 +1,261 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//Severity is how bad a SyncError is
+type Severity int
+
+const (
+	//SeverityTransient errors are retried, such as network failures, timeouts and server errors
+	SeverityTransient Severity = iota
+	//SeverityFatal errors can't be fixed by retrying, such as a rejected identity, they stop AutoSyncResilient
+	SeverityFatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityTransient:
+		return "transient"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+//operations reported by SyncError
+const (
+	//SyncOpInitial is the initial sync of a strategy set in ResilientSync.Initial
+	//the default initial sync is a full sync, which is reported as SyncOpFullSync
+	SyncOpInitial = "initial"
+	//SyncOpFullSync is a full sync, see Client.FullSync
+	SyncOpFullSync = "full_sync"
+	//SyncOpUpdate is a recent sync, see Client.Update
+	SyncOpUpdate = "update"
+	//SyncOpDeltaSync is a sync from a Mirror, see Client.DeltaSync
+	SyncOpDeltaSync = "delta_sync"
+	//SyncOpFeed is the realtime feed, see Client.ListenForUpdates
+	SyncOpFeed = "feed"
+)
+
+//SyncError is an error that occurred while syncing in AutoSyncResilient
+type SyncError struct {
+	//Op is the operation that failed, one of the SyncOp constants
+	Op string
+	//Err is the error the operation failed with
+	Err error
+	//Severity is whether the error gets retried or stops syncing
+	Severity Severity
+	//Failures is the amount of times Op failed in a row, including this one
+	Failures int
+	//Retry is how long until Op is retried, it is zero if it's retried at its next interval or not at all
+	Retry time.Duration
+	//Time is when the error occurred
+	Time time.Time
+}
+
+func (e SyncError) Error() string {
+	return fmt.Sprintf("%s %s failure: %v", e.Severity, e.Op, e.Err)
+}
+
+func (e SyncError) Unwrap() error {
+	return e.Err
+}
+
+//ResilientSync configures AutoSyncResilient
+type ResilientSync struct {
+	//Realtime listens for updates on the feed, reconnecting whenever it fails
+	Realtime bool
+	//RecentInterval is how often recent updates are synced, 0 disables it, see AutoSync
+	RecentInterval time.Duration
+	//FullSyncInterval is how often the cache is fully synced, 0 disables it, see AutoSync
+	FullSyncInterval time.Duration
+	//FullSyncSchedule schedules full syncs in addition to FullSyncInterval, such as Daily, nil disables it
+	FullSyncSchedule Schedule
+	//Initial populates the cache before syncing, nil uses InitialFullSync, see AutoSyncWith
+	//its errors are reported as SyncOpInitial, or as SyncOpFullSync when it's nil
+	Initial InitialSync
+	//RetryDelay is how long to wait before retrying the initial sync or reconnecting the feed, it defaults to a second
+	//the delay doubles on every failure in a row, up to MaxRetryDelay
+	RetryDelay time.Duration
+	//MaxRetryDelay is the longest delay between retries, it defaults to 5 minutes
+	MaxRetryDelay time.Duration
+	//OnError is called with every error, it may be nil to ignore them
+	//to receive errors on a channel, send them into one from OnError
+	OnError func(SyncError)
+}
+
+//retryDelay returns the delay before retrying after failures in a row
+func (rs ResilientSync) retryDelay(failures int) time.Duration {
+	delay := rs.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < failures && delay < rs.maxRetryDelay(); i++ {
+		delay *= 2
+	}
+	if delay > rs.maxRetryDelay() {
+		delay = rs.maxRetryDelay()
+	}
+	return delay
+}
+
+//maxRetryDelay returns MaxRetryDelay or its default
+func (rs ResilientSync) maxRetryDelay() time.Duration {
+	if rs.MaxRetryDelay <= 0 {
+		return time.Minute * 5
+	}
+	return rs.MaxRetryDelay
+}
+
+//AutoSyncResilient is AutoSync that keeps running through transient failures, such as api outages
+//failed syncs are reported to ResilientSync.OnError instead of being returned, and are retried
+//the initial sync and the feed are retried with a growing delay, later syncs are retried at their next interval
+//updates missed while the feed is disconnected are picked up by the next recent or full sync
+//this function blocks and returns only when cancelled by ctx, or on a fatal error, which is returned
+func AutoSyncResilient(ctx context.Context, c *Client, opts ResilientSync) error {
+	ctx, cancel := c.r.withBase(ctx)
+	defer cancel()
+	fatal := make(chan error, 1)
+	if opts.Realtime {
+		go func() {
+			if err := listenResilient(ctx, c, opts); err != nil {
+				fatal <- err
+			}
+		}()
+	}
+
+	initial, initialOp := opts.Initial, SyncOpInitial
+	if initial == nil {
+		initial, initialOp = InitialFullSync, SyncOpFullSync
+	}
+	for failures := 1; ; failures++ {
+		err := initial(ctx, c)
+		if err == nil || errors.Is(err, ErrPaused) {
+			break
+		}
+		delay := opts.retryDelay(failures)
+		if err = opts.report(initialOp, err, failures, delay); err != nil {
+			return err
+		}
+		if !waitContext(ctx, delay) {
+			return nil
+		}
+	}
+
+	recentTick, stopRecent := tick(opts.RecentInterval)
+	defer stopRecent()
+	fullSyncTick, stopFullSync := tick(opts.FullSyncInterval)
+	defer stopFullSync()
+	scheduledTick, stopScheduled := scheduleTick(opts.FullSyncSchedule)
+	defer stopScheduled()
+	var recentFailures, fullSyncFailures int
+	fullSync := func() error {
+		err := c.FullSync()
+		if err == nil || errors.Is(err, ErrPaused) {
+			fullSyncFailures = 0
+			return nil
+		}
+		fullSyncFailures++
+		return opts.report(SyncOpFullSync, err, fullSyncFailures, 0)
+	}
+	for {
+		select {
+		case <-recentTick:
+			err := c.Update()
+			if err == nil || errors.Is(err, ErrPaused) {
+				recentFailures = 0
+				continue
+			}
+			recentFailures++
+			if err = opts.report(SyncOpUpdate, err, recentFailures, 0); err != nil {
+				return err
+			}
+		case <-fullSyncTick:
+			if err := fullSync(); err != nil {
+				return err
+			}
+		case <-scheduledTick:
+			if err := fullSync(); err != nil {
+				return err
+			}
+		case err := <-fatal:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+//report passes err of op to OnError as a SyncError, it returns err if it is fatal
+func (rs ResilientSync) report(op string, err error, failures int, retry time.Duration) error {
+	syncErr := SyncError{Op: op, Err: err, Severity: severity(err), Failures: failures, Retry: retry, Time: time.Now()}
+	if syncErr.Severity == SeverityFatal {
+		syncErr.Retry = 0
+	}
+	if rs.OnError != nil {
+		rs.OnError(syncErr)
+	}
+	if syncErr.Severity == SeverityFatal {
+		return err
+	}
+	return nil
+}
+
+//listenResilient listens for updates on the feed, reconnecting with a growing delay whenever it fails
+//failures are reported as SyncOpFeed, it returns nil once ctx is done, or the first fatal error
+func listenResilient(ctx context.Context, c *Client, opts ResilientSync) error {
+	failures := 0
+	for {
+		connected := time.Now()
+		err := c.ListenForUpdates(ctx)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		//a connection that stayed up for longer than the longest delay starts the delay over
+		if time.Since(connected) > opts.maxRetryDelay() {
+			failures = 0
+		}
+		failures++
+		delay := opts.retryDelay(failures)
+		if err = opts.report(SyncOpFeed, err, failures, delay); err != nil {
+			return err
+		}
+		if !waitContext(ctx, delay) {
+			return nil
+		}
+	}
+}
+
+//waitContext waits for d, it returns false if ctx is done first
+func waitContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+//severity classifies an error, client errors from the api are fatal as retrying would fail the same way
+//except for timeouts and rate limits, every other error is transient
+func severity(err error) Severity {
+	var statusErr unexpectedStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.status == http.StatusRequestTimeout, statusErr.status == http.StatusTooManyRequests:
+			return SeverityTransient
+		case statusErr.status >= 400 && statusErr.status < 500:
+			return SeverityFatal
+		}
+	}
+	return SeverityTransient
+}
@@ -0,0 +1,104 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAutoSyncResilient(t *testing.T) {
+	a := assert.New(t)
+	var m sync.Mutex
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`["a.com"]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "test", http.Client{})
+	errs := make(chan SyncError, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- AutoSyncResilient(ctx, c, ResilientSync{
+			FullSyncInterval: time.Millisecond * 20,
+			RetryDelay:       time.Millisecond * 5,
+			OnError:          func(err SyncError) { errs <- err },
+		})
+	}()
+
+	for i := 1; i <= 2; i++ {
+		err := <-errs
+		a.Equal(SyncOpFullSync, err.Op, "the default initial sync is reported as a full sync")
+		a.Equal(SeverityTransient, err.Severity)
+		a.Equal(i, err.Failures)
+		a.Equal(time.Millisecond*5*time.Duration(i), err.Retry)
+	}
+	m.Lock()
+	status = http.StatusOK
+	m.Unlock()
+	a.Eventually(func() bool { return c.Check("a.com") }, time.Second, time.Millisecond*5)
+
+	m.Lock()
+	status = http.StatusForbidden
+	m.Unlock()
+	select {
+	case err := <-done:
+		a.Error(err)
+	case <-time.After(time.Second):
+		a.Fail("a fatal error should stop syncing")
+	}
+	var last SyncError
+	for len(errs) > 0 {
+		last = <-errs
+	}
+	a.Equal(SeverityFatal, last.Severity)
+	a.Equal(time.Duration(0), last.Retry)
+}
+
+func TestAutoSyncResilientInitial(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	errs := make(chan SyncError, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- AutoSyncResilient(ctx, c, ResilientSync{
+			Initial: func(ctx context.Context, c *Client) error {
+				return errors.New("no cache")
+			},
+			RetryDelay: time.Millisecond * 5,
+			OnError:    func(err SyncError) { errs <- err },
+		})
+	}()
+
+	err := <-errs
+	a.Equal(SyncOpInitial, err.Op)
+	a.Equal(1, err.Failures)
+	cancel()
+	a.NoError(<-done)
+}
+
+func TestRetryDelay(t *testing.T) {
+	a := assert.New(t)
+	rs := ResilientSync{}
+	a.Equal(time.Second, rs.retryDelay(1))
+	a.Equal(time.Second*4, rs.retryDelay(3))
+	a.Equal(time.Minute*5, rs.retryDelay(100))
+
+	rs = ResilientSync{RetryDelay: time.Millisecond * 10, MaxRetryDelay: time.Millisecond * 25}
+	a.Equal(time.Millisecond*20, rs.retryDelay(2))
+	a.Equal(time.Millisecond*25, rs.retryDelay(3))
+}
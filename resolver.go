@@ -0,0 +1,40 @@
+package sinkingyachts
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+//ExportUnbound writes an unbound config snippet answering NXDOMAIN for the domains and their subdomains
+//include it from unbound.conf with include: "/etc/unbound/phishing.conf"
+var ExportUnbound Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("# sinking yachts phishing domains\nserver:\n")
+	for _, domain := range topDomains(set) {
+		_, _ = bw.WriteString("\tlocal-zone: \"")
+		_, _ = bw.WriteString(zoneEscaper.Replace(domain))
+		_, _ = bw.WriteString(".\" always_nxdomain\n")
+	}
+	return bw.Flush()
+})
+
+//ExportKnot writes a knot resolver lua policy denying the domains and their subdomains
+//load it from kresd.conf with dofile('/etc/knot-resolver/phishing.lua')
+var ExportKnot Exporter = ExporterFunc(func(w io.Writer, set ExportSet) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("-- sinking yachts phishing domains\npolicy.add(policy.suffix(policy.DENY, policy.todnames({\n")
+	for _, domain := range topDomains(set) {
+		_, _ = bw.WriteString("\t'")
+		_, _ = bw.WriteString(luaEscaper.Replace(domain))
+		_, _ = bw.WriteString("',\n")
+	}
+	_, _ = bw.WriteString("})))\n")
+	return bw.Flush()
+})
+
+//zoneEscaper escapes domains inside quoted zone names
+var zoneEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+//luaEscaper escapes domains inside single quoted lua strings
+var luaEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
@@ -0,0 +1,32 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestResolverExporters(t *testing.T) {
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "login.bad.com", "evil.org"}}, SourceFeed)
+
+	tests := []struct {
+		name     string
+		exporter Exporter
+		want     string
+	}{
+		{name: "unbound", exporter: ExportUnbound, want: "# sinking yachts phishing domains\nserver:\n" +
+			"\tlocal-zone: \"bad.com.\" always_nxdomain\n\tlocal-zone: \"evil.org.\" always_nxdomain\n"},
+		{name: "knot", exporter: ExportKnot, want: "-- sinking yachts phishing domains\npolicy.add(policy.suffix(policy.DENY, policy.todnames({\n" +
+			"\t'bad.com',\n\t'evil.org',\n})))\n"},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			var buf bytes.Buffer
+			a.NoError(WriteExport(c, &buf, data.exporter))
+			a.Equal(data.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	//errRollbackStore is returned by rollbacks when the Manager doesn't persist into a FileStore
+	errRollbackStore = errors.New("rollback requires a file store with backups")
+	//errUnknownBackup is returned by Manager.Rollback for paths that aren't backups of the store
+	errUnknownBackup = errors.New("unknown backup")
+)
+
+//RollbackTo restores the newest backup made at or before t, and returns its path, see Manager.Rollback
+func (m *Manager) RollbackTo(t time.Time) (string, error) {
+	fs, ok := m.store.(*FileStore)
+	if !ok {
+		return "", errRollbackStore
+	}
+	backup, err := fs.BackupAt(t)
+	if err != nil {
+		return "", err
+	}
+	return backup, m.Rollback(backup)
+}
+
+//Rollback restores the cache from a backup listed by FileStore.Backups, as a defense against a bad upstream push such as a bulk-add
+//the Manager is paused before restoring, so neither syncs nor the feed re-apply the rolled back updates until Resume is called
+//Resume catches up with upstream, so it should only be called once upstream has been fixed
+//if restoring fails, the Manager is only left paused if it was paused before
+func (m *Manager) Rollback(backup string) error {
+	fs, ok := m.store.(*FileStore)
+	if !ok {
+		return errRollbackStore
+	}
+	backups, err := fs.Backups()
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, b := range backups {
+		known = known || b == backup
+	}
+	if !known {
+		return errUnknownBackup
+	}
+
+	paused := m.client.Paused()
+	m.client.Pause()
+	if err = fs.Restore(m.client, backup); err != nil {
+		if !paused {
+			m.client.Resume()
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManagerRollback(t *testing.T) {
+	a := assert.New(t)
+	m, err := NewManager(Config{
+		Endpoint: "https://example.com",
+		Identity: "test",
+		Store:    StoreConfig{Path: filepath.Join(t.TempDir(), "cache.json"), Backups: 5},
+	})
+	a.NoError(err)
+	c := m.Client()
+
+	c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: []string{"a.com"}}}, SourceFeed)
+	a.NoError(m.Store().Save(c))
+	time.Sleep(time.Millisecond * 2)
+	good := time.Now()
+	time.Sleep(time.Millisecond * 2)
+	c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: []string{"bad1.com", "bad2.com"}}}, SourceFeed)
+	a.NoError(m.Store().Save(c))
+
+	_, err = m.RollbackTo(good.Add(-time.Hour))
+	a.ErrorIs(err, ErrNoBackup)
+	a.False(m.Paused())
+	a.ErrorIs(m.Rollback("cache.json.bak"), errUnknownBackup)
+
+	backup, err := m.RollbackTo(good)
+	a.NoError(err)
+	a.Contains(backup, ".bak")
+	a.True(m.Paused())
+	a.Equal([]string{"a.com"}, c.Domains())
+	c.ApplyUpdates([]DomainUpdate{{Add: true, Domains: []string{"bad1.com"}}}, SourceFeed)
+	a.False(c.Check("bad1.com"))
+
+	_, err = (&Manager{client: c}).RollbackTo(good)
+	a.ErrorIs(err, errRollbackStore)
+}
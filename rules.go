@@ -0,0 +1,96 @@
+package sinkingyachts
+
+import (
+	"sort"
+	"time"
+)
+
+//kinds of rules reported by RuleHits
+//known domains synced from the api are not rules, they are managed upstream
+const (
+	//RuleLocal is a local domain, see Client.AddLocal
+	RuleLocal = "local"
+	//RuleRegex is a regex rule, see Client.SetRegexRules
+	RuleRegex = "regex"
+)
+
+//RuleHits are the hit counters of a single rule
+type RuleHits struct {
+	//Kind is the kind of the rule, one of the Rule constants
+	Kind string
+	//Rule is the local domain or the regex pattern
+	Rule string
+	//Hits is the amount of checks the rule matched, including matches in dry run mode
+	Hits uint64
+	//LastHit is when the rule last matched, it is zero if it never matched
+	LastHit time.Time
+}
+
+//ruleKey identifies a rule in the hit counters
+type ruleKey struct {
+	kind string
+	rule string
+}
+
+//ruleHit are the counters of a rule
+type ruleHit struct {
+	hits    uint64
+	lastHit time.Time
+}
+
+//hitRule counts a hit of the rule
+//should only be called when mutex is locked
+func (c *Client) hitRule(kind, rule string) {
+	if c.ruleHits == nil {
+		c.ruleHits = map[ruleKey]*ruleHit{}
+	}
+	key := ruleKey{kind: kind, rule: rule}
+	hit, ok := c.ruleHits[key]
+	if !ok {
+		hit = &ruleHit{}
+		c.ruleHits[key] = hit
+	}
+	hit.hits++
+	hit.lastHit = time.Now()
+}
+
+//countRuleHit counts a hit of the rule, for checks made outside the mutex
+func (c *Client) countRuleHit(kind, rule string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.hitRule(kind, rule)
+}
+
+//forgetRule drops the counters of a rule that got removed
+//should only be called when mutex is locked
+func (c *Client) forgetRule(kind, rule string) {
+	delete(c.ruleHits, ruleKey{kind: kind, rule: rule})
+}
+
+//ruleHitsLocked returns the counters of every current rule, rules that never matched are included with no hits
+//they are sorted by kind, then by rule
+//should only be called when mutex is locked
+func (c *Client) ruleHitsLocked() []RuleHits {
+	var rules []RuleHits
+	add := func(kind, rule string) {
+		rh := RuleHits{Kind: kind, Rule: rule}
+		if hit, ok := c.ruleHits[ruleKey{kind: kind, rule: rule}]; ok {
+			rh.Hits = hit.hits
+			rh.LastHit = hit.lastHit
+		}
+		rules = append(rules, rh)
+	}
+	for domain := range c.local {
+		add(RuleLocal, domain)
+	}
+	for _, rule := range c.regexRules {
+		add(RuleRegex, rule.pattern)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Kind != rules[j].Kind {
+			return rules[i].Kind < rules[j].Kind
+		}
+		return rules[i].Rule < rules[j].Rule
+	})
+	return rules
+}
@@ -0,0 +1,57 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestRuleHits(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"known.com"}}, SourceFeed)
+	c.AddLocal(0, "local.com", "unused.com")
+	a.NoError(c.SetRegexRules(`nitro-[0-9]+\.com`, `unused-\d+\.com`))
+
+	a.True(c.Check("known.com"))
+	a.True(c.Check("local.com"))
+	a.True(c.FuzzyCheck("foo.local.com"))
+	a.True(c.FuzzyCheck("nitro-123.com"))
+
+	rules := c.Stats().Rules
+	a.Len(rules, 4)
+	hits := map[string]uint64{}
+	for _, rule := range rules {
+		hits[rule.Kind+" "+rule.Rule] = rule.Hits
+		a.Equal(rule.Hits == 0, rule.LastHit.IsZero())
+	}
+	a.Equal(map[string]uint64{
+		"local local.com":         2,
+		"local unused.com":        0,
+		`regex nitro-[0-9]+\.com`: 1,
+		`regex unused-\d+\.com`:   0,
+	}, hits)
+
+	var buf bytes.Buffer
+	a.NoError(c.Stats().WritePrometheus(&buf))
+	a.Contains(buf.String(), `sinkingyachts_rule_hits_total{kind="local"} 2`)
+	a.NotContains(buf.String(), `rule="local.com"`)
+	a.Contains(buf.String(), `sinkingyachts_rule_hits_total{kind="regex",rule="unused-\\d+\\.com"} 0`)
+
+	//planning an import doesn't count as checking
+	c.PlanImport([]string{"local.com"})
+	a.Equal(uint64(2), c.Stats().Rules[0].Hits)
+
+	c.RemoveLocal("local.com")
+	c.AddLocal(0, "local.com")
+	a.NoError(c.SetRegexRules(`nitro-[0-9]+\.com`))
+	for _, rule := range c.Stats().Rules {
+		if rule.Rule == "local.com" {
+			a.Zero(rule.Hits)
+		}
+		if rule.Kind == RuleRegex {
+			a.Equal(uint64(1), rule.Hits)
+		}
+	}
+}
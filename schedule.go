@@ -0,0 +1,118 @@
+package sinkingyachts
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Schedule decides when a scheduled sync runs, as an alternative to a fixed interval
+//such as running heavy full syncs of a fleet in an off peak window
+type Schedule interface {
+	//Next returns the next time after t the sync runs, or the zero time to never run again
+	Next(t time.Time) time.Time
+}
+
+//ScheduleFunc is a function that implements Schedule
+type ScheduleFunc func(t time.Time) time.Time
+
+//Next calls the function
+func (f ScheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+//Daily returns a Schedule that runs every day at hour:minute in loc, nil loc uses the local time zone
+//days where the time is skipped or repeated by daylight saving run at the time as normalized by time.Date
+func Daily(hour, minute int, loc *time.Location) Schedule {
+	if loc == nil {
+		loc = time.Local
+	}
+	return ScheduleFunc(func(t time.Time) time.Time {
+		t = t.In(loc)
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, loc)
+		for !next.After(t) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, hour, minute, 0, 0, loc)
+		}
+		return next
+	})
+}
+
+//WithJitter delays every run of the Schedule by a random duration up to jitter
+//so a fleet sharing a Schedule spreads its syncs out over the window instead of all syncing at once
+//the returned Schedule is safe for concurrent use
+func WithJitter(s Schedule, jitter time.Duration) Schedule {
+	if jitter <= 0 {
+		return s
+	}
+	//rand.Rand isn't safe for concurrent use, and the global source isn't seeded so every instance would jitter the same
+	var m sync.Mutex
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return ScheduleFunc(func(t time.Time) time.Time {
+		next := s.Next(t)
+		if next.IsZero() {
+			return next
+		}
+		m.Lock()
+		defer m.Unlock()
+		return next.Add(time.Duration(rng.Int63n(int64(jitter))))
+	})
+}
+
+//ParseDaily parses a daily time as "HH:MM" into a Daily Schedule in loc
+func ParseDaily(at string, loc *time.Location) (Schedule, error) {
+	hour, minute, err := parseClock(at)
+	if err != nil {
+		return nil, err
+	}
+	return Daily(hour, minute, loc), nil
+}
+
+//parseClock parses "HH:MM" into its hour and minute
+func parseClock(at string) (int, int, error) {
+	parts := strings.Split(at, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time of day %q, expected HH:MM", at)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour in time of day %q", at)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute in time of day %q", at)
+	}
+	return hour, minute, nil
+}
+
+//scheduleTick returns a channel ticking whenever the Schedule runs and a function to stop it
+//the channel never ticks if s is nil, ticks that aren't received in time are dropped like a time.Ticker
+func scheduleTick(s Schedule) (<-chan time.Time, func()) {
+	if s == nil {
+		return nil, func() {}
+	}
+	ch := make(chan time.Time, 1)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			next := s.Next(time.Now())
+			if next.IsZero() {
+				return
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case t := <-timer.C:
+				select {
+				case ch <- t:
+				default:
+				}
+			}
+		}
+	}()
+	return ch, func() { close(stop) }
+}
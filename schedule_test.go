@@ -0,0 +1,82 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDaily(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	tests := []struct {
+		name string
+		now  time.Time
+		next time.Time
+	}{
+		{name: "later today", now: time.Date(2022, 3, 1, 1, 30, 0, 0, loc), next: time.Date(2022, 3, 1, 4, 0, 0, 0, loc)},
+		{name: "passed today", now: time.Date(2022, 3, 1, 5, 0, 0, 0, loc), next: time.Date(2022, 3, 2, 4, 0, 0, 0, loc)},
+		{name: "exactly now", now: time.Date(2022, 3, 1, 4, 0, 0, 0, loc), next: time.Date(2022, 3, 2, 4, 0, 0, 0, loc)},
+		{name: "month end", now: time.Date(2022, 2, 28, 12, 0, 0, 0, loc), next: time.Date(2022, 3, 1, 4, 0, 0, 0, loc)},
+		{name: "other zone", now: time.Date(2022, 3, 1, 2, 30, 0, 0, time.UTC), next: time.Date(2022, 3, 2, 4, 0, 0, 0, loc)},
+	}
+	s := Daily(4, 0, loc)
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			assert.True(t, data.next.Equal(s.Next(data.now)), "expected %s, got %s", data.next, s.Next(data.now))
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	a := assert.New(t)
+	base := Daily(4, 0, time.UTC)
+	s := WithJitter(base, time.Minute*30)
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		next := s.Next(now)
+		a.False(next.Before(base.Next(now)))
+		a.True(next.Before(base.Next(now).Add(time.Minute * 30)))
+	}
+	a.Equal(base.Next(now), WithJitter(base, 0).Next(now))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Next(now)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseDaily(t *testing.T) {
+	a := assert.New(t)
+	s, err := ParseDaily("04:30", time.UTC)
+	a.NoError(err)
+	a.Equal(time.Date(2022, 3, 1, 4, 30, 0, 0, time.UTC), s.Next(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)))
+	for _, at := range []string{"", "4", "24:00", "04:60", "aa:00", "04:00:00"} {
+		_, err = ParseDaily(at, time.UTC)
+		a.Error(err, at)
+	}
+}
+
+func TestScheduleTick(t *testing.T) {
+	a := assert.New(t)
+	ticks, stop := scheduleTick(ScheduleFunc(func(t time.Time) time.Time {
+		return t.Add(time.Millisecond * 10)
+	}))
+	defer stop()
+	select {
+	case <-ticks:
+	case <-time.After(time.Second):
+		a.Fail("schedule should tick")
+	}
+
+	ticks, stop = scheduleTick(nil)
+	stop()
+	a.Nil(ticks)
+}
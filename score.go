@@ -0,0 +1,243 @@
+package sinkingyachts
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+//names of the signals used by Scorer
+const (
+	SignalListed         = "listed"
+	SignalLookalike      = "lookalike"
+	SignalSuspiciousTLD  = "suspicious_tld"
+	SignalShortener      = "shortener"
+	SignalRawIP          = "raw_ip"
+	SignalSubdomainDepth = "subdomain_depth"
+	SignalKeyword        = "keyword"
+)
+
+//Signal is a single heuristic that contributed to a Score
+type Signal struct {
+	//Name is the name of the signal, one of the Signal constants
+	Name string
+	//Weight is how much the signal added to the score
+	Weight int
+	//Detail describes what triggered the signal
+	Detail string
+}
+
+//Score is the risk score of a link
+type Score struct {
+	//Host is the host that got scored
+	Host string
+	//Total is the risk score from 0 to 100
+	Total int
+	//Signals are the signals that contributed to Total
+	Signals []Signal
+}
+
+//Scorer combines heuristics into a risk score, to warn about suspicious links that are not yet listed
+//fields can be changed to tune it, but Scorer should not be modified while scoring
+type Scorer struct {
+	//Client is used to check if the host is listed, it may be nil to only use heuristics
+	Client *Client
+	//Targets are commonly impersonated domains, hosts within a small edit distance of them are lookalikes
+	Targets []string
+	//SuspiciousTLDs are top level domains commonly used for phishing, without the leading dot
+	SuspiciousTLDs []string
+	//Shorteners are url shortener domains that hide the real destination
+	Shorteners []string
+	//Keywords are suspicious phrases, such as "free-nitro", matched against the lower cased host and path
+	Keywords []string
+	//MaxDepth is the amount of labels a host may have before it's considered excessively deep
+	MaxDepth int
+	//Weights is the weight of each signal by name, signals with no weight are not used
+	Weights map[string]int
+}
+
+//NewScorer creates a Scorer with default lists and weights
+//c may be nil to only use heuristics
+func NewScorer(c *Client) *Scorer {
+	return &Scorer{
+		Client:         c,
+		Targets:        []string{"discord.com", "discord.gg", "discordapp.com", "steamcommunity.com", "steampowered.com"},
+		SuspiciousTLDs: []string{"ru", "tk", "ml", "ga", "cf", "gq", "xyz", "top", "icu", "click", "link", "gift"},
+		Shorteners:     []string{"bit.ly", "tinyurl.com", "t.co", "goo.gl", "is.gd", "cutt.ly", "shorturl.at", "rb.gy"},
+		MaxDepth:       4,
+		Weights: map[string]int{
+			SignalListed:         100,
+			SignalLookalike:      45,
+			SignalSuspiciousTLD:  15,
+			SignalShortener:      20,
+			SignalRawIP:          25,
+			SignalSubdomainDepth: 10,
+			SignalKeyword:        25,
+		},
+	}
+}
+
+//Watch adds the tlds and keywords of a Watchlist to Scorer
+func (s *Scorer) Watch(wl Watchlist) {
+	s.SuspiciousTLDs = append(s.SuspiciousTLDs, wl.TLDs...)
+	s.Keywords = append(s.Keywords, wl.Keywords...)
+}
+
+//Score scores a link or bare domain, such as "https://dlscord.gift/nitro" or "dlscord.gift"
+//links that can't be parsed score 0
+func (s *Scorer) Score(link string) Score {
+	host, path := parseLink(link)
+	sc := Score{Host: host}
+	if host == "" {
+		return sc
+	}
+	add := func(name, detail string) {
+		weight, ok := s.Weights[name]
+		if !ok || weight == 0 {
+			return
+		}
+		sc.Signals = append(sc.Signals, Signal{Name: name, Weight: weight, Detail: detail})
+		sc.Total += weight
+	}
+
+	if net.ParseIP(host) != nil {
+		add(SignalRawIP, host)
+	} else {
+		if s.Client != nil {
+			if m := s.Client.CheckDetailed(host); m.Phishing() {
+				add(SignalListed, m.Matched)
+			}
+		}
+		if target := s.lookalike(host); target != "" {
+			add(SignalLookalike, target)
+		}
+		if tld := host[strings.LastIndex(host, ".")+1:]; containsString(s.SuspiciousTLDs, tld) {
+			add(SignalSuspiciousTLD, tld)
+		}
+		if shortener := matchesSuffix(s.Shorteners, host); shortener != "" {
+			add(SignalShortener, shortener)
+		}
+		if depth := strings.Count(host, ".") + 1; s.MaxDepth > 0 && depth > s.MaxDepth {
+			add(SignalSubdomainDepth, host)
+		}
+	}
+	for _, keyword := range s.Keywords {
+		keyword = strings.ToLower(keyword)
+		if keyword != "" && (strings.Contains(host, keyword) || strings.Contains(path, keyword)) {
+			add(SignalKeyword, keyword)
+			break
+		}
+	}
+	if sc.Total > 100 {
+		sc.Total = 100
+	}
+	return sc
+}
+
+//Has returns true if the named signal contributed to the score
+//callers can use it to take softer actions than blocking, such as warning on watchlist hits
+func (sc Score) Has(name string) bool {
+	for _, signal := range sc.Signals {
+		if signal.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+//lookalike returns the target that host is impersonating, or empty if none
+//a host impersonates a target if its registrable part is within an edit distance of 2, without being the target itself
+func (s *Scorer) lookalike(host string) string {
+	if matchesSuffix(s.Targets, host) != "" {
+		return ""
+	}
+	name := secondLevel(host)
+	for _, target := range s.Targets {
+		targetName := secondLevel(target)
+		if name == targetName {
+			//same name on another tld, such as discord.gift
+			return target
+		}
+		maxDistance := 2
+		if len(targetName) <= 5 {
+			maxDistance = 1
+		}
+		if levenshtein(name, targetName) <= maxDistance {
+			return target
+		}
+	}
+	return ""
+}
+
+//parseLink extracts the lower cased host and path out of a link or bare domain
+func parseLink(link string) (string, string) {
+	link = strings.TrimSpace(link)
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), strings.ToLower(u.Path)
+}
+
+//secondLevel returns the label before the top level domain, "foo.discord.com" returns "discord"
+func secondLevel(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return host
+	}
+	return labels[len(labels)-2]
+}
+
+//matchesSuffix returns the domain in domains that host is or is a subdomain of, or empty if none
+func matchesSuffix(domains []string, host string) string {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return d
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+//levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, v := range rest {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
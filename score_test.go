@@ -0,0 +1,46 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestScorer(t *testing.T) {
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com"}}, SourceFeed)
+	s := NewScorer(c)
+	s.Watch(Watchlist{TLDs: []string{"lol"}, Keywords: []string{"Free-Nitro"}})
+	tests := []struct {
+		name    string
+		input   string
+		total   int
+		signals []string
+	}{
+		{name: "Clean", input: "https://example.com/foo", total: 0},
+		{name: "Target", input: "https://discord.com/channels", total: 0},
+		{name: "Target Subdomain", input: "cdn.discordapp.com", total: 0},
+		{name: "Listed", input: "https://foo.bad.com/", total: 100, signals: []string{SignalListed}},
+		{name: "Lookalike", input: "https://dlscord.com/nitro", total: 45, signals: []string{SignalLookalike}},
+		{name: "Lookalike Suspicious TLD", input: "discord.gift", total: 60, signals: []string{SignalLookalike, SignalSuspiciousTLD}},
+		{name: "Shortener", input: "https://bit.ly/abc", total: 20, signals: []string{SignalShortener}},
+		{name: "Raw IP", input: "http://127.0.0.1:8080/", total: 25, signals: []string{SignalRawIP}},
+		{name: "Deep", input: "a.b.c.d.example.com", total: 10, signals: []string{SignalSubdomainDepth}},
+		{name: "Invalid", input: "http://[::1", total: 0},
+		{name: "Watched TLD", input: "example.lol", total: 15, signals: []string{SignalSuspiciousTLD}},
+		{name: "Keyword Host", input: "free-nitro.example.com", total: 25, signals: []string{SignalKeyword}},
+		{name: "Keyword Path", input: "https://example.com/FREE-NITRO", total: 25, signals: []string{SignalKeyword}},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			score := s.Score(data.input)
+			a.Equal(data.total, score.Total)
+			var names []string
+			for _, signal := range score.Signals {
+				names = append(names, signal.Name)
+			}
+			a.Equal(data.signals, names)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package sinkingyachts
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//readyPoll is how often readiness is checked before systemd is notified of it
+const readyPoll = time.Second
+
+//NotifySystemd reports the Manager's health to systemd with the sd_notify protocol, for services with Type=notify
+//READY=1 is sent once Manager.Ready with threshold succeeds, and STOPPING=1 when cancelled by ctx
+//if WatchdogSec is set on the service, WATCHDOG=1 is sent at half the interval only while Manager is ready,
+//so systemd restarts the service if the sync loop wedges
+//nothing is done when not running under systemd
+//this function blocks and returns only when cancelled by ctx, or when notifying fails
+func NotifySystemd(ctx context.Context, m *Manager, threshold time.Duration) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	cn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+	notify := func(state string) error {
+		_, err := cn.Write([]byte(state))
+		return err
+	}
+
+	ready := time.NewTicker(readyPoll)
+	for err := m.Ready(threshold); err != nil; err = m.Ready(threshold) {
+		select {
+		case <-ctx.Done():
+			ready.Stop()
+			return notify("STOPPING=1")
+		case <-ready.C:
+		}
+	}
+	ready.Stop()
+	err = notify("READY=1\nSTATUS=synced")
+	if err != nil {
+		return err
+	}
+
+	watchdog, stop := tick(systemdWatchdog())
+	defer stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return notify("STOPPING=1")
+		case <-watchdog:
+			if err := m.Ready(threshold); err != nil {
+				err = notify("STATUS=" + err.Error())
+			} else {
+				err = notify("WATCHDOG=1\nSTATUS=synced")
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//systemdWatchdog returns half of the watchdog interval set by systemd for this process, or 0 if there's none
+func systemdWatchdog() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
@@ -0,0 +1,62 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifySystemd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram is not supported on windows")
+	}
+	a := assert.New(t)
+	socket := filepath.Join(t.TempDir(), "notify")
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	a.NoError(err)
+	defer l.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	t.Setenv("WATCHDOG_USEC", "20000")
+
+	m, err := NewManager(Config{Endpoint: "https://example.com", Identity: "test"})
+	a.NoError(err)
+	m.synced()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NotifySystemd(ctx, m, time.Minute)
+	}()
+	read := func() string {
+		buf := make([]byte, 256)
+		_ = l.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := l.Read(buf)
+		a.NoError(err)
+		return string(buf[:n])
+	}
+	a.Equal("READY=1\nSTATUS=synced", read())
+	a.Equal("WATCHDOG=1\nSTATUS=synced", read())
+
+	m.m.Lock()
+	m.lastSync = time.Now().Add(-time.Hour)
+	m.m.Unlock()
+	//a watchdog ping may have been sent before lastSync changed
+	msg := read()
+	for i := 0; i < 10 && msg == "WATCHDOG=1\nSTATUS=synced"; i++ {
+		msg = read()
+	}
+	a.Contains(msg, "STATUS=feed is not connected")
+
+	cancel()
+	a.NoError(<-done)
+	msg = read()
+	for strings.HasPrefix(msg, "WATCHDOG=1") || strings.HasPrefix(msg, "STATUS=") {
+		msg = read()
+	}
+	a.Equal("STOPPING=1", msg)
+}
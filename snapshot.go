@@ -0,0 +1,164 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+//the snapshot format is designed to be memory mapped and searched in place
+//it starts with a header of the magic, last updated time in unix nanoseconds and the amount of domains
+//followed by an offset table of uint32s into the entries, then the entries sorted by domain, each prefixed with its uint16 length
+//all integers are big endian
+const (
+	snapshotMagic  = "SYS1"
+	snapshotHeader = len(snapshotMagic) + 8 + 4
+)
+
+//ErrInvalidSnapshot is returned when opening a snapshot that is truncated or not in the snapshot format
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+//WriteSnapshot writes the Client's domains in the snapshot format into the writer
+//snapshots are read only, and are meant to be opened by many processes on the same host with OpenSnapshot
+//it returns an error without writing anything if a domain is longer than 65535 bytes, or the domains don't fit in 4GiB
+func WriteSnapshot(c *Client, w io.Writer) error {
+	c.m.Lock()
+	lastUpdated := c.lastUpdated
+	domains := make([]string, 0, len(c.domains))
+	var size uint64
+	for domain := range c.domains {
+		domains = append(domains, domain)
+		size += 2 + uint64(len(domain))
+		if len(domain) > math.MaxUint16 {
+			c.m.Unlock()
+			return fmt.Errorf("domain of %d bytes is too long for a snapshot", len(domain))
+		}
+	}
+	c.m.Unlock()
+	if size > math.MaxUint32 {
+		return fmt.Errorf("domains of %d bytes are too large for a snapshot", size)
+	}
+	sort.Strings(domains)
+
+	buf := make([]byte, 0, snapshotHeader+len(domains)*4)
+	buf = append(buf, snapshotMagic...)
+	var nanos int64
+	if !lastUpdated.IsZero() {
+		nanos = lastUpdated.UnixNano()
+	}
+	buf = appendUint(buf, 64, uint64(nanos))
+	buf = appendUint(buf, 32, uint64(len(domains)))
+	offset := 0
+	for _, domain := range domains {
+		buf = appendUint(buf, 32, uint64(offset))
+		offset += 2 + len(domain)
+	}
+	for _, domain := range domains {
+		buf = appendUint(buf, 16, uint64(len(domain)))
+		buf = append(buf, domain...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+//Snapshot is a read only list of domains searched in place, usually backed by a memory mapped file
+//it is safe for concurrent use, but must not be used after Close
+type Snapshot struct {
+	data        []byte
+	offsets     []byte
+	entries     []byte
+	count       int
+	lastUpdated time.Time
+	close       func() error
+}
+
+//newSnapshot validates data in the snapshot format
+func newSnapshot(data []byte) (*Snapshot, error) {
+	if len(data) < snapshotHeader || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrInvalidSnapshot
+	}
+	header := data[len(snapshotMagic):]
+	s := &Snapshot{
+		data:  data,
+		count: int(binary.BigEndian.Uint32(header[8:])),
+	}
+	if nanos := int64(binary.BigEndian.Uint64(header)); nanos != 0 {
+		s.lastUpdated = time.Unix(0, nanos)
+	}
+	rest := data[snapshotHeader:]
+	if s.count > len(rest)/4 {
+		return nil, ErrInvalidSnapshot
+	}
+	s.offsets, s.entries = rest[:s.count*4], rest[s.count*4:]
+
+	var prev []byte
+	for i := 0; i < s.count; i++ {
+		entry, ok := s.entry(i)
+		if !ok || i > 0 && bytes.Compare(prev, entry) >= 0 {
+			return nil, ErrInvalidSnapshot
+		}
+		prev = entry
+	}
+	return s, nil
+}
+
+//entry returns the domain at index i, and false if it's out of bounds
+func (s *Snapshot) entry(i int) ([]byte, bool) {
+	offset := int(binary.BigEndian.Uint32(s.offsets[i*4:]))
+	if offset+2 > len(s.entries) {
+		return nil, false
+	}
+	end := offset + 2 + int(binary.BigEndian.Uint16(s.entries[offset:]))
+	if end > len(s.entries) {
+		return nil, false
+	}
+	return s.entries[offset+2 : end], true
+}
+
+//Check if a domain is phishing, see Client.Check
+func (s *Snapshot) Check(domain string) bool {
+	i := sort.Search(s.count, func(i int) bool {
+		entry, _ := s.entry(i)
+		return string(entry) >= domain
+	})
+	if i >= s.count {
+		return false
+	}
+	entry, _ := s.entry(i)
+	return string(entry) == domain
+}
+
+//FuzzyCheck if a domain or its parent domains are phishing, see Client.FuzzyCheck
+func (s *Snapshot) FuzzyCheck(domain string) bool {
+	for _, part := range GenerateVariants(domain) {
+		if s.Check(part) {
+			return true
+		}
+	}
+	return false
+}
+
+//Size return the amount of domains in the snapshot
+func (s *Snapshot) Size() int {
+	return s.count
+}
+
+//LastUpdated returns when the snapshotted Client was last updated
+func (s *Snapshot) LastUpdated() time.Time {
+	return s.lastUpdated
+}
+
+//Close releases the snapshot
+func (s *Snapshot) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	err := s.close()
+	s.close = nil
+	return err
+}
@@ -0,0 +1,41 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package sinkingyachts
+
+import (
+	"os"
+	"syscall"
+)
+
+//OpenSnapshot memory maps a snapshot written by WriteSnapshot
+//the snapshot is searched in place without loading it into the heap,
+//so every process on the host opening the same file shares a single physical copy
+//the file must not be modified while it's open, replace it with a rename instead
+func OpenSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < int64(snapshotHeader) || int64(int(fi.Size())) != fi.Size() {
+		return nil, ErrInvalidSnapshot
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSnapshot(data)
+	if err != nil {
+		_ = syscall.Munmap(data)
+		return nil, err
+	}
+	s.close = func() error {
+		return syscall.Munmap(data)
+	}
+	return s, nil
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package sinkingyachts
+
+import (
+	"os"
+)
+
+//OpenSnapshot reads a snapshot written by WriteSnapshot
+//memory mapping is not supported on this platform, so the snapshot is read into the heap instead
+func OpenSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshot(data)
+}
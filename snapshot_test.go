@@ -0,0 +1,89 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "evil.org", "a.b.scam.net", "zz.io"}}, SourceFeed)
+	c.lastUpdated = time.Unix(1650000000, 0)
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+	f, err := os.Create(path)
+	a.NoError(err)
+	a.NoError(WriteSnapshot(c, f))
+	a.NoError(f.Close())
+
+	s, err := OpenSnapshot(path)
+	a.NoError(err)
+	defer s.Close()
+	a.Equal(4, s.Size())
+	a.True(s.LastUpdated().Equal(c.lastUpdated))
+
+	var buf bytes.Buffer
+	a.NoError(WriteSnapshot(New("", "test", http.Client{}), &buf))
+	empty, err := newSnapshot(buf.Bytes())
+	a.NoError(err)
+	a.True(empty.LastUpdated().IsZero())
+	a.False(empty.Check("bad.com"))
+
+	tests := []struct {
+		name   string
+		domain string
+		check  bool
+		fuzzy  bool
+	}{
+		{name: "first", domain: "a.b.scam.net", check: true, fuzzy: true},
+		{name: "last", domain: "zz.io", check: true, fuzzy: true},
+		{name: "subdomain", domain: "foo.bad.com", check: false, fuzzy: true},
+		{name: "unknown", domain: "good.com", check: false, fuzzy: false},
+		{name: "before first", domain: "a.com", check: false, fuzzy: false},
+		{name: "after last", domain: "zzz.io", check: false, fuzzy: false},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(data.check, s.Check(data.domain))
+			a.Equal(data.fuzzy, s.FuzzyCheck(data.domain))
+		})
+	}
+}
+
+func TestSnapshotLongDomain(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", strings.Repeat("a", 0x10000) + ".com"}}, SourceFeed)
+	var buf bytes.Buffer
+	a.Error(WriteSnapshot(c, &buf), "domains that don't fit aren't silently left out")
+	a.Zero(buf.Len())
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "evil.org"}}, SourceFeed)
+	var buf bytes.Buffer
+	a.NoError(WriteSnapshot(c, &buf))
+	data := buf.Bytes()
+
+	for i := 0; i < len(data); i++ {
+		_, err := newSnapshot(data[:i])
+		a.ErrorIs(err, ErrInvalidSnapshot, "truncated at %d", i)
+	}
+	_, err := newSnapshot(data)
+	a.NoError(err)
+
+	unsorted := append([]byte{}, data...)
+	copy(unsorted[len(unsorted)-len("evil.org"):], "aaaa.org")
+	_, err = newSnapshot(unsorted)
+	a.ErrorIs(err, ErrInvalidSnapshot)
+}
@@ -0,0 +1,345 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//Stats is a snapshot of Client's state and counters
+type Stats struct {
+	//Domains is the amount of known phishing domains
+	Domains int
+	//LastUpdated is when the cache was last updated
+	LastUpdated time.Time
+	//Generation is the generation of the cache, see Client.Generation
+	Generation uint64
+	//FeedConnected is true while listening for updates
+	FeedConnected bool
+	//FeedConnects is how many times the feed has been connected to
+	FeedConnects uint64
+	//FeedMessages is the amount of updates received from the feed
+	FeedMessages uint64
+	//FeedAdded is the amount of domains added by the feed
+	FeedAdded uint64
+	//FeedRemoved is the amount of domains removed by the feed
+	FeedRemoved uint64
+	//FeedLastMessage is when the last update was received from the feed
+	FeedLastMessage time.Time
+	//FeedLag is the estimated time the last feed update waited between being received and applied
+	FeedLag time.Duration
+	//FeedDelay is how long the last feed update took to propagate from upstream, if the feed provides its time, see DomainUpdate.Delay
+	FeedDelay time.Duration
+	//DryRunHits is the amount of matches observed while in dry run mode
+	DryRunHits uint64
+	//Evicted is the amount of domains evicted to stay within the bound of Client.SetMaxEntries
+	Evicted uint64
+	//Rules are the hit counters of local domains and regex rules, rules that never matched have no hits
+	//this helps to prune dead rules and to identify noisy ones
+	Rules []RuleHits
+	//FeedBytes is the amount of bytes of messages received on the feed
+	FeedBytes uint64
+	//FeedDropped is the amount of feed updates dropped as they couldn't be applied in time, see BackpressureDrop
+	FeedDropped uint64
+	//Endpoints are the requests made to every api endpoint and the bytes received from them, see RawClient.Transfer
+	Endpoints []EndpointTransfer
+	//SyncFailures is the amount of syncs that failed in a row, it's reset by a successful sync
+	SyncFailures int
+	//LastSyncError is the error of the last failed sync, it's empty after a successful sync
+	LastSyncError string
+	//Anomalies is the amount of updates flagged by the AnomalyGuard
+	Anomalies uint64
+	//Held is the amount of updates currently held by the AnomalyGuard
+	Held int
+	//Paused is true while changes to the cache are paused, see Client.Pause
+	Paused bool
+	//MissedWindows is the amount of times Client.Update did a full sync, as the cache was older than the recent window
+	MissedWindows uint64
+}
+
+//Reconnects is the amount of times the feed has been reconnected to after the first connection
+func (s Stats) Reconnects() uint64 {
+	if s.FeedConnects == 0 {
+		return 0
+	}
+	return s.FeedConnects - 1
+}
+
+//SinceLastMessage returns the time since the last feed update, or 0 if none has been received
+//a stalled stream shows up as an ever growing value while FeedConnected is true
+func (s Stats) SinceLastMessage() time.Duration {
+	if s.FeedLastMessage.IsZero() {
+		return 0
+	}
+	return time.Since(s.FeedLastMessage)
+}
+
+//WritePrometheus writes the stats in the prometheus text exposition format
+func (s Stats) WritePrometheus(w io.Writer) error {
+	connected := 0
+	if s.FeedConnected {
+		connected = 1
+	}
+	paused := 0
+	if s.Paused {
+		paused = 1
+	}
+	metrics := []struct {
+		name  string
+		kind  string
+		help  string
+		value float64
+	}{
+		{"sinkingyachts_domains", "gauge", "Amount of known phishing domains.", float64(s.Domains)},
+		{"sinkingyachts_last_updated_timestamp_seconds", "gauge", "Unix time of the last cache update.", unixSeconds(s.LastUpdated)},
+		{"sinkingyachts_cache_generation", "gauge", "Generation of the cache, increased by every change.", float64(s.Generation)},
+		{"sinkingyachts_feed_connected", "gauge", "Whether the update feed is connected.", float64(connected)},
+		{"sinkingyachts_feed_reconnects_total", "counter", "Amount of feed reconnections.", float64(s.Reconnects())},
+		{"sinkingyachts_feed_messages_total", "counter", "Amount of updates received from the feed.", float64(s.FeedMessages)},
+		{"sinkingyachts_feed_domains_added_total", "counter", "Amount of domains added by the feed.", float64(s.FeedAdded)},
+		{"sinkingyachts_feed_domains_removed_total", "counter", "Amount of domains removed by the feed.", float64(s.FeedRemoved)},
+		{"sinkingyachts_feed_last_message_timestamp_seconds", "gauge", "Unix time of the last feed update.", unixSeconds(s.FeedLastMessage)},
+		{"sinkingyachts_feed_lag_seconds", "gauge", "Estimated delay of applying the last feed update.", s.FeedLag.Seconds()},
+		{"sinkingyachts_feed_delay_seconds", "gauge", "Propagation delay of the last timestamped feed update.", s.FeedDelay.Seconds()},
+		{"sinkingyachts_dry_run_hits_total", "counter", "Amount of matches observed in dry run mode.", float64(s.DryRunHits)},
+		{"sinkingyachts_evicted_domains_total", "counter", "Amount of domains evicted to stay within the max entries.", float64(s.Evicted)},
+		{"sinkingyachts_feed_received_bytes_total", "counter", "Amount of bytes received on the feed.", float64(s.FeedBytes)},
+		{"sinkingyachts_feed_dropped_total", "counter", "Amount of feed updates dropped as they couldn't be applied in time.", float64(s.FeedDropped)},
+		{"sinkingyachts_sync_failures", "gauge", "Amount of syncs that failed in a row.", float64(s.SyncFailures)},
+		{"sinkingyachts_anomalies_total", "counter", "Amount of updates flagged by the anomaly guard.", float64(s.Anomalies)},
+		{"sinkingyachts_held_updates", "gauge", "Amount of updates held by the anomaly guard.", float64(s.Held)},
+		{"sinkingyachts_paused", "gauge", "Whether changes to the cache are paused.", float64(paused)},
+		{"sinkingyachts_missed_windows_total", "counter", "Amount of updates that fell back to a full sync as the cache was too old.", float64(s.MissedWindows)},
+	}
+	for _, m := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+		if err != nil {
+			return err
+		}
+	}
+	if len(s.Endpoints) > 0 {
+		_, err := io.WriteString(w, "# HELP sinkingyachts_endpoint_requests_total Amount of requests made to an api endpoint.\n# TYPE sinkingyachts_endpoint_requests_total counter\n")
+		if err != nil {
+			return err
+		}
+		for _, et := range s.Endpoints {
+			_, err = fmt.Fprintf(w, "sinkingyachts_endpoint_requests_total{endpoint=\"%s\"} %d\n", labelValue.Replace(et.Endpoint), et.Requests)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = io.WriteString(w, "# HELP sinkingyachts_endpoint_received_bytes_total Amount of bytes received from an api endpoint.\n# TYPE sinkingyachts_endpoint_received_bytes_total counter\n")
+		if err != nil {
+			return err
+		}
+		for _, et := range s.Endpoints {
+			_, err = fmt.Fprintf(w, "sinkingyachts_endpoint_received_bytes_total{endpoint=\"%s\"} %d\n", labelValue.Replace(et.Endpoint), et.Bytes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(s.Rules) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w, "# HELP sinkingyachts_rule_hits_total Amount of checks matched by local domains, or by each regex rule.\n# TYPE sinkingyachts_rule_hits_total counter\n")
+	if err != nil {
+		return err
+	}
+	//local domains are summed up, as there can be any amount of them, their hits per domain are in Stats.Rules
+	var localHits uint64
+	var local bool
+	for _, rule := range s.Rules {
+		if rule.Kind == RuleLocal {
+			localHits += rule.Hits
+			local = true
+			continue
+		}
+		_, err = fmt.Fprintf(w, "sinkingyachts_rule_hits_total{kind=\"%s\",rule=\"%s\"} %d\n", rule.Kind, labelValue.Replace(rule.Rule), rule.Hits)
+		if err != nil {
+			return err
+		}
+	}
+	if local {
+		_, err = fmt.Fprintf(w, "sinkingyachts_rule_hits_total{kind=\"%s\"} %d\n", RuleLocal, localHits)
+	}
+	return err
+}
+
+//statsJSON is the json representation of Stats
+//times are RFC 3339 and omitted when zero, durations are in seconds, like the prometheus metrics
+type statsJSON struct {
+	Domains          int            `json:"domains"`
+	LastUpdated      *time.Time     `json:"last_updated,omitempty"`
+	Generation       uint64         `json:"generation"`
+	FeedConnected    bool           `json:"feed_connected"`
+	FeedConnects     uint64         `json:"feed_connects"`
+	FeedReconnects   uint64         `json:"feed_reconnects"`
+	FeedMessages     uint64         `json:"feed_messages"`
+	FeedAdded        uint64         `json:"feed_added"`
+	FeedRemoved      uint64         `json:"feed_removed"`
+	FeedLastMessage  *time.Time     `json:"feed_last_message,omitempty"`
+	SinceLastMessage float64        `json:"since_last_message_seconds"`
+	FeedLag          float64        `json:"feed_lag_seconds"`
+	FeedDelay        float64        `json:"feed_delay_seconds"`
+	FeedBytes        uint64         `json:"feed_bytes"`
+	FeedDropped      uint64         `json:"feed_dropped"`
+	DryRunHits       uint64         `json:"dry_run_hits"`
+	Evicted          uint64         `json:"evicted"`
+	SyncFailures     int            `json:"sync_failures"`
+	LastSyncError    string         `json:"last_sync_error,omitempty"`
+	MissedWindows    uint64         `json:"missed_windows"`
+	Anomalies        uint64         `json:"anomalies"`
+	Held             int            `json:"held"`
+	Paused           bool           `json:"paused"`
+	Endpoints        []endpointJSON `json:"endpoints,omitempty"`
+	Rules            []ruleHitsJSON `json:"rules,omitempty"`
+}
+
+//endpointJSON is the json representation of EndpointTransfer
+type endpointJSON struct {
+	Endpoint string `json:"endpoint"`
+	Requests uint64 `json:"requests"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+//ruleHitsJSON is the json representation of RuleHits
+type ruleHitsJSON struct {
+	Kind    string     `json:"kind"`
+	Rule    string     `json:"rule"`
+	Hits    uint64     `json:"hits"`
+	LastHit *time.Time `json:"last_hit,omitempty"`
+}
+
+//MarshalJSON marshals Stats into a flat object of snake case counters and health fields, including the derived ones
+//such as feed_reconnects and since_last_message_seconds, for operators checking on Client with simple http checks
+//before it was added Stats marshalled with its Go field names, such as "Domains" instead of "domains", including on AdminHandler
+func (s Stats) MarshalJSON() ([]byte, error) {
+	sj := statsJSON{
+		Domains:          s.Domains,
+		LastUpdated:      optionalTime(s.LastUpdated),
+		Generation:       s.Generation,
+		FeedConnected:    s.FeedConnected,
+		FeedConnects:     s.FeedConnects,
+		FeedReconnects:   s.Reconnects(),
+		FeedMessages:     s.FeedMessages,
+		FeedAdded:        s.FeedAdded,
+		FeedRemoved:      s.FeedRemoved,
+		FeedLastMessage:  optionalTime(s.FeedLastMessage),
+		SinceLastMessage: s.SinceLastMessage().Seconds(),
+		FeedLag:          s.FeedLag.Seconds(),
+		FeedDelay:        s.FeedDelay.Seconds(),
+		FeedBytes:        s.FeedBytes,
+		FeedDropped:      s.FeedDropped,
+		DryRunHits:       s.DryRunHits,
+		Evicted:          s.Evicted,
+		SyncFailures:     s.SyncFailures,
+		LastSyncError:    s.LastSyncError,
+		MissedWindows:    s.MissedWindows,
+		Anomalies:        s.Anomalies,
+		Held:             s.Held,
+		Paused:           s.Paused,
+	}
+	for _, et := range s.Endpoints {
+		sj.Endpoints = append(sj.Endpoints, endpointJSON{Endpoint: et.Endpoint, Requests: et.Requests, Bytes: et.Bytes})
+	}
+	for _, rule := range s.Rules {
+		sj.Rules = append(sj.Rules, ruleHitsJSON{Kind: rule.Kind, Rule: rule.Rule, Hits: rule.Hits, LastHit: optionalTime(rule.LastHit)})
+	}
+	return json.Marshal(sj)
+}
+
+//public returns s without the fields that shouldn't be served without authentication
+//rule hits can reveal local domains and regex rules, and the last sync error can reveal internal addresses
+func (s Stats) public() Stats {
+	s.Rules = nil
+	s.LastSyncError = ""
+	return s
+}
+
+//optionalTime returns a pointer to t, or nil if t is zero so it's omitted
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+//labelValue escapes a prometheus label value
+var labelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+//Stats returns a snapshot of Client's state and counters
+func (c *Client) Stats() Stats {
+	c.m.Lock()
+	defer c.m.Unlock()
+	syncErr := ""
+	if c.syncErr != nil {
+		syncErr = c.syncErr.Error()
+	}
+	transfer := c.r.Transfer()
+	return Stats{
+		Domains:         len(c.domains),
+		LastUpdated:     c.lastUpdated,
+		Generation:      c.generation,
+		FeedConnected:   c.streaming,
+		FeedConnects:    c.feed.connects,
+		FeedMessages:    c.feed.messages,
+		FeedAdded:       c.feed.added,
+		FeedRemoved:     c.feed.removed,
+		FeedLastMessage: c.feed.lastMessage,
+		FeedLag:         c.feed.lag,
+		FeedDelay:       c.feed.delay,
+		DryRunHits:      c.dryRunHits,
+		Evicted:         c.evicted,
+		Rules:           c.ruleHitsLocked(),
+		FeedBytes:       transfer.FeedBytes,
+		FeedDropped:     transfer.FeedDropped,
+		Endpoints:       transfer.Endpoints,
+		SyncFailures:    c.syncFailures,
+		LastSyncError:   syncErr,
+		Anomalies:       c.anomalies,
+		Held:            len(c.held),
+		Paused:          c.paused,
+		MissedWindows:   c.missedWindows,
+	}
+}
+
+//feedStats are the counters of feed activity
+type feedStats struct {
+	connects    uint64
+	messages    uint64
+	added       uint64
+	removed     uint64
+	lastMessage time.Time
+	lag         time.Duration
+	delay       time.Duration
+}
+
+//record counts an update from the feed applied at now
+func (f *feedStats) record(u feedUpdate, now time.Time) {
+	mod := u.mod
+	f.messages++
+	if mod.Add {
+		f.added += uint64(len(mod.Domains))
+	} else {
+		f.removed += uint64(len(mod.Domains))
+	}
+	f.lastMessage = now
+	if !u.received.IsZero() {
+		f.lastMessage = u.received
+		f.lag = now.Sub(u.received)
+	}
+	if delay := u.delay(); delay != 0 {
+		f.delay = delay
+	}
+}
+
+//unixSeconds returns t as fractional unix seconds, or 0 if t is zero
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / float64(time.Second)
+}
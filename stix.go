@@ -0,0 +1,167 @@
+package sinkingyachts
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//stixNamespace is the namespace of the deterministic ids of STIX objects
+//ids derived from it are stable across exports, so consumers can deduplicate indicators
+var stixNamespace = [16]byte{0x6f, 0x2b, 0x5a, 0x1e, 0x3c, 0x94, 0x4e, 0x0d, 0x9b, 0x27, 0x81, 0x4a, 0xc2, 0x6d, 0x15, 0xe8}
+
+//STIXExporter exports domains as a STIX 2.1 bundle of indicators, for threat intelligence platforms and SIEMs
+//indicator ids are derived from the domain, so re-exporting a domain updates the same indicator
+type STIXExporter struct {
+	//Producer is the name of the identity that created the indicators, leave empty to omit the identity
+	Producer string
+}
+
+//stixObject is a STIX domain object, only the fields used by indicators and identities are included
+type stixObject struct {
+	Type           string     `json:"type"`
+	SpecVersion    string     `json:"spec_version"`
+	ID             string     `json:"id"`
+	Created        time.Time  `json:"created"`
+	Modified       time.Time  `json:"modified"`
+	CreatedByRef   string     `json:"created_by_ref,omitempty"`
+	Name           string     `json:"name"`
+	IdentityClass  string     `json:"identity_class,omitempty"`
+	IndicatorTypes []string   `json:"indicator_types,omitempty"`
+	Pattern        string     `json:"pattern,omitempty"`
+	PatternType    string     `json:"pattern_type,omitempty"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	Labels         []string   `json:"labels,omitempty"`
+	Revoked        bool       `json:"revoked,omitempty"`
+}
+
+type stixBundle struct {
+	Type    string       `json:"type"`
+	ID      string       `json:"id"`
+	Objects []stixObject `json:"objects"`
+}
+
+//Export writes the domains as indicators valid from when they were added if known, otherwise from when the cache was last updated
+func (s STIXExporter) Export(w io.Writer, set ExportSet) error {
+	objects, producer := s.objects()
+	fallback := set.LastUpdated
+	if fallback.IsZero() {
+		fallback = time.Now()
+	}
+	for _, entry := range set.Entries {
+		at, category := fallback, DefaultCategory
+		if md := entry.Metadata; md != nil {
+			if !md.AddedAt.IsZero() {
+				at = md.AddedAt
+			}
+			if md.Category != "" {
+				category = md.Category
+			}
+		}
+		objects = append(objects, stixIndicator(entry.Domain, category, producer, at))
+	}
+	return writeSTIXBundle(w, objects)
+}
+
+//ExportUpdates writes incremental updates as indicators, added domains are new indicators and removed domains are revoked
+func (s STIXExporter) ExportUpdates(w io.Writer, updates []AppliedUpdate) error {
+	objects, producer := s.objects()
+	for _, au := range updates {
+		for _, domain := range au.Update.Domains {
+			indicator := stixIndicator(domain, au.Update.category(), producer, au.Time)
+			if !au.Update.Add {
+				indicator.Revoked = true
+			}
+			objects = append(objects, indicator)
+		}
+	}
+	return writeSTIXBundle(w, objects)
+}
+
+//objects returns the initial objects of a bundle, and the id of the producer identity if any
+func (s STIXExporter) objects() ([]stixObject, string) {
+	if s.Producer == "" {
+		return []stixObject{}, ""
+	}
+	identity := stixObject{
+		Type:          "identity",
+		SpecVersion:   "2.1",
+		ID:            "identity--" + uuidV5(stixNamespace, "identity:"+s.Producer),
+		Created:       stixEpoch,
+		Modified:      stixEpoch,
+		Name:          s.Producer,
+		IdentityClass: "organization",
+	}
+	return []stixObject{identity}, identity.ID
+}
+
+//stixEpoch is when objects with derived ids are created
+//STIX requires created to never change for an id, and ids are derived from the domain, so it can't be any time that varies between exports
+var stixEpoch = time.Unix(0, 0).UTC()
+
+//stixIndicator creates the indicator of a domain, modified and valid from at
+func stixIndicator(domain, category, producer string, at time.Time) stixObject {
+	at = at.UTC().Truncate(time.Millisecond)
+	return stixObject{
+		Type:           "indicator",
+		SpecVersion:    "2.1",
+		ID:             "indicator--" + uuidV5(stixNamespace, "domain:"+domain),
+		Created:        stixEpoch,
+		Modified:       at,
+		CreatedByRef:   producer,
+		Name:           domain,
+		IndicatorTypes: []string{"malicious-activity"},
+		Pattern:        fmt.Sprintf("[domain-name:value = '%s']", stixEscaper.Replace(domain)),
+		PatternType:    "stix",
+		ValidFrom:      &at,
+		Labels:         []string{category},
+	}
+}
+
+//stixEscaper escapes string literals of STIX patterns
+var stixEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func writeSTIXBundle(w io.Writer, objects []stixObject) error {
+	id, err := uuidV4()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stixBundle{
+		Type:    "bundle",
+		ID:      "bundle--" + id,
+		Objects: objects,
+	})
+}
+
+//uuidV5 returns the name based uuid of name in namespace, see RFC 4122
+func uuidV5(namespace [16]byte, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+//uuidV4 returns a random uuid, see RFC 4122
+func uuidV4() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
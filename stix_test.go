@@ -0,0 +1,51 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUUIDV5(t *testing.T) {
+	dns := [16]byte{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	assert.Equal(t, "886313e1-3b8a-5372-9b90-0c9aee199e5d", uuidV5(dns, "python.org"))
+}
+
+func TestSTIXExporter(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"bad.com", "it's.com"}}, SourceFeed)
+	c.lastUpdated = time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	e := STIXExporter{Producer: "Example Org"}
+	a.NoError(WriteExport(c, &buf, e))
+	var bundle stixBundle
+	a.NoError(json.Unmarshal(buf.Bytes(), &bundle))
+	a.Equal("bundle", bundle.Type)
+	a.Len(bundle.Objects, 3)
+	a.Equal("identity", bundle.Objects[0].Type)
+	a.Nil(bundle.Objects[0].ValidFrom)
+	indicator := bundle.Objects[1]
+	a.Equal("indicator", indicator.Type)
+	a.Equal("[domain-name:value = 'bad.com']", indicator.Pattern)
+	a.Equal(bundle.Objects[0].ID, indicator.CreatedByRef)
+	a.True(indicator.Created.Equal(stixEpoch))
+	a.True(indicator.Modified.Equal(c.lastUpdated))
+	a.True(indicator.ValidFrom.Equal(c.lastUpdated))
+	a.Equal([]string{DefaultCategory}, indicator.Labels)
+	a.Equal(`[domain-name:value = 'it\'s.com']`, bundle.Objects[2].Pattern)
+
+	buf.Reset()
+	a.NoError(e.ExportUpdates(&buf, []AppliedUpdate{{Update: DomainUpdate{Add: false, Domains: []string{"bad.com"}}, Time: time.Now()}}))
+	var updates stixBundle
+	a.NoError(json.Unmarshal(buf.Bytes(), &updates))
+	a.Len(updates.Objects, 2)
+	a.True(updates.Objects[1].Revoked)
+	a.Equal(indicator.ID, updates.Objects[1].ID, "indicator ids should be stable")
+	a.True(indicator.Created.Equal(updates.Objects[1].Created), "created must not change for an id")
+	a.True(updates.Objects[1].Modified.After(indicator.Modified))
+}
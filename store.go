@@ -0,0 +1,148 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//Store persists Client's cache
+type Store interface {
+	//Save stores the Client's cache
+	Save(c *Client) error
+	//Load loads the stored cache into Client
+	Load(c *Client) error
+}
+
+//FileStore is a Store that keeps the cache in a single file
+//saves are atomic, the cache is written into a temporary file which then replaces the old one
+type FileStore struct {
+	path       string
+	format     CacheFormat
+	backups    BackupPolicy
+	key        KeyFunc
+	m          sync.Mutex
+	lastBackup time.Time
+}
+
+//NewFileStore creates a FileStore storing the cache at path in the given format
+func NewFileStore(path string, format CacheFormat) *FileStore {
+	return &FileStore{
+		path:   path,
+		format: format,
+	}
+}
+
+//Save writes the Client's cache into the file, and backs it up if backups are enabled
+func (s *FileStore) Save(c *Client) error {
+	err := writeFileAtomic(s.path, s.write(c))
+	if err != nil {
+		return err
+	}
+	return s.backup()
+}
+
+//EnableEncryption encrypts the file with AES-GCM under the key, see Encrypt
+//this should be called before the FileStore is used, files saved without encryption can't be loaded afterwards
+func (s *FileStore) EnableEncryption(key KeyFunc) {
+	s.key = key
+}
+
+//write returns a function writing the Client's cache into a writer, encrypted if enabled
+func (s *FileStore) write(c *Client) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		if s.key == nil {
+			return WriteCacheFormat(c, w, s.format)
+		}
+		data, err := s.format.marshal(c)
+		if err != nil {
+			return err
+		}
+		sealed, err := Encrypt(s.key, data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(sealed)
+		return err
+	}
+}
+
+//read reads the cache from r into Client, decrypting it if enabled
+func (s *FileStore) read(c *Client, r io.Reader) error {
+	if s.key == nil {
+		return ReadCacheFormat(c, r, s.format)
+	}
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data, err := Decrypt(s.key, sealed)
+	if err != nil {
+		return err
+	}
+	return ReadCacheFormat(c, bytes.NewReader(data), s.format)
+}
+
+//writeFileAtomic writes a temporary file next to path with fn, which then replaces path
+func writeFileAtomic(path string, fn func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = fn(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+//Load reads the cache from the file into Client
+//an error satisfying errors.Is(err, os.ErrNotExist) is returned if nothing has been saved yet
+func (s *FileStore) Load(c *Client) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.read(c, f)
+}
+
+//Shutdown stops listening for updates, applies updates that have already been received, and then saves into store
+//it returns once the final save is done, or with ctx's error if ctx is done before the feed stops
+//store may be nil to only stop, Client can still be used afterwards
+func (c *Client) Shutdown(ctx context.Context, store Store) error {
+	c.m.Lock()
+	if c.listenStop != nil {
+		c.listenStop()
+	}
+	if c.cancelFunc != nil {
+		c.cancelFunc()
+	}
+	c.m.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		c.draining.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if store == nil {
+		return nil
+	}
+	return store.Save(c)
+}
@@ -0,0 +1,116 @@
+package sinkingyachts
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	r := NewReplicator(primary, "")
+	defer r.Close()
+	srv := httptest.NewServer(http.StripPrefix(endpointFeed, r))
+	defer srv.Close()
+
+	store := NewFileStore(filepath.Join(t.TempDir(), "cache.json"), CacheJSON)
+	a.True(errors.Is(store.Load(New("", "test", http.Client{})), os.ErrNotExist))
+
+	follower := New("ws"+strings.TrimPrefix(srv.URL, "http"), "test", http.Client{})
+	a.NoError(follower.StartListening(context.Background()))
+	a.Eventually(func() bool { return follower.Size() == 2 }, time.Second, time.Millisecond*10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	a.NoError(follower.Shutdown(ctx, store))
+	a.False(follower.Listening())
+
+	loaded := New("", "test", http.Client{})
+	a.NoError(store.Load(loaded))
+	a.Equal(2, loaded.Size())
+}
+
+func TestFileStoreWatch(t *testing.T) {
+	a := assert.New(t)
+	store := NewFileStore(filepath.Join(t.TempDir(), "cache.json"), CacheJSON)
+	writer := New("", "test", http.Client{})
+	reader := New("", "test", http.Client{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- store.Watch(ctx, reader, func(err error) {
+			t.Error(err)
+		})
+	}()
+	//give the watcher time to start
+	time.Sleep(time.Millisecond * 50)
+
+	writer.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	a.NoError(store.Save(writer))
+	a.Eventually(func() bool { return reader.Check("b.com") }, time.Second*2, time.Millisecond*10)
+
+	writer.applyLiveUpdates(DomainUpdate{Add: false, Domains: []string{"a.com"}}, SourceFeed)
+	a.NoError(store.Save(writer))
+	a.Eventually(func() bool { return !reader.Check("a.com") }, time.Second*2, time.Millisecond*10)
+
+	cancel()
+	a.NoError(<-done)
+}
+
+func TestFileStoreBackups(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{"plain", false},
+		{"compressed", true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			path := filepath.Join(t.TempDir(), "cache.json")
+			store := NewFileStore(path, CacheJSON)
+			store.EnableBackups(BackupPolicy{Keep: 2, Compress: data.compress})
+
+			c := New("", "test", http.Client{})
+			for _, domain := range []string{"a.com", "b.com", "c.com"} {
+				c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{domain}}, SourceFeed)
+				a.NoError(store.Save(c))
+				time.Sleep(time.Millisecond * 2)
+			}
+			backups, err := store.Backups()
+			a.NoError(err)
+			a.Len(backups, 2)
+			a.Equal(data.compress, strings.HasSuffix(backups[0], ".gz"))
+
+			restored := New("", "test", http.Client{})
+			a.NoError(store.Restore(restored, backups[1]))
+			a.ElementsMatch([]string{"a.com", "b.com"}, restored.Domains())
+			loaded := New("", "test", http.Client{})
+			a.NoError(store.Load(loaded))
+			a.Equal(2, loaded.Size())
+		})
+	}
+
+	a := assert.New(t)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	store := NewFileStore(path, CacheJSON)
+	store.EnableBackups(BackupPolicy{Keep: 2, Interval: time.Hour})
+	c := New("", "test", http.Client{})
+	a.NoError(store.Save(c))
+	a.NoError(store.Save(c))
+	backups, err := store.Backups()
+	a.NoError(err)
+	a.Len(backups, 1)
+}
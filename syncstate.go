@@ -0,0 +1,93 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+//SyncState is where a Client's syncing left off, without any domains
+//it's much smaller than the cache, so lightweight deployments can persist only it and catch up with Client.Update
+//the domains themselves have to come from elsewhere, such as Bootstrap, as updates before LastUpdated are not fetched again
+type SyncState struct {
+	//LastUpdated is when the cache was last synced with the api
+	LastUpdated time.Time `json:"last_updated"`
+	//Generation is the generation of the cache, see Client.Generation
+	Generation uint64 `json:"generation"`
+	//DeltaInstance is the Mirror instance the last DeltaSync synced from
+	DeltaInstance string `json:"delta_instance,omitempty"`
+	//DeltaGeneration is the generation of the Mirror the last DeltaSync left off at
+	DeltaGeneration uint64 `json:"delta_generation,omitempty"`
+}
+
+//SyncState returns where the Client's syncing left off
+func (c *Client) SyncState() SyncState {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return SyncState{
+		LastUpdated:     c.lastUpdated,
+		Generation:      c.generation,
+		DeltaInstance:   c.delta.instance,
+		DeltaGeneration: c.delta.generation,
+	}
+}
+
+//RestoreSyncState continues syncing from where the state left off, without changing the cached domains
+//the generation never goes backwards, it's only raised to the state's generation if that is higher
+func (c *Client) RestoreSyncState(state SyncState) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.lastUpdated = state.LastUpdated
+	c.delta = deltaCursor{instance: state.DeltaInstance, generation: state.DeltaGeneration}
+	if state.Generation > c.generation {
+		c.generation = state.Generation
+		c.history.reset(c.generation)
+	}
+}
+
+//WriteSyncState saves the Client's SyncState into the writer as JSON
+func WriteSyncState(c *Client, w io.Writer) error {
+	return json.NewEncoder(w).Encode(c.SyncState())
+}
+
+//ReadSyncState loads a SyncState saved by WriteSyncState from the reader into Client
+func ReadSyncState(c *Client, r io.Reader) error {
+	var state SyncState
+	err := json.NewDecoder(r).Decode(&state)
+	if err != nil {
+		return err
+	}
+	c.RestoreSyncState(state)
+	return nil
+}
+
+//StateStore is a Store that keeps only the SyncState in a file, not the domains
+//it can be used wherever a Store is, such as SaveOnChange or Client.Shutdown, see SyncState
+//saves are atomic like FileStore
+type StateStore struct {
+	path string
+}
+
+//NewStateStore creates a StateStore storing the SyncState at path
+func NewStateStore(path string) *StateStore {
+	return &StateStore{path: path}
+}
+
+//Save writes the Client's SyncState into the file
+func (s *StateStore) Save(c *Client) error {
+	return writeFileAtomic(s.path, func(w io.Writer) error {
+		return WriteSyncState(c, w)
+	})
+}
+
+//Load restores the SyncState from the file into Client
+//an error satisfying errors.Is(err, os.ErrNotExist) is returned if nothing has been saved yet
+func (s *StateStore) Load(c *Client) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ReadSyncState(c, f)
+}
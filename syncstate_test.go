@@ -0,0 +1,60 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateStore(t *testing.T) {
+	a := assert.New(t)
+	var seconds int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.True(strings.HasPrefix(r.URL.Path, endpointRecent), "only recent updates should be fetched")
+		seconds, _ = strconv.Atoi(strings.TrimPrefix(r.URL.Path, endpointRecent))
+		_, _ = w.Write([]byte(`[{"type":"add","domains":["new.com"]}]`))
+	}))
+	defer srv.Close()
+
+	store := NewStateStore(filepath.Join(t.TempDir(), "state.json"))
+	c := New(srv.URL, "test", http.Client{})
+	a.True(errors.Is(store.Load(c), os.ErrNotExist))
+
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	c.lastUpdated = time.Now().Add(-time.Hour)
+	c.delta = deltaCursor{instance: "mirror", generation: 42}
+	a.NoError(store.Save(c))
+
+	restored := New(srv.URL, "test", http.Client{})
+	a.NoError(store.Load(restored))
+	want, got := c.SyncState(), restored.SyncState()
+	a.True(want.LastUpdated.Equal(got.LastUpdated))
+	want.LastUpdated, got.LastUpdated = time.Time{}, time.Time{}
+	a.Equal(want, got)
+	a.Empty(restored.Domains(), "domains are not stored")
+
+	a.NoError(restored.Update())
+	a.InDelta((time.Hour + time.Minute).Seconds(), seconds, 5)
+	a.Equal([]string{"new.com"}, restored.Domains())
+}
+
+func TestRestoreSyncState(t *testing.T) {
+	a := assert.New(t)
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com"}}, SourceFeed)
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"b.com"}}, SourceFeed)
+	generation := c.Generation()
+
+	c.RestoreSyncState(SyncState{Generation: generation - 1})
+	a.Equal(generation, c.Generation(), "the generation should never go backwards")
+	c.RestoreSyncState(SyncState{Generation: generation + 10})
+	a.Equal(generation+10, c.Generation())
+	a.ElementsMatch([]string{"a.com", "b.com"}, c.Domains())
+}
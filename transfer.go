@@ -0,0 +1,154 @@
+package sinkingyachts
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Transfer are the totals of data received from the api, for sizing the sync strategy on metered connections
+//bytes are counted as read, after the transport transparently decompresses responses, so they may exceed the bytes on the wire
+type Transfer struct {
+	//FeedBytes is the amount of bytes of messages received on the websocket feed
+	FeedBytes uint64
+	//FeedDropped is the amount of feed updates dropped as the consumer didn't keep up, see BackpressureDrop
+	FeedDropped uint64
+	//Endpoints are the totals of every endpoint requested, sorted by endpoint
+	Endpoints []EndpointTransfer
+}
+
+//EndpointTransfer are the totals of a single endpoint
+//endpoints with a parameter in their path, such as checks, are counted together under their prefix
+type EndpointTransfer struct {
+	//Endpoint is the path of the endpoint, without its parameters
+	Endpoint string
+	//Requests is the amount of requests made to the endpoint
+	Requests uint64
+	//Bytes is the amount of bytes of response bodies received from the endpoint
+	Bytes uint64
+}
+
+//transferStats counts transfers, it's shared by copies of a RawClient
+type transferStats struct {
+	m         sync.Mutex
+	feed      uint64
+	dropped   uint64
+	endpoints map[string]*EndpointTransfer
+}
+
+//newTransferStats creates empty transferStats
+func newTransferStats() *transferStats {
+	return &transferStats{endpoints: map[string]*EndpointTransfer{}}
+}
+
+//request counts a request to an endpoint
+func (t *transferStats) request(endpoint string) {
+	if t == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.endpointLocked(endpoint).Requests++
+}
+
+//received counts bytes received from an endpoint
+func (t *transferStats) received(endpoint string, n int) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.endpointLocked(endpoint).Bytes += uint64(n)
+}
+
+//feedReceived counts bytes received on the feed
+func (t *transferStats) feedReceived(n int) {
+	if t == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.feed += uint64(n)
+}
+
+//feedDropped counts an update dropped from the feed
+func (t *transferStats) feedDropped() {
+	if t == nil {
+		return
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.dropped++
+}
+
+//endpointLocked returns the totals of an endpoint, creating them if needed
+//should only be called when mutex is locked
+func (t *transferStats) endpointLocked(endpoint string) *EndpointTransfer {
+	et, ok := t.endpoints[endpoint]
+	if !ok {
+		et = &EndpointTransfer{Endpoint: endpoint}
+		t.endpoints[endpoint] = et
+	}
+	return et
+}
+
+//snapshot returns a copy of the totals
+func (t *transferStats) snapshot() Transfer {
+	if t == nil {
+		return Transfer{}
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	transfer := Transfer{FeedBytes: t.feed, FeedDropped: t.dropped}
+	for _, et := range t.endpoints {
+		transfer.Endpoints = append(transfer.Endpoints, *et)
+	}
+	sort.Slice(transfer.Endpoints, func(i, j int) bool {
+		return transfer.Endpoints[i].Endpoint < transfer.Endpoints[j].Endpoint
+	})
+	return transfer
+}
+
+//transferEndpoint returns the endpoint a request is counted under, dropping the query and path parameters
+func transferEndpoint(endpoint string) string {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	for _, prefix := range []string{endpointCheck, endpointRecent} {
+		if strings.HasPrefix(endpoint, prefix) {
+			return prefix
+		}
+	}
+	return endpoint
+}
+
+//countingBody counts the bytes read from a response body
+type countingBody struct {
+	io.ReadCloser
+	stats    *transferStats
+	endpoint string
+}
+
+//Read reads from the body, counting the bytes read
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.stats.received(b.endpoint, n)
+	return n, err
+}
+
+//countResponse counts a request to the endpoint, and wraps the body of resp to count the bytes received
+func (t *transferStats) countResponse(endpoint string, resp *http.Response) {
+	if t == nil || resp == nil {
+		return
+	}
+	endpoint = transferEndpoint(endpoint)
+	t.request(endpoint)
+	resp.Body = countingBody{ReadCloser: resp.Body, stats: t, endpoint: endpoint}
+}
+
+//Transfer returns the totals of data received by RawClient and its copies
+func (c RawClient) Transfer() Transfer {
+	return c.transfer.snapshot()
+}
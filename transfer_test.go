@@ -0,0 +1,49 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransfer(t *testing.T) {
+	a := assert.New(t)
+	primary := New("", "test", http.Client{})
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	mirror := NewMirror(primary)
+	defer mirror.Close()
+	srv := httptest.NewServer(mirror)
+	defer srv.Close()
+
+	c := New(srv.URL, "test", http.Client{})
+	a.NoError(c.FullSync())
+	_, err := c.Raw().Check("a.com")
+	a.NoError(err)
+	_, err = c.Raw().As("other").Check("c.com")
+	a.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = c.ListenForUpdates(ctx)
+	}()
+	a.Eventually(func() bool { return c.Stats().FeedConnected }, time.Second, time.Millisecond*10)
+	a.Eventually(func() bool {
+		mirror.feed.m.Lock()
+		defer mirror.feed.m.Unlock()
+		return len(mirror.feed.followers) == 1
+	}, time.Second, time.Millisecond*10)
+	primary.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"c.com"}}, SourceFeed)
+	a.Eventually(func() bool { return c.Check("c.com") }, time.Second, time.Millisecond*10)
+
+	stats := c.Stats()
+	a.NotZero(stats.FeedBytes)
+	a.Len(stats.Endpoints, 2)
+	a.Equal(EndpointTransfer{Endpoint: endpointAll, Requests: 1, Bytes: uint64(len(`["a.com","b.com"]`) + 1)}, stats.Endpoints[0])
+	a.Equal(endpointCheck, stats.Endpoints[1].Endpoint)
+	a.Equal(uint64(2), stats.Endpoints[1].Requests)
+	a.Equal(uint64(len("true")+len("false")), stats.Endpoints[1].Bytes)
+}
@@ -0,0 +1,149 @@
+package sinkingyachts
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+//HTTPVersion is the http version requests are made with, see WithHTTPVersion
+type HTTPVersion int
+
+const (
+	//HTTPAuto uses http/2 when the server supports it, and http/1.1 otherwise
+	HTTPAuto HTTPVersion = iota
+	//HTTP1 always uses http/1.1, for proxies and middleboxes that break http/2
+	HTTP1
+	//HTTP2 attempts http/2 even on transports with a custom dialer or tls config, which otherwise fall back to http/1.1
+	HTTP2
+)
+
+//transportConfig are the transport settings of a RawClient, applied on top of the http.Client's transport
+type transportConfig struct {
+	//dirty is set by options that changed the settings, the transport is only rebuilt if it's set
+	dirty          bool
+	version        HTTPVersion
+	maxIdle        int
+	maxIdlePerHost int
+	idleTimeout    time.Duration
+	keepAlive      time.Duration
+	resolver       Resolver
+	certificates   []tls.Certificate
+	rootCAs        *x509.CertPool
+	preference     IPPreference
+	fallbackDelay  time.Duration
+}
+
+//WithHTTPVersion sets the http version requests are made with, the websocket feed always uses http/1.1
+//transport options make RawClient use its own copy of the http.Client's transport, see WithMaxIdleConns
+func WithHTTPVersion(version HTTPVersion) Option {
+	return func(client *RawClient) {
+		client.transport.version = version
+		client.transport.dirty = true
+	}
+}
+
+//WithHTTP2 attempts http/2 for requests, it's the same as WithHTTPVersion(HTTP2)
+func WithHTTP2() Option {
+	return WithHTTPVersion(HTTP2)
+}
+
+//WithMaxIdleConns sets how many idle connections are kept open, in total and per host, 0 keeps the transport's setting
+//like every transport option, it makes RawClient use its own copy of the http.Client's transport
+//so it no longer shares the connection pool with other clients, a nil transport is copied from http.DefaultTransport
+//custom transports that aren't a *http.Transport, such as a Recorder, can't be tuned and are left as is
+func WithMaxIdleConns(total, perHost int) Option {
+	return func(client *RawClient) {
+		client.transport.maxIdle = total
+		client.transport.maxIdlePerHost = perHost
+		client.transport.dirty = true
+	}
+}
+
+//WithIdleConnTimeout sets how long idle connections are kept open, see WithMaxIdleConns for how transports are tuned
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(client *RawClient) {
+		client.transport.idleTimeout = timeout
+		client.transport.dirty = true
+	}
+}
+
+//WithTCPKeepAlive sets the interval of tcp keepalive probes, a negative interval disables them
+//it replaces the dialer of the transport, see WithMaxIdleConns for how transports are tuned
+func WithTCPKeepAlive(interval time.Duration) Option {
+	return func(client *RawClient) {
+		client.transport.keepAlive = interval
+		client.transport.dirty = true
+	}
+}
+
+//WithClientCertificate presents certs to servers that require mutual tls, such as a private Mirror or relay
+//it applies to both requests and the websocket feed, see WithMaxIdleConns for how transports are tuned
+//certificates can be loaded with tls.LoadX509KeyPair, the first one is presented unless the server asks for another ca
+func WithClientCertificate(certs ...tls.Certificate) Option {
+	return func(client *RawClient) {
+		client.transport.certificates = certs
+		client.transport.dirty = true
+	}
+}
+
+//WithRootCAs verifies the certificates of servers with pool instead of the system roots, such as for a Mirror with a private ca
+//see WithMaxIdleConns for how transports are tuned
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(client *RawClient) {
+		client.transport.rootCAs = pool
+		client.transport.dirty = true
+	}
+}
+
+//applyTransport rebuilds the transport of RawClient if transport options changed its settings
+func (c *RawClient) applyTransport() {
+	if !c.transport.dirty {
+		return
+	}
+	c.transport.dirty = false
+	var t *http.Transport
+	switch base := c.webClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = base.Clone()
+	default:
+		return
+	}
+	cfg := c.transport
+	switch cfg.version {
+	case HTTP1:
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case HTTP2:
+		t.ForceAttemptHTTP2 = true
+	}
+	if cfg.maxIdle > 0 {
+		t.MaxIdleConns = cfg.maxIdle
+	}
+	if cfg.maxIdlePerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.maxIdlePerHost
+	}
+	if cfg.idleTimeout > 0 {
+		t.IdleConnTimeout = cfg.idleTimeout
+	}
+	if cfg.customDialer() {
+		t.DialContext = cfg.dialContext()
+	}
+	if len(cfg.certificates) > 0 || cfg.rootCAs != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		} else {
+			t.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+		if len(cfg.certificates) > 0 {
+			t.TLSClientConfig.Certificates = cfg.certificates
+		}
+		if cfg.rootCAs != nil {
+			t.TLSClientConfig.RootCAs = cfg.rootCAs
+		}
+	}
+	c.webClient.Transport = t
+}
@@ -3,13 +3,19 @@ package sinkingyachts
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 )
 
 //save is the on disk save format
 type save struct {
-	LastUpdated time.Time `json:"last_updated"`
-	Domains     []string  `json:"domains"`
+	LastUpdated  time.Time            `json:"last_updated"`
+	Domains      []string             `json:"domains"`
+	Metadata     map[string]Metadata  `json:"metadata,omitempty"`
+	Local        map[string]time.Time `json:"local,omitempty"`
+	Reviews      map[string]time.Time `json:"reviews,omitempty"`
+	LocalAdded   map[string]time.Time `json:"local_added,omitempty"`
+	LocalRemoved map[string]time.Time `json:"local_removed,omitempty"`
 }
 
 //DomainUpdate represent an update to the domains list,
@@ -19,6 +25,84 @@ type DomainUpdate struct {
 	Add bool
 	//Domains is a slice of domains
 	Domains []string
+	//Category is the kind of threat the domains are, if provided by the source
+	//an empty category is treated as DefaultCategory
+	Category string
+	//Time is when the update was made upstream, it is zero unless provided by the source
+	Time time.Time
+	//Origin is who or what made the update upstream, it is empty unless provided by the source
+	Origin string
+}
+
+//UpdateSource describes where an applied update originated from
+type UpdateSource string
+
+const (
+	//SourceFeed is an update received from the websocket feed
+	SourceFeed UpdateSource = "feed"
+	//SourceRecent is an update fetched with Client.Update
+	SourceRecent UpdateSource = "recent"
+	//SourceFullSync is a change discovered by Client.FullSync
+	SourceFullSync UpdateSource = "full_sync"
+	//SourcePubSub is an update received with SubscribeUpdates
+	SourcePubSub UpdateSource = "pubsub"
+	//SourceWebhook is an update received by WebhookHandler
+	SourceWebhook UpdateSource = "webhook"
+	//SourceDelta is a change fetched from a Mirror with Client.DeltaSync
+	SourceDelta UpdateSource = "delta"
+	//SourceEviction is a removal of the oldest domains by Client.SetMaxEntries
+	SourceEviction UpdateSource = "eviction"
+	//SourceRepair is a change fetched from a Mirror with Client.Repair
+	SourceRepair UpdateSource = "repair"
+	//SourceReset is the removal of every domain by Client.Reset
+	SourceReset UpdateSource = "reset"
+)
+
+//AppliedUpdate is a DomainUpdate that has been applied to Client
+type AppliedUpdate struct {
+	//Update is the applied update
+	Update DomainUpdate
+	//Time is when the update was applied
+	Time time.Time
+	//Source is where the update originated from
+	Source UpdateSource
+}
+
+//appliedEntry is the serialized representation of AppliedUpdate
+type appliedEntry struct {
+	Time     time.Time    `json:"time"`
+	Source   UpdateSource `json:"source"`
+	Type     string       `json:"type"`
+	Domains  []string     `json:"domains"`
+	Category string       `json:"category,omitempty"`
+}
+
+//MarshalJSON marshal AppliedUpdate into a flat object of time, source, type and domains
+func (a AppliedUpdate) MarshalJSON() ([]byte, error) {
+	me := newModEntry(a.Update)
+	return json.Marshal(appliedEntry{
+		Time:     a.Time,
+		Source:   a.Source,
+		Type:     me.Type,
+		Domains:  me.Domains,
+		Category: me.Category,
+	})
+}
+
+//UnmarshalJSON unmarshal AppliedUpdate from the format produced by MarshalJSON
+func (a *AppliedUpdate) UnmarshalJSON(bytes []byte) error {
+	var ae appliedEntry
+	err := json.Unmarshal(bytes, &ae)
+	if err != nil {
+		return err
+	}
+	err = a.Update.fromModEntry(modEntry{Type: ae.Type, Domains: ae.Domains, Category: ae.Category})
+	if err != nil {
+		return err
+	}
+	a.Time = ae.Time
+	a.Source = ae.Source
+	return nil
 }
 
 //modEntry is the api representation of a domain update
@@ -27,14 +111,63 @@ type modEntry struct {
 	Type string `json:"type"`
 	//Domains is a slice of domains
 	Domains []string `json:"domains"`
+	//Category is the optional kind of threat
+	Category string `json:"category,omitempty"`
+	//Timestamp is the optional time the update was made
+	Timestamp *updateTime `json:"timestamp,omitempty"`
+	//Origin is the optional attribution of the update
+	Origin string `json:"origin,omitempty"`
 }
 
+//updateTime is a timestamp of an update, either an RFC 3339 string, or a number of unix seconds or milliseconds
+type updateTime time.Time
+
+//unixMillisThreshold is the smallest unix timestamp treated as milliseconds, it's far beyond any plausible time in seconds
+const unixMillisThreshold = 1e11
+
+//MarshalJSON marshals the timestamp as an RFC 3339 string
+func (t updateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(t).Format(time.RFC3339Nano))
+}
+
+//UnmarshalJSON unmarshals the timestamp from an RFC 3339 string, or a number of unix seconds or milliseconds
+//timestamps in other formats are left zero instead of failing, as they are optional and the update is still valid
+func (t *updateTime) UnmarshalJSON(bytes []byte) error {
+	var unix float64
+	if err := json.Unmarshal(bytes, &unix); err == nil {
+		if unix >= unixMillisThreshold {
+			unix /= 1000
+		}
+		sec, frac := math.Modf(unix)
+		*t = updateTime(time.Unix(int64(sec), int64(frac*float64(time.Second))))
+		return nil
+	}
+	var parsed time.Time
+	if err := json.Unmarshal(bytes, &parsed); err != nil {
+		*t = updateTime{}
+		return nil
+	}
+	*t = updateTime(parsed)
+	return nil
+}
+
+//MarshalJSON marshal DomainUpdate into the api's format of {"type":"add"/"delete","domains":[...]}
+func (m DomainUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newModEntry(m))
+}
+
+//UnmarshalJSON unmarshal DomainUpdate from the api's format
 func (m *DomainUpdate) UnmarshalJSON(bytes []byte) error {
 	var me modEntry
 	err := json.Unmarshal(bytes, &me)
 	if err != nil {
 		return err
 	}
+	return m.fromModEntry(me)
+}
+
+//fromModEntry sets DomainUpdate from its api representation
+func (m *DomainUpdate) fromModEntry(me modEntry) error {
 	switch me.Type {
 	case "add":
 		m.Add = true
@@ -44,9 +177,33 @@ func (m *DomainUpdate) UnmarshalJSON(bytes []byte) error {
 		return fmt.Errorf(`expecting "add" or "delete" in modEntry.Type, received "%s"`, me.Type)
 	}
 	m.Domains = me.Domains
+	m.Category = me.Category
+	m.Time = time.Time{}
+	if me.Timestamp != nil {
+		m.Time = time.Time(*me.Timestamp)
+	}
+	m.Origin = me.Origin
 	return nil
 }
 
+//newModEntry converts DomainUpdate into its api representation
+func newModEntry(m DomainUpdate) modEntry {
+	me := modEntry{
+		Type:     "delete",
+		Domains:  m.Domains,
+		Category: m.Category,
+		Origin:   m.Origin,
+	}
+	if !m.Time.IsZero() {
+		ts := updateTime(m.Time)
+		me.Timestamp = &ts
+	}
+	if m.Add {
+		me.Type = "add"
+	}
+	return me
+}
+
 type empty struct{}
 
 type unexpectedStatusError struct {
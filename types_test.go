@@ -0,0 +1,94 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDomainUpdateJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		update DomainUpdate
+		json   string
+	}{
+		{
+			name:   "Add",
+			update: DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}},
+			json:   `{"type":"add","domains":["a.com","b.com"]}`,
+		},
+		{
+			name:   "Category",
+			update: DomainUpdate{Add: true, Domains: []string{"a.com"}, Category: "scam"},
+			json:   `{"type":"add","domains":["a.com"],"category":"scam"}`,
+		},
+		{
+			name:   "Delete",
+			update: DomainUpdate{Add: false, Domains: []string{"a.com"}},
+			json:   `{"type":"delete","domains":["a.com"]}`,
+		},
+		{
+			name:   "Attribution",
+			update: DomainUpdate{Add: true, Domains: []string{"a.com"}, Time: time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC), Origin: "reporter"},
+			json:   `{"type":"add","domains":["a.com"],"timestamp":"2022-03-01T12:00:00Z","origin":"reporter"}`,
+		},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			b, err := json.Marshal(data.update)
+			a.NoError(err)
+			a.JSONEq(data.json, string(b))
+
+			var mod DomainUpdate
+			a.NoError(json.Unmarshal(b, &mod))
+			a.Equal(data.update, mod)
+		})
+	}
+}
+
+func TestDomainUpdateTimestamp(t *testing.T) {
+	want := time.Date(2022, 3, 1, 12, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		name      string
+		timestamp string
+		invalid   bool
+	}{
+		{name: "rfc3339", timestamp: `"2022-03-01T12:00:00.5Z"`},
+		{name: "rfc3339 offset", timestamp: `"2022-03-01T14:00:00.5+02:00"`},
+		{name: "unix seconds", timestamp: `1646136000.5`},
+		{name: "unix milliseconds", timestamp: `1646136000500`},
+		{name: "invalid", timestamp: `"yesterday"`, invalid: true},
+		{name: "empty", timestamp: `""`, invalid: true},
+		{name: "object", timestamp: `{"seconds":1646136000}`, invalid: true},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			var mod DomainUpdate
+			err := json.Unmarshal([]byte(`{"type":"add","domains":["a.com"],"timestamp":`+data.timestamp+`}`), &mod)
+			a.NoError(err)
+			a.Equal([]string{"a.com"}, mod.Domains)
+			if data.invalid {
+				a.True(mod.Time.IsZero(), "invalid timestamps are ignored")
+				return
+			}
+			a.True(want.Equal(mod.Time), "expected %s, got %s", want, mod.Time)
+		})
+	}
+}
+
+func TestDomainUpdateDelay(t *testing.T) {
+	a := assert.New(t)
+	made := time.Now().Add(-time.Second * 3)
+	u := feedUpdate{mod: DomainUpdate{Time: made}}
+	a.Zero(u.delay(), "updates that weren't received from the feed have no delay")
+	u.received = made.Add(time.Second * 2)
+	a.Equal(time.Second*2, u.delay())
+
+	var f feedStats
+	f.record(u, time.Now())
+	a.Equal(time.Second*2, f.delay)
+	a.Equal(u.received, f.lastMessage)
+}
@@ -0,0 +1,223 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+//ErrInvalidSignature is returned by BootstrapVerified when the cache doesn't match its signature
+var ErrInvalidSignature = errors.New("invalid cache signature")
+
+//Uploader stores a named object where other instances can download it from, such as an S3 or GCS bucket
+type Uploader interface {
+	Upload(ctx context.Context, name string, data []byte) error
+}
+
+//UploaderFunc is a function that implements Uploader
+type UploaderFunc func(ctx context.Context, name string, data []byte) error
+
+//Upload calls f(ctx, name, data)
+func (f UploaderFunc) Upload(ctx context.Context, name string, data []byte) error {
+	return f(ctx, name, data)
+}
+
+//HTTPUploader uploads objects with a PUT request to the name under URL
+//S3 and GCS accept such uploads, authenticated with Header or with a Client whose transport signs the requests
+type HTTPUploader struct {
+	//URL is the prefix of the objects, such as "https://bucket.s3.amazonaws.com/sinkingyachts"
+	URL string
+	//Client sends the requests, defaults to http.DefaultClient
+	Client *http.Client
+	//Header is added to every request
+	Header http.Header
+}
+
+//Upload puts data at URL/name
+func (u HTTPUploader) Upload(ctx context.Context, name string, data []byte) error {
+	url := strings.TrimSuffix(u.URL, "/") + "/" + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for key, values := range u.Header {
+		req.Header[key] = values
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return unexpectedStatusError{
+			endpoint: url,
+			status:   resp.StatusCode,
+		}
+	}
+	return nil
+}
+
+//SnapshotPublisher uploads the cache for fleets to Bootstrap from
+//next to the cache "<name>.sha256" holds its checksum in the format of sha256sum,
+//and "<name>.sig" its base64 ed25519 signature if Key is set
+//the cache is uploaded last, downloads racing an upload may fail verification and should fall back to a full sync
+type SnapshotPublisher struct {
+	//Uploader stores the objects
+	Uploader Uploader
+	//Name is the name of the cache object, such as "cache.json"
+	Name string
+	//Format is the format of the cache
+	Format CacheFormat
+	//Key signs the cache, leave nil to not sign it
+	Key ed25519.PrivateKey
+}
+
+//Publish uploads the Client's cache once
+func (p *SnapshotPublisher) Publish(ctx context.Context, c *Client) error {
+	data, err := p.Format.marshal(c)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	err = p.Uploader.Upload(ctx, p.Name+".sha256", []byte(hex.EncodeToString(sum[:])+"  "+p.Name+"\n"))
+	if err != nil {
+		return fmt.Errorf("uploading checksum: %w", err)
+	}
+	if p.Key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(p.Key, data))
+		err = p.Uploader.Upload(ctx, p.Name+".sig", []byte(sig+"\n"))
+		if err != nil {
+			return fmt.Errorf("uploading signature: %w", err)
+		}
+	}
+	err = p.Uploader.Upload(ctx, p.Name, data)
+	if err != nil {
+		return fmt.Errorf("uploading cache: %w", err)
+	}
+	return nil
+}
+
+//PublishSnapshots publishes the Client's cache right away, and then every interval if it changed
+//this function blocks and returns only when cancelled by ctx, or when publishing fails
+func PublishSnapshots(ctx context.Context, c *Client, p *SnapshotPublisher, interval time.Duration) error {
+	changed := make(chan struct{}, 1)
+	remove := c.OnUpdate(func(AppliedUpdate) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	defer remove()
+
+	err := p.Publish(ctx, c)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			select {
+			case <-changed:
+			default:
+				continue
+			}
+			err = p.Publish(ctx, c)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//signatureMaxSize is the largest signature downloaded by BootstrapVerified, well above the size of a base64 ed25519 signature
+const signatureMaxSize = 1 << 10
+
+//BootstrapVerified is Bootstrap, but the cache must match its signature at "<url>.sig" made by key
+//ErrInvalidSignature is returned if it doesn't, and the Client's cache is left unchanged
+func BootstrapVerified(ctx context.Context, c *Client, client *http.Client, url string, format CacheFormat, key ed25519.PublicKey) error {
+	return bootstrapVerified(ctx, c, client, url, format, key, DefaultBootstrapMaxSize)
+}
+
+//bootstrapVerified is BootstrapVerified, rejecting caches larger than maxSize
+func bootstrapVerified(ctx context.Context, c *Client, client *http.Client, url string, format CacheFormat, key ed25519.PublicKey, maxSize int64) error {
+	encoded, err := download(ctx, client, url+".sig", signatureMaxSize)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	data, err := download(ctx, client, url, maxSize)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return ErrInvalidSignature
+	}
+	return ReadCacheFormat(c, bytes.NewReader(data), format)
+}
+
+//LoadSigningKey reads an ed25519 private key from a PKCS #8 PEM file, as generated by "openssl genpkey -algorithm ed25519"
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	key, err := readPEMKey(path, x509.ParsePKCS8PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 private key", path)
+	}
+	return private, nil
+}
+
+//LoadVerifyKey reads an ed25519 public key from a PKIX PEM file, as generated by "openssl pkey -pubout"
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	key, err := readPEMKey(path, x509.ParsePKIXPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 public key", path)
+	}
+	return public, nil
+}
+
+//readPEMKey parses the first PEM block of the file with parse
+func readPEMKey(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	key, err := parse(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package sinkingyachts
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotPublisher(t *testing.T) {
+	a := assert.New(t)
+	var m sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer srv.Close()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	a.NoError(err)
+	dir := t.TempDir()
+	writePEM := func(name, kind string, der []byte) string {
+		path := filepath.Join(dir, name)
+		a.NoError(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600))
+		return path
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	a.NoError(err)
+	private, err = LoadSigningKey(writePEM("key.pem", "PRIVATE KEY", der))
+	a.NoError(err)
+	der, err = x509.MarshalPKIXPublicKey(public)
+	a.NoError(err)
+	public, err = LoadVerifyKey(writePEM("key.pub", "PUBLIC KEY", der))
+	a.NoError(err)
+	_, err = LoadVerifyKey(filepath.Join(dir, "key.pem"))
+	a.Error(err)
+
+	c := New("", "test", http.Client{})
+	c.applyLiveUpdates(DomainUpdate{Add: true, Domains: []string{"a.com", "b.com"}}, SourceFeed)
+	p := &SnapshotPublisher{
+		Uploader: HTTPUploader{URL: srv.URL + "/fleet/"},
+		Name:     "cache.msgpack",
+		Format:   CacheMsgpack,
+		Key:      private,
+	}
+	a.NoError(p.Publish(context.Background(), c))
+	sum := sha256.Sum256(objects["/fleet/cache.msgpack"])
+	a.Equal(hex.EncodeToString(sum[:])+"  cache.msgpack\n", string(objects["/fleet/cache.msgpack.sha256"]))
+
+	loaded := New("", "test", http.Client{})
+	a.NoError(BootstrapVerified(context.Background(), loaded, srv.Client(), srv.URL+"/fleet/cache.msgpack", CacheMsgpack, public))
+	a.Equal(2, loaded.Size())
+
+	other, _, err := ed25519.GenerateKey(nil)
+	a.NoError(err)
+	loaded = New("", "test", http.Client{})
+	a.ErrorIs(BootstrapVerified(context.Background(), loaded, srv.Client(), srv.URL+"/fleet/cache.msgpack", CacheMsgpack, other), ErrInvalidSignature)
+	a.Equal(0, loaded.Size())
+
+	objects["/fleet/cache.msgpack"] = append(objects["/fleet/cache.msgpack"], 0)
+	a.ErrorIs(BootstrapVerified(context.Background(), loaded, srv.Client(), srv.URL+"/fleet/cache.msgpack", CacheMsgpack, public), ErrInvalidSignature)
+}
@@ -0,0 +1,58 @@
+package sinkingyachts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//ValidateDomain checks if domain is a syntactically valid hostname
+//it must be at most 253 characters long and consist of at least two dot separated labels
+//each label must be 1 to 63 letters, digits or hyphens, and may not start or end with a hyphen
+//schemes, paths, ports and other url parts are rejected
+func ValidateDomain(domain string) error {
+	if domain == "" {
+		return errors.New("domain is empty")
+	}
+	if len(domain) > 253 {
+		return fmt.Errorf("domain is longer than 253 characters")
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("domain %q has no top level domain", domain)
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 {
+			return fmt.Errorf("domain %q has a label of invalid length", domain)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("domain %q has a label starting or ending with a hyphen", domain)
+		}
+		for i := 0; i < len(label); i++ {
+			ch := label[i]
+			if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-') {
+				return fmt.Errorf("domain %q contains invalid character %q", domain, ch)
+			}
+		}
+	}
+	return nil
+}
+
+//filterDomains removes invalid domains if strict validation is enabled
+//the underlying array of domains is reused
+func (c RawClient) filterDomains(domains []string) []string {
+	if !c.strict {
+		return domains
+	}
+	valid := domains[:0]
+	for _, domain := range domains {
+		if err := ValidateDomain(domain); err != nil {
+			if c.onInvalid != nil {
+				c.onInvalid(domain, err)
+			}
+			continue
+		}
+		valid = append(valid, domain)
+	}
+	return valid
+}
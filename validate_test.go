@@ -0,0 +1,35 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestValidateDomain(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{input: "example.com", valid: true},
+		{input: "foo-bar.example.com", valid: true},
+		{input: "xn--dscord-wmc.com", valid: true},
+		{input: "123.example.com", valid: true},
+		{input: "", valid: false},
+		{input: "com", valid: false},
+		{input: "https://example.com", valid: false},
+		{input: "example.com/path", valid: false},
+		{input: "example.com:443", valid: false},
+		{input: "-foo.example.com", valid: false},
+		{input: "foo..example.com", valid: false},
+		{input: "example.com.", valid: false},
+		{input: strings.Repeat("a", 64) + ".com", valid: false},
+		{input: strings.Repeat("a.", 127) + "com", valid: false},
+	}
+	for _, data := range tests {
+		t.Run(data.input, func(t *testing.T) {
+			err := ValidateDomain(data.input)
+			assert.Equal(t, data.valid, err == nil, err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package sinkingyachts
+
+import "strings"
+
+//VariantOption is a function that configures GenerateVariants
+type VariantOption func(o *variantOptions)
+
+type variantOptions struct {
+	suffix   func(domain string) (string, bool)
+	noWWW    bool
+	maxDepth int
+}
+
+//VariantPublicSuffix stops GenerateVariants at the public suffix of the domain, instead of only the top level domain
+//suffix returns the public suffix of a domain, publicsuffix.PublicSuffix of golang.org/x/net can be used as is
+//with it "foo.bar.co.uk" generates itself and "bar.co.uk" but not "co.uk"
+func VariantPublicSuffix(suffix func(domain string) (string, bool)) VariantOption {
+	return func(o *variantOptions) {
+		o.suffix = suffix
+	}
+}
+
+//VariantIncludeWWW sets if variants starting with a "www" label are generated, they are by default
+//lists rarely contain "www." domains, so excluding them saves a lookup
+func VariantIncludeWWW(include bool) VariantOption {
+	return func(o *variantOptions) {
+		o.noWWW = !include
+	}
+}
+
+//VariantMaxDepth limits variants to at most depth labels, deeper variants are skipped while their parents are still generated
+//a depth of 0 or less is unlimited
+func VariantMaxDepth(depth int) VariantOption {
+	return func(o *variantOptions) {
+		o.maxDepth = depth
+	}
+}
+
+//GenerateVariants generate variations of the domain and parent domains, from the most specific to the least specific
+//"foo.bar.bad.com" will generate itself, "bar.bad.com" and "bad.com" but not "com"
+//these are the domains FuzzyCheck looks up, so it can be used to write custom matchers that behave the same way
+//could have been optimized with callbacks or channels but this is simpler
+func GenerateVariants(domain string, opts ...VariantOption) []string {
+	var o variantOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var variants []string
+	parts := strings.Split(domain, ".")
+	stop := len(parts) - 1
+	if o.suffix != nil {
+		if suffix, _ := o.suffix(domain); suffix != "" {
+			stop = len(parts) - (strings.Count(suffix, ".") + 1)
+		}
+	}
+	for i := 0; i < stop; i++ {
+		if o.maxDepth > 0 && len(parts)-i > o.maxDepth {
+			continue
+		}
+		if o.noWWW && parts[i] == "www" {
+			continue
+		}
+		variants = append(variants, strings.Join(parts[i:], "."))
+	}
+	return variants
+}
@@ -0,0 +1,68 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestGenerateVariantsOptions(t *testing.T) {
+	//a tiny stand in for publicsuffix.PublicSuffix
+	suffix := func(domain string) (string, bool) {
+		for _, s := range []string{"co.uk", "github.io"} {
+			if domain == s || strings.HasSuffix(domain, "."+s) {
+				return s, true
+			}
+		}
+		return domain[strings.LastIndex(domain, ".")+1:], false
+	}
+	tests := []struct {
+		name     string
+		input    string
+		opts     []VariantOption
+		expected []string
+	}{
+		{
+			name:     "Public suffix",
+			input:    "foo.bar.co.uk",
+			opts:     []VariantOption{VariantPublicSuffix(suffix)},
+			expected: []string{"foo.bar.co.uk", "bar.co.uk"},
+		},
+		{
+			name:     "Public suffix only",
+			input:    "github.io",
+			opts:     []VariantOption{VariantPublicSuffix(suffix)},
+			expected: nil,
+		},
+		{
+			name:     "Public suffix of plain tld",
+			input:    "foo.example.com",
+			opts:     []VariantOption{VariantPublicSuffix(suffix)},
+			expected: []string{"foo.example.com", "example.com"},
+		},
+		{
+			name:     "Without www",
+			input:    "www.example.com",
+			opts:     []VariantOption{VariantIncludeWWW(false)},
+			expected: []string{"example.com"},
+		},
+		{
+			name:     "With www",
+			input:    "www.example.com",
+			opts:     []VariantOption{VariantIncludeWWW(true)},
+			expected: []string{"www.example.com", "example.com"},
+		},
+		{
+			name:     "Max depth",
+			input:    "a.b.c.example.com",
+			opts:     []VariantOption{VariantMaxDepth(3)},
+			expected: []string{"c.example.com", "example.com"},
+		},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(data.expected, GenerateVariants(data.input, data.opts...))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+//Cassette is a recording of api interactions, saved by Recorder and replayed by Replayer
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Interaction is a single recorded request and its response
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+//Recorder is a http.RoundTripper that records every interaction that passes through it into a Cassette
+//use it as the Transport of the http.Client given to New, then Save the recording to replay it in tests with Replayer
+//only plain requests are recorded, the websocket feed can't be recorded
+//request headers are not recorded, so the identity and any tokens don't end up in the recording
+type Recorder struct {
+	transport http.RoundTripper
+	cassette  Cassette
+	m         sync.Mutex
+}
+
+//NewRecorder creates a Recorder that makes requests with transport, nil uses http.DefaultTransport
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport}
+}
+
+//RoundTrip makes the request, and records it along with its response
+func (rec *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := rec.transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, Interaction{
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Status: resp.StatusCode,
+		Header: resp.Header.Clone(),
+		Body:   string(body),
+	})
+	return resp, nil
+}
+
+//Cassette returns a copy of the interactions recorded so far
+func (rec *Recorder) Cassette() Cassette {
+	rec.m.Lock()
+	defer rec.m.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), rec.cassette.Interactions...)}
+}
+
+//Save writes the interactions recorded so far into a json file at path
+func (rec *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(rec.Cassette(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+//Replayer is a http.RoundTripper that responds with the interactions of a Cassette, without making any request
+//requests are matched to interactions by method and path, ignoring the host, so recordings work against any endpoint
+//the seconds of the recent endpoint are also ignored, as they depend on when the request is made
+//matching interactions are replayed in recorded order, once they are all used the last one keeps being replayed
+type Replayer struct {
+	cassette Cassette
+	used     map[int]bool
+	m        sync.Mutex
+}
+
+//NewReplayer creates a Replayer of the cassette
+func NewReplayer(cassette Cassette) *Replayer {
+	return &Replayer{cassette: cassette, used: map[int]bool{}}
+}
+
+//LoadReplayer creates a Replayer of a cassette file saved by Recorder.Save
+func LoadReplayer(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err = json.Unmarshal(b, &cassette); err != nil {
+		return nil, err
+	}
+	return NewReplayer(cassette), nil
+}
+
+//RoundTrip responds with the next matching interaction, an error is returned if none match
+func (rep *Replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+	rep.m.Lock()
+	defer rep.m.Unlock()
+	last := -1
+	for i, in := range rep.cassette.Interactions {
+		if !interactionMatches(in, r) {
+			continue
+		}
+		last = i
+		if !rep.used[i] {
+			break
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("vcr: no recorded interaction for %s %s", r.Method, r.URL.Path)
+	}
+	rep.used[last] = true
+	in := rep.cassette.Interactions[last]
+	header := in.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(in.Body)),
+		ContentLength: int64(len(in.Body)),
+		Request:       r,
+	}, nil
+}
+
+//interactionMatches checks if the request matches the recorded interaction
+func interactionMatches(in Interaction, r *http.Request) bool {
+	if in.Method != r.Method {
+		return false
+	}
+	u, err := url.Parse(in.URL)
+	if err != nil {
+		return false
+	}
+	return matchPath(u.Path) == matchPath(r.URL.Path)
+}
+
+//matchPath returns the part of path that is matched by Replayer, dropping the seconds of the recent endpoint
+func matchPath(path string) string {
+	if i := strings.Index(path, endpointRecent); i >= 0 {
+		return path[:i+len(endpointRecent)]
+	}
+	return path
+}
@@ -0,0 +1,46 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestVCR(t *testing.T) {
+	a := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case endpointAll:
+			_, _ = w.Write([]byte(`["a.com","b.com"]`))
+		case endpointSize:
+			_, _ = w.Write([]byte(`2`))
+		default:
+			_, _ = w.Write([]byte(`[{"type":"add","domains":["c.com"]}]`))
+		}
+	}))
+
+	rec := NewRecorder(nil)
+	c := New(srv.URL, "test", http.Client{Transport: rec})
+	a.NoError(c.FullSync())
+	a.NoError(c.Update())
+	size, err := c.r.Size()
+	a.NoError(err)
+	a.Equal(2, size)
+	a.Len(rec.Cassette().Interactions, 3)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	a.NoError(rec.Save(path))
+	srv.Close()
+
+	rep, err := LoadReplayer(path)
+	a.NoError(err)
+	replayed := New("http://offline.invalid", "test", http.Client{Transport: rep})
+	a.NoError(replayed.FullSync())
+	a.NoError(replayed.Update())
+	//the recent interaction is reused once it's used up
+	a.NoError(replayed.Update())
+	a.ElementsMatch([]string{"a.com", "b.com", "c.com"}, replayed.Domains())
+	_, err = replayed.r.Check("a.com")
+	a.Error(err)
+}
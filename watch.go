@@ -0,0 +1,60 @@
+package sinkingyachts
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"time"
+)
+
+//watchSettle is how long the file must stay unchanged before it's reloaded
+//this coalesces the several events a single write usually produces
+const watchSettle = time.Millisecond * 100
+
+//Watch reloads the cache into Client whenever another process rewrites the file
+//this enables running a single syncing process that saves into the file, with many readers that only watch it
+//the directory is watched rather than the file, so atomic replacements such as FileStore.Save are picked up
+//reload errors, such as reading a partially written file, are passed to onError and retried on the next change, onError may be nil
+//this function blocks and returns only when cancelled by ctx, or when the watcher fails
+func (s *FileStore) Watch(ctx context.Context, c *Client, onError func(error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	err = w.Add(filepath.Dir(s.path))
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Clean(s.path)
+	settle := time.NewTimer(watchSettle)
+	if !settle.Stop() {
+		<-settle.C
+	}
+	defer settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(ev.Name) != name || !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+				continue
+			}
+			settle.Reset(watchSettle)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-settle.C:
+			err = s.Load(c)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package sinkingyachts
+
+import (
+	"encoding/json"
+	"io"
+)
+
+//Watchlist is a user managed list of suspicious tlds and keywords used by Scorer
+type Watchlist struct {
+	//TLDs are suspicious top level domains, without the leading dot
+	TLDs []string `json:"tlds"`
+	//Keywords are suspicious phrases, such as "free-nitro" or "steamgift"
+	Keywords []string `json:"keywords"`
+}
+
+//ReadWatchlistFrom loads a stored Watchlist from the reader
+func ReadWatchlistFrom(r io.Reader) (Watchlist, error) {
+	var wl Watchlist
+	err := json.NewDecoder(r).Decode(&wl)
+	return wl, err
+}
+
+//WriteWatchlistInto saves a Watchlist into the writer.
+func WriteWatchlistInto(wl Watchlist, w io.Writer) error {
+	if s, ok := w.(io.Seeker); ok {
+		_, err := s.Seek(0, 0)
+		if err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(wl)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
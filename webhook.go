@@ -0,0 +1,106 @@
+package sinkingyachts
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//WebhookSignatureHeader is the header carrying the hex encoded HMAC-SHA256 of the timestamp and the request body
+//the value is in the form of "sha256=<hex>"
+const WebhookSignatureHeader = "X-Signature-256"
+
+//WebhookTimestampHeader is the header carrying when the request was signed, in unix seconds
+//it's signed along with the body, so a captured request can't be replayed once it's older than webhookTolerance
+const WebhookTimestampHeader = "X-Signature-Timestamp"
+
+//webhookMaxBody is the maximum accepted size of a webhook payload
+const webhookMaxBody = 32 << 20
+
+//webhookTolerance is how far the timestamp of a webhook request may be from the current time
+const webhookTolerance = time.Minute * 5
+
+//WebhookHandler returns a http.Handler that accepts pushed updates and applies them to Client
+//this is useful when outbound websockets are impossible but an upstream relay can push updates
+//the body must be a single update or an array of updates in the api's format, sent with POST
+//requests must be signed with secret and carry the time they were signed, see SignWebhook
+//requests signed more than 5 minutes away from now are rejected, an empty secret rejects every request
+func WebhookHandler(c *Client, secret []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBody))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !verifyWebhook(secret, body, r.Header.Get(WebhookTimestampHeader), r.Header.Get(WebhookSignatureHeader), time.Now()) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		mods, err := parseWebhook(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.ApplyUpdates(mods, SourceWebhook)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+//SignWebhook returns the value of WebhookSignatureHeader for body signed with secret at the time at
+//the request must carry at as the value of WebhookTimestampHeader, formatted by WebhookTimestamp
+func SignWebhook(secret []byte, at time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(WebhookTimestamp(at) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+//WebhookTimestamp returns the value of WebhookTimestampHeader for a request signed at the time at
+func WebhookTimestamp(at time.Time) string {
+	return strconv.FormatInt(at.Unix(), 10)
+}
+
+//verifyWebhook checks if signature matches body signed with secret at timestamp, and that timestamp is close to now
+func verifyWebhook(secret, body []byte, timestamp, signature string, now time.Time) bool {
+	if len(secret) == 0 || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	at := time.Unix(unix, 0)
+	if at.Before(now.Add(-webhookTolerance)) || at.After(now.Add(webhookTolerance)) {
+		return false
+	}
+	return hmac.Equal([]byte(SignWebhook(secret, at, body)), []byte(signature))
+}
+
+//parseWebhook parse a webhook body of either a single update, or an array of updates
+func parseWebhook(body []byte) ([]DomainUpdate, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var mods []DomainUpdate
+		err := json.Unmarshal(body, &mods)
+		return mods, err
+	}
+	var mod DomainUpdate
+	err := json.Unmarshal(body, &mod)
+	if err != nil {
+		return nil, err
+	}
+	return []DomainUpdate{mod}, nil
+}
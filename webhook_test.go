@@ -0,0 +1,103 @@
+package sinkingyachts
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	stale := now.Add(-time.Minute * 10)
+	tests := []struct {
+		name      string
+		secret    []byte
+		body      string
+		timestamp string
+		signature string
+		status    int
+		expected  int
+	}{
+		{
+			name:      "Single",
+			body:      `{"type":"add","domains":["a.com","b.com"]}`,
+			signature: SignWebhook(secret, now, []byte(`{"type":"add","domains":["a.com","b.com"]}`)),
+			status:    http.StatusNoContent,
+			expected:  2,
+		},
+		{
+			name:      "Array",
+			body:      `[{"type":"add","domains":["a.com","b.com"]},{"type":"delete","domains":["a.com"]}]`,
+			signature: SignWebhook(secret, now, []byte(`[{"type":"add","domains":["a.com","b.com"]},{"type":"delete","domains":["a.com"]}]`)),
+			status:    http.StatusNoContent,
+			expected:  1,
+		},
+		{
+			name:      "Bad Signature",
+			body:      `{"type":"add","domains":["a.com"]}`,
+			signature: SignWebhook([]byte("wrong"), now, []byte(`{"type":"add","domains":["a.com"]}`)),
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "Invalid Type",
+			body:      `{"type":"nope","domains":["a.com"]}`,
+			signature: SignWebhook(secret, now, []byte(`{"type":"nope","domains":["a.com"]}`)),
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Stale",
+			body:      `{"type":"add","domains":["a.com"]}`,
+			timestamp: WebhookTimestamp(stale),
+			signature: SignWebhook(secret, stale, []byte(`{"type":"add","domains":["a.com"]}`)),
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "Changed Timestamp",
+			body:      `{"type":"add","domains":["a.com"]}`,
+			timestamp: WebhookTimestamp(now.Add(-time.Minute)),
+			signature: SignWebhook(secret, now, []byte(`{"type":"add","domains":["a.com"]}`)),
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "Missing Timestamp",
+			body:      `{"type":"add","domains":["a.com"]}`,
+			timestamp: "-",
+			signature: SignWebhook(secret, now, []byte(`{"type":"add","domains":["a.com"]}`)),
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "Empty Secret",
+			secret:    []byte{},
+			body:      `{"type":"add","domains":["a.com"]}`,
+			signature: SignWebhook(nil, now, []byte(`{"type":"add","domains":["a.com"]}`)),
+			status:    http.StatusUnauthorized,
+		},
+	}
+	for _, data := range tests {
+		t.Run(data.name, func(t *testing.T) {
+			a := assert.New(t)
+			c := New("", "test", http.Client{})
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.body))
+			req.Header.Set(WebhookSignatureHeader, data.signature)
+			switch data.timestamp {
+			case "":
+				req.Header.Set(WebhookTimestampHeader, WebhookTimestamp(now))
+			case "-":
+			default:
+				req.Header.Set(WebhookTimestampHeader, data.timestamp)
+			}
+			key := secret
+			if data.secret != nil {
+				key = data.secret
+			}
+			rec := httptest.NewRecorder()
+			WebhookHandler(c, key).ServeHTTP(rec, req)
+			a.Equal(data.status, rec.Code)
+			a.Equal(data.expected, c.Size())
+		})
+	}
+}